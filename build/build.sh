@@ -45,6 +45,11 @@ if [ -z "${OS}" ]; then
     echo "OS must be set"
     exit 1
 fi
+# Without the public key a release could not verify its own updates
+if [ "${RELEASE:-false}" == "true" ] && [ -z "${UPDATE_PUBLIC_KEY:-}" ]; then
+    echo "UPDATE_PUBLIC_KEY must be set for release builds"
+    exit 1
+fi
 
 export CGO_ENABLED=0
 export GOARCH="${ARCH}"
@@ -54,7 +59,7 @@ export GOOS="${OS}"
 unset GOBIN
 export GOOUT="${GOPATH}/bin/${OS}_${ARCH}"
 go build                                                         \
-    -ldflags "-X \"${PKG}/pkg/config.Version=${VERSION}\" -X \"${PKG}/pkg/config.Branch=${BRANCH}\" -X \"${PKG}/pkg/config.BuildStamp=${BUILDSTAMP}\" -X \"${PKG}/pkg/config.GitHash=${GITHASH}\"" \
+    -ldflags "-X \"${PKG}/pkg/config.Version=${VERSION}\" -X \"${PKG}/pkg/config.Branch=${BRANCH}\" -X \"${PKG}/pkg/config.BuildStamp=${BUILDSTAMP}\" -X \"${PKG}/pkg/config.GitHash=${GITHASH}\" -X \"${PKG}/pkg/config.UpdatePublicKey=${UPDATE_PUBLIC_KEY:-}\"" \
     -gcflags='-B -l' \
     -pkgdir=${GOPATH}/pkg \
     -o=${GOOUT} \
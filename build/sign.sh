@@ -0,0 +1,31 @@
+#!/bin/bash
+
+# Signs files published on the update server. The base64 encoded ed25519 signature of each file is written
+# to <file>.sig, which ao verifies with the public key it is built with.
+#
+# UPDATE_SIGNING_KEY is a PEM file with the ed25519 private key, and UPDATE_PUBLIC_KEY the base64 encoded
+# public key passed to build.sh. Signing fails if they do not belong together.
+
+set -o errexit
+set -o nounset
+set -o pipefail
+
+if [ -z "${UPDATE_SIGNING_KEY:-}" ]; then
+    echo "UPDATE_SIGNING_KEY must be set"
+    exit 1
+fi
+if [ -z "${UPDATE_PUBLIC_KEY:-}" ]; then
+    echo "UPDATE_PUBLIC_KEY must be set"
+    exit 1
+fi
+
+# The raw ed25519 public key is the last 32 bytes of the DER encoded key
+PUBLIC_KEY=$(openssl pkey -in "${UPDATE_SIGNING_KEY}" -pubout -outform DER | tail -c 32 | base64)
+if [ "${PUBLIC_KEY}" != "${UPDATE_PUBLIC_KEY}" ]; then
+    echo "UPDATE_SIGNING_KEY does not match UPDATE_PUBLIC_KEY"
+    exit 1
+fi
+
+for FILE in "$@"; do
+    openssl pkeyutl -sign -inkey "${UPDATE_SIGNING_KEY}" -rawin -in "${FILE}" | base64 | tr -d '\n' > "${FILE}.sig"
+done
@@ -3,16 +3,23 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+
+	"github.com/mitchellh/go-homedir"
 
 	"github.com/skatteetaten/ao/pkg/versioncontrol"
 
+	"github.com/sirupsen/logrus"
 	"github.com/skatteetaten/ao/pkg/config"
 	"github.com/spf13/cobra"
 )
 
 var flagShowAll bool
+var flagClustersVerbose bool
 var flagAddCluster []string
 var flagBetaMultipleClusterTypes bool
+var flagCredentialFile string
+var flagCredentialHelper string
 
 var admCmd = &cobra.Command{
 	Use:   "adm",
@@ -20,10 +27,9 @@ var admCmd = &cobra.Command{
 }
 
 var getClusterCmd = &cobra.Command{
-	Use:     "clusters",
-	Short:   "List configured clusters",
-	Aliases: []string{"cluster"},
-	Run:     printClusters,
+	Use:   "clusters",
+	Short: "List configured clusters",
+	Run:   printClusters,
 }
 
 var getAffiliationCmd = &cobra.Command{
@@ -35,25 +41,65 @@ var getAffiliationCmd = &cobra.Command{
 var updateClustersCmd = &cobra.Command{
 	Use:   "update-clusters",
 	Short: "Will recreate clusters in config file",
-	RunE:  UpdateClusters,
+	Long: `Will recreate clusters in config file.
+The cluster list is updated from the signed cluster catalogue on the update server, if available.
+Clusters added with "ao adm cluster add" are kept.`,
+	RunE: UpdateClusters,
+	Annotations: map[string]string{
+		annotationLockConfig: "true",
+	},
 }
 
 var recreateConfigCmd = &cobra.Command{
 	Use:   "recreate-config",
 	Short: `The command will recreate the .ao.json file.`,
 	RunE:  RecreateConfig,
+	Annotations: map[string]string{
+		annotationLockConfig: "true",
+	},
 }
 
 var updateHookCmd = &cobra.Command{
 	Use:   "update-hook <auroraconfig>",
 	Short: `Update or create git hook to validate AuroraConfig.`,
-	RunE:  UpdateGitHook,
+	Long: `Update or create a git hook to validate AuroraConfig.
+The pre-commit hook checks the staged files, and the pre-push hook checks the files changed by the pushed commits.`,
+	RunE: UpdateGitHook,
 }
 
 var updateRefCmd = &cobra.Command{
 	Use:   "update-ref <refName>",
 	Short: `Update git ref for your auroraconfig checkout.`,
 	RunE:  SetRefName,
+	Annotations: map[string]string{
+		annotationLockConfig: "true",
+	},
+}
+
+var updateChannelCmd = &cobra.Command{
+	Use:   "update-channel <stable|beta>",
+	Short: "Select the release channel used by ao update",
+	RunE:  SetUpdateChannel,
+	Annotations: map[string]string{
+		annotationLockConfig: "true",
+	},
+}
+
+var credentialStoreCmd = &cobra.Command{
+	Use:   "credential-store <plaintext|keyring|file|helper>",
+	Short: "Select where tokens are stored",
+	Long: `Select where tokens are stored. Tokens are stored in plaintext in the config file by default.
+
+  keyring    the Secret Service keyring on Linux, using secret-tool
+  file       a file encrypted with a passphrase, given by AO_CREDENTIAL_PASSPHRASE or prompted for
+  helper     an executable called with get, store or erase, like a git credential helper
+
+Tokens stay in plaintext until a store is selected here. Then existing tokens are moved to the selected store,
+and the config file only holds references to them. A token is only read from the store when its cluster is used.`,
+	RunE: SetCredentialStore,
+	Annotations: map[string]string{
+		annotationLockConfig: "true",
+	},
 }
 
 const (
@@ -114,12 +160,19 @@ func init() {
 	admCmd.AddCommand(updateClustersCmd)
 	admCmd.AddCommand(updateHookCmd)
 	admCmd.AddCommand(updateRefCmd)
+	admCmd.AddCommand(credentialStoreCmd)
+	admCmd.AddCommand(updateChannelCmd)
 
 	getClusterCmd.Flags().BoolVarP(&flagShowAll, "all", "a", false, "Show all clusters, not just the reachable ones")
+	getClusterCmd.Flags().BoolVarP(&flagClustersVerbose, "verbose", "v", false, "Show the status of each endpoint when the clusters were last checked")
 	recreateConfigCmd.Flags().BoolVarP(&flagBetaMultipleClusterTypes, "beta-multiple-cluster-types", "", false, "Generate new config for multiple cluster types. Eks ocp3, ocp4")
 	recreateConfigCmd.Flags().StringVarP(&flagCluster, "cluster", "c", "", "Recreate config with one cluster")
 	recreateConfigCmd.Flags().StringArrayVarP(&flagAddCluster, "add-cluster", "a", []string{}, "Add cluster to available clusters")
-	updateHookCmd.Flags().StringVarP(&flagGitHookType, "git-hook", "g", "pre-push", "Change git hook to validate AuroraConfig")
+	credentialStoreCmd.Flags().StringVarP(&flagCredentialFile, "file", "", "", "Encrypted credential file (default ~/.ao-credentials)")
+	credentialStoreCmd.Flags().StringVarP(&flagCredentialHelper, "helper", "", "", "Credential helper command")
+	updateHookCmd.Flags().StringVarP(&flagGitHookType, "type", "", versioncontrol.HookPrePush, "Git hook to validate AuroraConfig with [pre-commit, pre-push]")
+	updateHookCmd.Flags().StringVarP(&flagGitHookType, "git-hook", "g", versioncontrol.HookPrePush, "Change git hook to validate AuroraConfig")
+	updateHookCmd.Flags().MarkDeprecated("git-hook", "use --type instead")
 }
 
 // PrintClusters is the main method for the `adm clusters` cli command
@@ -137,7 +190,9 @@ func PrintClusters(cmd *cobra.Command, printAll bool) {
 		}
 
 		loggedIn := ""
-		if cluster.HasValidToken() {
+		if err := AO.ResolveClusterToken(name); err != nil {
+			logrus.Debug(err)
+		} else if cluster.HasValidToken() {
 			loggedIn = "Yes"
 		}
 
@@ -158,8 +213,29 @@ func PrintClusters(cmd *cobra.Command, printAll bool) {
 	DefaultTablePrinter(header, rows, cmd.OutOrStdout())
 }
 
+// PrintClusterEndpoints prints the status of each endpoint of the clusters, from when they were last checked
+func PrintClusterEndpoints(cmd *cobra.Command, printAll bool) {
+	var rows []string
+	for _, name := range AO.AvailableClusters {
+		cluster := AO.Clusters[name]
+		if cluster == nil || !(cluster.Reachable || printAll) {
+			continue
+		}
+		for _, endpoint := range cluster.Endpoints {
+			rows = append(rows, fmt.Sprintf("\t%s\t%s", name, getEndpointStatusRow(endpoint)))
+		}
+	}
+
+	header := "\tCLUSTER NAME\tENDPOINT\tURL\tSTATUS\tLATENCY\tERROR"
+	DefaultTablePrinter(header, rows, cmd.OutOrStdout())
+}
+
 func printClusters(cmd *cobra.Command, args []string) {
 	PrintClusters(cmd, flagShowAll)
+	if flagClustersVerbose {
+		cmd.Println()
+		PrintClusterEndpoints(cmd, flagShowAll)
+	}
 }
 
 // SetRefName is the entry point for the `adm update-ref` cli command
@@ -177,6 +253,53 @@ func SetRefName(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// SetUpdateChannel is the entry point for the `adm update-channel` cli command
+func SetUpdateChannel(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Usage()
+	}
+
+	if err := AO.SetUpdateChannel(args[0]); err != nil {
+		return err
+	}
+	if err := config.WriteConfig(*AO, ConfigLocation); err != nil {
+		return err
+	}
+
+	cmd.Printf("updateChannel = %s\n", args[0])
+	return nil
+}
+
+// SetCredentialStore is the entry point for the `adm credential-store` cli command
+func SetCredentialStore(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Usage()
+	}
+
+	storeConfig := &config.CredentialStoreConfig{
+		Type:   args[0],
+		Helper: flagCredentialHelper,
+		File:   flagCredentialFile,
+	}
+	if storeConfig.Type == config.CredentialStoreFile && storeConfig.File == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return err
+		}
+		storeConfig.File = filepath.Join(home, ".ao-credentials")
+	}
+
+	if err := AO.SetCredentialStore(storeConfig); err != nil {
+		return err
+	}
+	if err := config.WriteConfig(*AO, ConfigLocation); err != nil {
+		return err
+	}
+
+	cmd.Printf("credentialStore = %s\n", args[0])
+	return nil
+}
+
 // UpdateClusters is the entry point for the `update-clusters` cli command
 func UpdateClusters(cmd *cobra.Command, args []string) error {
 	AO.InitClusters()
@@ -184,9 +307,11 @@ func UpdateClusters(cmd *cobra.Command, args []string) error {
 	return config.WriteConfig(*AO, ConfigLocation)
 }
 
-// RecreateConfig is the entry point for the `adm recreate-config` cli command
+// RecreateConfig is the entry point for the `adm recreate-config` cli command.
+// The credential store is kept, so that tokens from the next login are stored where the user chose.
 func RecreateConfig(cmd *cobra.Command, args []string) error {
 	conf := &config.DefaultAOConfig
+	conf.CredentialStore = AO.CredentialStore
 	if flagCluster != "" {
 		conf.AvailableClusters = []string{flagCluster}
 		conf.PreferredAPIClusters = []string{flagCluster}
@@ -200,6 +325,9 @@ func RecreateConfig(cmd *cobra.Command, args []string) error {
 
 	conf.InitClusters()
 	conf.SelectAPICluster()
+	if _, err := conf.Migrate(); err != nil {
+		return err
+	}
 	return config.WriteConfig(*conf, ConfigLocation)
 }
 
@@ -243,10 +371,18 @@ func Completion(cmd *cobra.Command, args []string) error {
 // UpdateGitHook is the entry point for the `adm update-hook` cli command
 func UpdateGitHook(cmd *cobra.Command, args []string) error {
 
+	if len(args) != 1 {
+		return cmd.Usage()
+	}
+
 	wd, _ := os.Getwd()
 	gitPath, err := versioncontrol.FindGitPath(wd)
 	if err != nil {
 		return err
 	}
-	return versioncontrol.CreateGitValidateHook(gitPath, flagGitHookType, args[0])
+	if err := versioncontrol.CreateGitValidateHook(gitPath, flagGitHookType, args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Created %s hook to validate AuroraConfig %s\n", flagGitHookType, args[0])
+	return nil
 }
@@ -0,0 +1,243 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/skatteetaten/ao/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+const (
+	clusterAddLong    = `Add a cluster to the ao config. The service urls of the cluster are given by the url patterns of its type.`
+	urlPatternAddLong = `Add service url patterns for a cluster type. %s in a pattern is replaced by the cluster name,
+or by the cluster url prefix of the cluster for the cluster and cluster login patterns.`
+	urlPatternAddExample = `ao adm url-pattern add private --cluster-url 'https://api.%s.example.com:6443' \
+  --boober-url 'https://boober.apps.%s.example.com' --gobo-url 'https://gobo.apps.%s.example.com'`
+)
+
+var (
+	flagClusterType         string
+	flagClusterURLPrefix    string
+	flagClusterAPI          bool
+	flagClusterUpdate       bool
+	flagClusterURLPattern   string
+	flagClusterLoginPattern string
+	flagBooberURLPattern    string
+	flagGoboURLPattern      string
+	flagUpdateURLPattern    string
+)
+
+var lockConfigAnnotations = map[string]string{annotationLockConfig: "true"}
+
+var clusterCmd = &cobra.Command{
+	Use:   "cluster",
+	Short: "List and manage configured clusters",
+	Run:   printClusters,
+}
+
+var clusterAddCmd = &cobra.Command{
+	Use:         "add <name>",
+	Short:       "Add a cluster",
+	Long:        clusterAddLong,
+	Annotations: lockConfigAnnotations,
+	RunE:        AddCluster,
+}
+
+var clusterRemoveCmd = &cobra.Command{
+	Use:         "remove <name>",
+	Short:       "Remove a cluster",
+	Annotations: lockConfigAnnotations,
+	RunE:        RemoveCluster,
+}
+
+var clusterSetTypeCmd = &cobra.Command{
+	Use:         "set-type <name> <type>",
+	Short:       "Change the type of a cluster",
+	Annotations: lockConfigAnnotations,
+	RunE:        SetClusterType,
+}
+
+var clusterTestCmd = &cobra.Command{
+	Use:   "test <name>",
+	Short: "Check that the endpoints of a cluster are reachable",
+	RunE:  TestCluster,
+}
+
+var urlPatternCmd = &cobra.Command{
+	Use:   "url-pattern",
+	Short: "Manage service url patterns for cluster types",
+	Run:   printURLPatterns,
+}
+
+var urlPatternAddCmd = &cobra.Command{
+	Use:         "add <type>",
+	Short:       "Add service url patterns for a cluster type",
+	Long:        urlPatternAddLong,
+	Example:     urlPatternAddExample,
+	Annotations: lockConfigAnnotations,
+	RunE:        AddURLPattern,
+}
+
+func init() {
+	admCmd.AddCommand(clusterCmd)
+	clusterCmd.AddCommand(clusterAddCmd)
+	clusterCmd.AddCommand(clusterRemoveCmd)
+	clusterCmd.AddCommand(clusterSetTypeCmd)
+	clusterCmd.AddCommand(clusterTestCmd)
+	admCmd.AddCommand(urlPatternCmd)
+	urlPatternCmd.AddCommand(urlPatternAddCmd)
+
+	clusterCmd.Flags().BoolVarP(&flagShowAll, "all", "a", false, "Show all clusters, not just the reachable ones")
+	clusterCmd.Flags().BoolVarP(&flagClustersVerbose, "verbose", "v", false, "Show the status of each endpoint when the clusters were last checked")
+
+	clusterAddCmd.Flags().StringVarP(&flagClusterType, "type", "", "", "Cluster type with url patterns (required)")
+	clusterAddCmd.Flags().StringVarP(&flagClusterURLPrefix, "url-prefix", "", "", "Use instead of the cluster name in cluster and cluster login urls")
+	clusterAddCmd.Flags().BoolVarP(&flagClusterAPI, "api", "", false, "Prefer the cluster as API cluster")
+	clusterAddCmd.Flags().BoolVarP(&flagClusterUpdate, "update", "", false, "Use the cluster as update server")
+	clusterAddCmd.MarkFlagRequired("type")
+
+	urlPatternAddCmd.Flags().StringVarP(&flagClusterURLPattern, "cluster-url", "", "", "OpenShift API url pattern (required)")
+	urlPatternAddCmd.Flags().StringVarP(&flagClusterLoginPattern, "cluster-login-url", "", "", "OpenShift OAuth url pattern, if different from the API url")
+	urlPatternAddCmd.Flags().StringVarP(&flagBooberURLPattern, "boober-url", "", "", "Boober url pattern (required)")
+	urlPatternAddCmd.Flags().StringVarP(&flagGoboURLPattern, "gobo-url", "", "", "Gobo url pattern (required)")
+	urlPatternAddCmd.Flags().StringVarP(&flagUpdateURLPattern, "update-url", "", "", "ao update server url pattern")
+}
+
+// AddCluster is the entry point for the `adm cluster add` cli command
+func AddCluster(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Usage()
+	}
+
+	cluster, err := AO.AddCluster(args[0], config.ClusterOptions{
+		Type:             flagClusterType,
+		ClusterURLPrefix: flagClusterURLPrefix,
+		APICluster:       flagClusterAPI,
+		UpdateCluster:    flagClusterUpdate,
+	})
+	if err != nil {
+		return err
+	}
+	if err := config.WriteConfig(*AO, ConfigLocation); err != nil {
+		return err
+	}
+
+	cmd.Printf("Added cluster %s, reachable: %t\n", cluster.Name, cluster.Reachable)
+	if flagClusterAPI {
+		cmd.Printf("apiCluster = %s\n", AO.APICluster)
+	}
+	return nil
+}
+
+// RemoveCluster is the entry point for the `adm cluster remove` cli command
+func RemoveCluster(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Usage()
+	}
+
+	if err := AO.RemoveCluster(args[0]); err != nil {
+		return err
+	}
+	if err := config.WriteConfig(*AO, ConfigLocation); err != nil {
+		return err
+	}
+
+	cmd.Printf("Removed cluster %s\n", args[0])
+	return nil
+}
+
+// SetClusterType is the entry point for the `adm cluster set-type` cli command
+func SetClusterType(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return cmd.Usage()
+	}
+
+	cluster, err := AO.SetClusterType(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if err := config.WriteConfig(*AO, ConfigLocation); err != nil {
+		return err
+	}
+
+	cmd.Printf("Cluster %s is of type %s, reachable: %t\n", cluster.Name, args[1], cluster.Reachable)
+	return nil
+}
+
+// TestCluster is the entry point for the `adm cluster test` cli command
+func TestCluster(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Usage()
+	}
+
+	checks, err := AO.TestCluster(args[0])
+	if err != nil {
+		return err
+	}
+
+	if failed := printEndpointChecks(checks, cmd.OutOrStdout()); failed > 0 {
+		return errors.Errorf("cluster %s is not reachable", args[0])
+	}
+	return nil
+}
+
+// AddURLPattern is the entry point for the `adm url-pattern add` cli command
+func AddURLPattern(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Usage()
+	}
+
+	err := AO.AddURLPattern(args[0], config.ServiceURLPatterns{
+		ClusterURLPattern:      flagClusterURLPattern,
+		ClusterLoginURLPattern: flagClusterLoginPattern,
+		BooberURLPattern:       flagBooberURLPattern,
+		GoboURLPattern:         flagGoboURLPattern,
+		UpdateURLPattern:       flagUpdateURLPattern,
+	})
+	if err != nil {
+		return err
+	}
+	if err := config.WriteConfig(*AO, ConfigLocation); err != nil {
+		return err
+	}
+
+	cmd.Printf("Added url patterns for cluster type %s\n", args[0])
+	return nil
+}
+
+func printURLPatterns(cmd *cobra.Command, args []string) {
+	var rows []string
+	for _, clusterType := range AO.ClusterTypes() {
+		patterns := AO.ServiceURLPatterns[clusterType]
+		rows = append(rows, fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s", clusterType, patterns.ClusterURLPattern,
+			patterns.ClusterLoginURLPattern, patterns.BooberURLPattern, patterns.GoboURLPattern, patterns.UpdateURLPattern))
+	}
+	DefaultTablePrinter("TYPE\tCLUSTER\tCLUSTER LOGIN\tBOOBER\tGOBO\tUPDATE", rows, cmd.OutOrStdout())
+}
+
+// printEndpointChecks prints the result of each check, and returns the number of failed checks
+func printEndpointChecks(checks []config.EndpointStatus, out io.Writer) int {
+	failed := 0
+	var rows []string
+	for _, check := range checks {
+		if !check.OK() {
+			failed++
+		}
+		rows = append(rows, "\t"+getEndpointStatusRow(check))
+	}
+	DefaultTablePrinter("\tENDPOINT\tURL\tSTATUS\tLATENCY\tERROR", rows, out)
+	return failed
+}
+
+func getEndpointStatusRow(endpoint config.EndpointStatus) string {
+	status := "OK"
+	if !endpoint.OK() {
+		status = endpoint.ErrorType
+	}
+	if endpoint.StatusCode != 0 {
+		status = fmt.Sprintf("%s %d", status, endpoint.StatusCode)
+	}
+	return fmt.Sprintf("%s\t%s\t%s\t%dms\t%s", endpoint.Endpoint, endpoint.URL, status, endpoint.LatencyMs, endpoint.Error)
+}
@@ -3,8 +3,12 @@ package cmd
 import (
 	"bytes"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
+	"github.com/skatteetaten/ao/pkg/config"
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -36,3 +40,57 @@ func TestPrintClusters(t *testing.T) {
 		assert.Equal(t, string(data), buffer.String())
 	}
 }
+
+func TestPrintClusterEndpoints(t *testing.T) {
+	AO = GetDefaultAOConfig()
+	AO.Clusters["utv"].Endpoints = []config.EndpointStatus{
+		{Endpoint: config.EndpointBoober, URL: "http://boober.utv", StatusCode: 200, LatencyMs: 12},
+		{Endpoint: config.EndpointGobo, URL: "http://gobo.utv", StatusCode: 502, LatencyMs: 30, ErrorType: config.ErrorTypeHTTP, Error: "server error 502 Bad Gateway"},
+	}
+	AO.Clusters["test"].Endpoints = []config.EndpointStatus{
+		{Endpoint: config.EndpointBoober, URL: "http://boober.test", LatencyMs: 3, ErrorType: config.ErrorTypeDNS, Error: "no such host"},
+	}
+
+	buffer := &bytes.Buffer{}
+	testCommand.SetOutput(buffer)
+	PrintClusterEndpoints(testCommand, false)
+
+	assert.Contains(t, buffer.String(), "utv            boober     http://boober.utv   OK 200     12ms")
+	assert.Contains(t, buffer.String(), "http 502")
+	assert.NotContains(t, buffer.String(), "boober.test")
+
+	buffer.Reset()
+	PrintClusterEndpoints(testCommand, true)
+	assert.Contains(t, buffer.String(), "dns")
+}
+
+func TestConfigWritingCommandsLockConfig(t *testing.T) {
+	for _, cmd := range []*cobra.Command{
+		updateClustersCmd, recreateConfigCmd, updateRefCmd, updateChannelCmd, credentialStoreCmd,
+		contextUseCmd, contextCreateCmd, contextDeleteCmd, loginCmd, logoutCmd,
+		clusterAddCmd, clusterRemoveCmd, clusterSetTypeCmd, urlPatternAddCmd,
+	} {
+		t.Run(cmd.CommandPath(), func(t *testing.T) {
+			_, locked := cmd.Annotations[annotationLockConfig]
+			assert.True(t, locked)
+		})
+	}
+}
+
+func TestRecreateConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao-recreate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer func(location string) { ConfigLocation = location }(ConfigLocation)
+	defer func() { config.DefaultAOConfig.CredentialStore = nil }()
+
+	ConfigLocation = filepath.Join(dir, ".ao.json")
+	AO = GetDefaultAOConfig()
+	AO.CredentialStore = &config.CredentialStoreConfig{Type: config.CredentialStoreFile, File: filepath.Join(dir, ".ao-credentials")}
+
+	assert.NoError(t, RecreateConfig(recreateConfigCmd, []string{}))
+
+	recreated, err := config.LoadConfigFile(ConfigLocation)
+	assert.NoError(t, err)
+	assert.Equal(t, AO.CredentialStore, recreated.CredentialStore)
+}
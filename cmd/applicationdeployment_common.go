@@ -57,6 +57,20 @@ func newDeploySpecPartition(deploySpecs []deploymentspec.DeploymentSpec, cluster
 	}
 }
 
+// resolveDeploySpecTokens reads the tokens of the clusters the deploy specs are deployed to from the credential store,
+// unless the token is overridden
+func resolveDeploySpecTokens(overrideToken string, deploySpecs []deploymentspec.DeploymentSpec) error {
+	if overrideToken != "" || config.CI {
+		return nil
+	}
+	for _, spec := range deploySpecs {
+		if err := AO.ResolveClusterToken(spec.Cluster()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func createDeploySpecPartitions(auroraConfig, overrideToken string, clusters map[string]*config.Cluster, deploySpecs []deploymentspec.DeploymentSpec) ([]DeploySpecPartition, error) {
 	type deploySpecPartitionID struct {
 		envName, clusterName string
@@ -104,6 +118,11 @@ func getApplicationDeploymentClient(partition Partition) client.ApplicationDeplo
 			token = partition.OverrideToken
 		}
 		cli = client.NewAPIClient(partition.Cluster.BooberURL, partition.Cluster.GoboURL, token, partition.AuroraConfigName, DefaultAPIClient.RefName, DefaultAPIClient.Korrelasjonsid)
+		cli.TLSConfig = partition.Cluster.TLSConfig()
+		if DefaultAPIClient.Transport != nil {
+			// Calls to all clusters share the retries of the command
+			cli.Transport = DefaultAPIClient.Transport.WithTLSConfig(cli.TLSConfig)
+		}
 	}
 
 	return cli
@@ -93,7 +93,7 @@ func deleteApplicationDeployment(cmd *cobra.Command, args []string) error {
 		auroraConfigName = flagAuroraConfig
 	}
 
-	apiClient, err := getAPIClient(auroraConfigName, pFlagToken, flagCluster)
+	apiClient, err := getAPIClient(auroraConfigName, AO.TokenOverride(), flagCluster)
 	if err != nil {
 		return err
 	}
@@ -110,18 +110,22 @@ func deleteApplicationDeployment(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	deployInfos, err := getDeployedApplications(getApplicationDeploymentClient, filteredDeploymentSpecs, auroraConfigName, pFlagToken)
+	deployInfos, err := getDeployedApplications(getApplicationDeploymentClient, filteredDeploymentSpecs, auroraConfigName, AO.TokenOverride())
 	if err != nil {
 		return err
 	} else if len(deployInfos) == 0 {
 		return errors.New("No applications to delete")
 	}
 
-	partitions, err := createDeploymentPartitions(auroraConfigName, pFlagToken, AO.Clusters, deployInfos)
+	partitions, err := createDeploymentPartitions(auroraConfigName, AO.TokenOverride(), AO.Clusters, deployInfos)
 	if err != nil {
 		return err
 	}
 
+	if config.CI && !flagNoPrompt {
+		return errors.New("delete asks for confirmation, use --no-prompt in CI mode")
+	}
+
 	if !getDeleteConfirmation(flagNoPrompt, deployInfos, cmd.OutOrStdout()) {
 		return errors.New("No applications to delete")
 	}
@@ -19,6 +19,9 @@ func newPartialExistsResults(partition DeploySpecPartition, existsResults client
 }
 
 func getDeployedApplications(getClient func(partition Partition) client.ApplicationDeploymentClient, deploySpecs []deploymentspec.DeploymentSpec, auroraConfigName, overrideToken string) ([]DeploymentInfo, error) {
+	if err := resolveDeploySpecTokens(overrideToken, deploySpecs); err != nil {
+		return nil, err
+	}
 	partitions, err := createDeploySpecPartitions(auroraConfigName, overrideToken, AO.Clusters, deploySpecs)
 	if err != nil {
 		return nil, err
@@ -8,9 +8,25 @@ import (
 	"text/tabwriter"
 
 	"github.com/pkg/errors"
+	"github.com/skatteetaten/ao/pkg/auroraconfig"
 	"github.com/skatteetaten/ao/pkg/client"
+	"github.com/skatteetaten/ao/pkg/config"
+	"github.com/skatteetaten/ao/pkg/versioncontrol"
 )
 
+// ExitError makes ao exit with a specific exit code. Err is printed if set.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit code %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
 // DefaultTablePrinter prints a table on screen
 func DefaultTablePrinter(header string, rows []string, out io.Writer) {
 	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', tabwriter.TabIndent)
@@ -62,6 +78,20 @@ func GetFilesTable(files []string) (string, []string) {
 	return "FILES", append(single, envApp...)
 }
 
+// collectLocalAuroraConfig collects the AuroraConfig files in the repo, and prints the skipped files if verbose
+func collectLocalAuroraConfig(affiliation, gitRoot string, verbose bool, out io.Writer) (*auroraconfig.AuroraConfig, error) {
+	ac, skipped, err := versioncontrol.CollectAuroraConfigFilesInRepo(affiliation, gitRoot)
+	if err != nil {
+		return nil, err
+	}
+	if verbose {
+		for _, file := range skipped {
+			fmt.Fprintf(out, "Skipped %s: %s\n", file.Name, file.Reason)
+		}
+	}
+	return ac, nil
+}
+
 func getAPIClient(auroraConfig, overrideToken, overrideCluster string) (*client.APIClient, error) {
 	api := DefaultAPIClient
 	api.Affiliation = auroraConfig
@@ -72,9 +102,19 @@ func getAPIClient(auroraConfig, overrideToken, overrideCluster string) (*client.
 			return nil, errors.Errorf("%s cluster is not reachable", overrideCluster)
 		}
 
+		if overrideToken == "" && !config.CI {
+			if err := AO.ResolveClusterToken(overrideCluster); err != nil {
+				return nil, err
+			}
+		}
+
 		api.Host = c.BooberURL
 		api.GoboHost = c.GoboURL
 		api.Token = c.Token
+		api.TLSConfig = c.TLSConfig()
+		if api.Transport != nil {
+			api.Transport = api.Transport.WithTLSConfig(api.TLSConfig)
+		}
 		if overrideToken != "" {
 			api.Token = overrideToken
 		}
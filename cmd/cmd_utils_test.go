@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/skatteetaten/ao/pkg/auroraconfig"
+	"github.com/skatteetaten/ao/pkg/client"
+	"github.com/skatteetaten/ao/pkg/config"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -71,3 +73,28 @@ func TestGetFileRows(t *testing.T) {
 	assert.Equal(t, rows[4], "foo/bar.json")
 	assert.Equal(t, rows[5], "foo/baz.json")
 }
+
+func TestGetAPIClientForCluster(t *testing.T) {
+	defer func(api *client.APIClient, ao *config.AOConfig) {
+		DefaultAPIClient = api
+		AO = ao
+	}(DefaultAPIClient, AO)
+
+	AO = &config.AOConfig{
+		Clusters: map[string]*config.Cluster{
+			"utv":  {Name: "utv", Reachable: true, BooberURL: "https://boober-utv", Token: "utv-token"},
+			"test": {Name: "test", Reachable: true, BooberURL: "https://boober-test", Token: "test-token"},
+		},
+	}
+	AO.ConfigureTLS()
+	transport := client.NewTransport(nil, client.DefaultTransportOptions())
+	DefaultAPIClient = client.NewAPIClient("https://boober-utv", "", "utv-token", "paas", "master", "")
+	DefaultAPIClient.Transport = transport
+
+	api, err := getAPIClient("paas", "", "test")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://boober-test", api.Host)
+	assert.Equal(t, "test-token", api.Token)
+	assert.True(t, api.TLSConfig == AO.Clusters["test"].TLSConfig(), "the TLS config of the cluster should be used")
+	assert.NotSame(t, transport, api.Transport)
+}
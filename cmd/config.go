@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/skatteetaten/ao/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+const configViewLong = `Show the effective value of each setting and where it came from.
+Settings are resolved in order of increasing precedence: built-in defaults, the user config file (` + config.EnvConfig + ` or ~/.ao.json),
+a ` + config.RepoConfigFileName + ` file found in the working directory or one of its parents, ` + "AO_*" + ` environment variables and flags.`
+
+var (
+	configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the ao configuration",
+	}
+
+	configViewCmd = &cobra.Command{
+		Use:   "view",
+		Short: "Show the effective configuration and where each setting came from",
+		Long:  configViewLong,
+		RunE:  ViewConfig,
+	}
+)
+
+func init() {
+	RootCmd.AddCommand(configCmd)
+	configCmd.AddCommand(configViewCmd)
+}
+
+// ViewConfig is the entry point of the `config view` cli command
+func ViewConfig(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Usage()
+	}
+
+	header, rows := getSettingsTable(AO.Settings())
+	DefaultTablePrinter(header, rows, cmd.OutOrStdout())
+	return nil
+}
+
+func getSettingsTable(settings []config.Setting) (string, []string) {
+	var rows []string
+	for _, setting := range settings {
+		value := setting.Value
+		if setting.Name == config.SettingToken {
+			value = maskToken(value)
+		}
+		rows = append(rows, fmt.Sprintf("%s\t%s\t%s", setting.Name, value, setting.Source))
+	}
+	return "SETTING\tVALUE\tSOURCE", rows
+}
+
+func maskToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	if len(token) <= 8 {
+		return "****"
+	}
+	return token[:4] + "..." + token[len(token)-4:]
+}
@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/skatteetaten/ao/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_getSettingsTable(t *testing.T) {
+	settings := []config.Setting{
+		{Name: config.SettingAffiliation, Value: "paas", Source: "env AO_AFFILIATION"},
+		{Name: config.SettingToken, Value: "sha256~abcdefghijklmnop", Source: "flag --token"},
+	}
+
+	header, rows := getSettingsTable(settings)
+	assert.Equal(t, "SETTING\tVALUE\tSOURCE", header)
+	assert.Equal(t, []string{
+		"affiliation\tpaas\tenv AO_AFFILIATION",
+		"token\tsha2...mnop\tflag --token",
+	}, rows)
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/skatteetaten/ao/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagContextAffiliation string
+	flagContextRefName     string
+	flagContextAPICluster  string
+)
+
+const contextLong = `Manage named contexts. A context holds its own affiliation, git ref, API cluster and tokens,
+which makes it possible to switch between affiliations without logging in again.
+Use the global --context flag to run a single command in another context.`
+
+var (
+	contextCmd = &cobra.Command{
+		Use:   "context",
+		Short: "Manage named contexts with affiliation, ref, API cluster and tokens",
+		Long:  contextLong,
+	}
+
+	contextListCmd = &cobra.Command{
+		Use:     "list",
+		Short:   "List all contexts",
+		Aliases: []string{"get"},
+		RunE:    ListContexts,
+	}
+
+	contextUseCmd = &cobra.Command{
+		Use:         "use <context>",
+		Short:       "Set the current context",
+		RunE:        UseContext,
+		Annotations: lockConfigAnnotations,
+	}
+
+	contextCreateCmd = &cobra.Command{
+		Use:         "create <context>",
+		Short:       "Create a new context. Log in to the new context to obtain tokens",
+		RunE:        CreateContext,
+		Annotations: lockConfigAnnotations,
+	}
+
+	contextDeleteCmd = &cobra.Command{
+		Use:         "delete <context>",
+		Short:       "Delete a context",
+		RunE:        DeleteContext,
+		Annotations: lockConfigAnnotations,
+	}
+)
+
+func init() {
+	RootCmd.AddCommand(contextCmd)
+	contextCmd.AddCommand(contextListCmd)
+	contextCmd.AddCommand(contextUseCmd)
+	contextCmd.AddCommand(contextCreateCmd)
+	contextCmd.AddCommand(contextDeleteCmd)
+
+	contextCreateCmd.Flags().StringVarP(&flagContextAffiliation, "affiliation", "", "", "affiliation of the context")
+	contextCreateCmd.Flags().StringVarP(&flagContextRefName, "ref", "", "master", "git ref name of the context")
+	contextCreateCmd.Flags().StringVarP(&flagContextAPICluster, "apicluster", "", "", "API cluster of the context, default is the API cluster in use")
+}
+
+// ListContexts is the entry point of the `context list` cli command
+func ListContexts(cmd *cobra.Command, args []string) error {
+	if len(AO.Contexts) == 0 {
+		cmd.Println("No contexts defined, use \"ao context create <context>\" to create one")
+		return nil
+	}
+
+	var rows []string
+	for _, name := range AO.ContextNames() {
+		context := AO.Contexts[name]
+		current := ""
+		if name == AO.ActiveContext() {
+			current = "*"
+		}
+		rows = append(rows, fmt.Sprintf("%s\t%s\t%s\t%s\t%s", current, name, context.Affiliation, context.RefName, context.APICluster))
+	}
+
+	DefaultTablePrinter("CURRENT\tNAME\tAFFILIATION\tREF\tAPI CLUSTER", rows, cmd.OutOrStdout())
+	return nil
+}
+
+// UseContext is the entry point of the `context use` cli command
+func UseContext(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Usage()
+	}
+
+	if err := AO.UseContext(args[0]); err != nil {
+		return err
+	}
+	if err := config.WriteConfig(*AO, ConfigLocation); err != nil {
+		return err
+	}
+
+	cmd.Printf("Switched to context %s\n", args[0])
+	return nil
+}
+
+// CreateContext is the entry point of the `context create` cli command
+func CreateContext(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Usage()
+	}
+
+	apiCluster := flagContextAPICluster
+	if apiCluster == "" {
+		apiCluster = AO.APICluster
+	} else if _, found := AO.Clusters[apiCluster]; !found {
+		return fmt.Errorf("%s is not a valid cluster option. Choose between %v", apiCluster, AO.AvailableClusters)
+	}
+
+	context := config.Context{
+		Affiliation: flagContextAffiliation,
+		RefName:     flagContextRefName,
+		APICluster:  apiCluster,
+	}
+	if err := AO.CreateContext(args[0], context); err != nil {
+		return err
+	}
+	if err := config.WriteConfig(*AO, ConfigLocation); err != nil {
+		return err
+	}
+
+	cmd.Printf("Context %s created\n", args[0])
+	return nil
+}
+
+// DeleteContext is the entry point of the `context delete` cli command
+func DeleteContext(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Usage()
+	}
+
+	if err := AO.DeleteContext(args[0]); err != nil {
+		return err
+	}
+	if err := config.WriteConfig(*AO, ConfigLocation); err != nil {
+		return err
+	}
+
+	cmd.Printf("Context %s deleted\n", args[0])
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/skatteetaten/ao/pkg/client"
+	"github.com/skatteetaten/ao/pkg/config"
 	"github.com/skatteetaten/ao/pkg/deploymentspec"
 	"github.com/skatteetaten/ao/pkg/prompt"
 	"github.com/skatteetaten/ao/pkg/service"
@@ -94,7 +95,7 @@ func deploy(cmd *cobra.Command, args []string) error {
 		auroraConfigName = flagAuroraConfig
 	}
 
-	apiClient, err := getAPIClient(auroraConfigName, pFlagToken, flagCluster)
+	apiClient, err := getAPIClient(auroraConfigName, AO.TokenOverride(), flagCluster)
 	if err != nil {
 		return err
 	}
@@ -120,11 +121,19 @@ func deploy(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	partitions, err := createDeploySpecPartitions(auroraConfigName, pFlagToken, AO.Clusters, filteredDeploymentSpecs)
+	if err := resolveDeploySpecTokens(AO.TokenOverride(), filteredDeploymentSpecs); err != nil {
+		return err
+	}
+	partitions, err := createDeploySpecPartitions(auroraConfigName, AO.TokenOverride(), AO.Clusters, filteredDeploymentSpecs)
 	if err != nil {
 		return err
 	}
 
+	warnExpiringTokens(partitions, cmd.OutOrStdout())
+
+	if config.CI && !flagNoPrompt {
+		return errors.New("deploy asks for confirmation, use --no-prompt in CI mode")
+	}
 	if !getDeployConfirmation(flagNoPrompt, filteredDeploymentSpecs, flagVersion, cmd.OutOrStdout()) {
 		return errors.New("No applications to deploy")
 	}
@@ -77,6 +77,7 @@ func EditFile(cmd *cobra.Command, args []string) error {
 		}
 		return nil
 	})
+	fileEditor.Help = auroraconfig.FieldHelp(file.Name)
 
 	err = fileEditor.Edit(string(file.Contents), file.Name)
 	if err != nil {
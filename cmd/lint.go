@@ -0,0 +1,166 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/skatteetaten/ao/pkg/auroraconfig"
+	"github.com/skatteetaten/ao/pkg/config"
+	"github.com/skatteetaten/ao/pkg/versioncontrol"
+	"github.com/spf13/cobra"
+)
+
+const (
+	lintFormatText  = "text"
+	lintFormatJSON  = "json"
+	lintFormatSARIF = "sarif"
+	// ExitCodeLintErrors is the exit code of ao lint when a rule with severity error fails
+	ExitCodeLintErrors = 1
+	lintLong           = `Lint the AuroraConfig files in the current git repository, without calling Boober.
+
+The rules can be configured in .aolint.yaml in the root of the repository:
+
+  rules:
+    unknown-key: error        # off, warning or error
+    latest-in-prod: off
+  knownKeys: [myKey]          # top-level keys that are not reported as unknown
+  prodEnvironments: [^prod$]  # regular expressions matching production environments
+  exclude: [^templates/]      # regular expressions matching files that are not linted
+
+Rules:
+`
+)
+
+var (
+	flagLintFormat  string
+	flagLintConfig  string
+	flagLintVerbose bool
+)
+
+var lintCmd = &cobra.Command{
+	Use:         "lint",
+	Short:       "Check the AuroraConfig files in the current repository offline",
+	Long:        lintLong + lintRulesHelp(),
+	Annotations: map[string]string{"type": "local"},
+	RunE:        Lint,
+}
+
+func init() {
+	RootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().StringVarP(&flagLintFormat, "output", "o", lintFormatText, "output format [text, json, sarif]")
+	lintCmd.Flags().StringVar(&flagLintConfig, "config", "", "lint config file, defaults to "+auroraconfig.LintConfigFileName+" in the root of the repository")
+	lintCmd.Flags().BoolVarP(&flagLintVerbose, "verbose", "v", false, "list the files that are not part of the AuroraConfig, and why")
+}
+
+// Lint is the entry point of the `lint` cli command
+func Lint(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Usage()
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	gitRoot, err := versioncontrol.FindGitPath(wd)
+	if err != nil {
+		return err
+	}
+
+	lintConfig, err := loadLintConfig(gitRoot, flagLintConfig)
+	if err != nil {
+		return err
+	}
+
+	ac, err := collectLocalAuroraConfig(AO.Affiliation, gitRoot, flagLintVerbose, cmd.ErrOrStderr())
+	if err != nil {
+		return err
+	}
+	findings, err := ac.Lint(lintConfig)
+	if err != nil {
+		return err
+	}
+
+	if err := writeLintFindings(findings, lintConfig, flagLintFormat, cmd.OutOrStdout()); err != nil {
+		return err
+	}
+
+	for _, finding := range findings {
+		if finding.Severity == auroraconfig.SeverityError {
+			return &ExitError{Code: ExitCodeLintErrors}
+		}
+	}
+	return nil
+}
+
+// loadLintConfig loads the given lint config file, or .aolint.yaml in gitRoot if it exists
+func loadLintConfig(gitRoot, file string) (*auroraconfig.LintConfig, error) {
+	if file != "" {
+		return auroraconfig.LoadLintConfig(file)
+	}
+
+	file = filepath.Join(gitRoot, auroraconfig.LintConfigFileName)
+	if _, err := os.Stat(file); os.IsNotExist(err) {
+		return auroraconfig.DefaultLintConfig(), nil
+	}
+	return auroraconfig.LoadLintConfig(file)
+}
+
+func writeLintFindings(findings []auroraconfig.LintFinding, lintConfig *auroraconfig.LintConfig, format string, out io.Writer) error {
+	var report interface{}
+	switch format {
+	case lintFormatText:
+		printLintFindings(findings, out)
+		return nil
+	case lintFormatJSON:
+		if findings == nil {
+			findings = []auroraconfig.LintFinding{}
+		}
+		report = struct {
+			Findings []auroraconfig.LintFinding `json:"findings"`
+		}{findings}
+	case lintFormatSARIF:
+		report = auroraconfig.NewSARIFReport(findings, lintConfig, config.Version)
+	default:
+		return errors.Errorf("Unknown output format %s, must be one of [text, json, sarif]", format)
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(out, string(data))
+	return nil
+}
+
+func printLintFindings(findings []auroraconfig.LintFinding, out io.Writer) {
+	if len(findings) == 0 {
+		fmt.Fprintln(out, "OK")
+		return
+	}
+
+	errorCount := 0
+	for _, finding := range findings {
+		location := finding.File
+		if finding.Line > 0 {
+			location = fmt.Sprintf("%s:%d", finding.File, finding.Line)
+		}
+		fmt.Fprintf(out, "%s: %s: %s [%s]\n", location, finding.Severity, finding.Message, finding.Rule)
+		if finding.Severity == auroraconfig.SeverityError {
+			errorCount++
+		}
+	}
+	fmt.Fprintf(out, "\n%d errors, %d warnings\n", errorCount, len(findings)-errorCount)
+}
+
+func lintRulesHelp() string {
+	var help string
+	for _, rule := range auroraconfig.LintRules {
+		help += fmt.Sprintf("  %-20s %-8s %s\n", rule.ID, rule.DefaultSeverity, rule.Description)
+	}
+	return help
+}
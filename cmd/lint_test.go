@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skatteetaten/ao/pkg/auroraconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_writeLintFindings(t *testing.T) {
+	findings := []auroraconfig.LintFinding{
+		{Rule: auroraconfig.RuleUnknownKey, Severity: auroraconfig.SeverityWarning, File: "utv/foo.json", Line: 3, Message: "unknown key foobar"},
+		{Rule: auroraconfig.RuleMissingBaseFile, Severity: auroraconfig.SeverityError, File: "utv/bar.json", Message: "utv/bar.json has no base file bar and no baseFile"},
+	}
+	lintConfig := auroraconfig.DefaultLintConfig()
+
+	t.Run("Should print findings as text", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		assert.NoError(t, writeLintFindings(findings, lintConfig, lintFormatText, out))
+		assert.Equal(t, `utv/foo.json:3: warning: unknown key foobar [unknown-key]
+utv/bar.json: error: utv/bar.json has no base file bar and no baseFile [missing-base-file]
+
+1 errors, 1 warnings
+`, out.String())
+
+		out.Reset()
+		assert.NoError(t, writeLintFindings(nil, lintConfig, lintFormatText, out))
+		assert.Equal(t, "OK\n", out.String())
+	})
+
+	t.Run("Should write findings as json", func(t *testing.T) {
+		out := &bytes.Buffer{}
+		assert.NoError(t, writeLintFindings(nil, lintConfig, lintFormatJSON, out))
+
+		var report struct {
+			Findings []auroraconfig.LintFinding `json:"findings"`
+		}
+		assert.NoError(t, json.Unmarshal(out.Bytes(), &report))
+		assert.NotNil(t, report.Findings)
+		assert.Empty(t, report.Findings)
+	})
+
+	t.Run("Should fail on unknown format", func(t *testing.T) {
+		assert.Error(t, writeLintFindings(findings, lintConfig, "xml", &bytes.Buffer{}))
+	})
+}
+
+func Test_loadLintConfig(t *testing.T) {
+	gitRoot, err := ioutil.TempDir("", "aolint")
+	assert.NoError(t, err)
+	defer os.RemoveAll(gitRoot)
+
+	lintConfig, err := loadLintConfig(gitRoot, "")
+	assert.NoError(t, err)
+	assert.Equal(t, auroraconfig.DefaultLintConfig(), lintConfig)
+
+	file := filepath.Join(gitRoot, auroraconfig.LintConfigFileName)
+	assert.NoError(t, ioutil.WriteFile(file, []byte("rules:\n  latest-in-prod: off\n"), 0644))
+	lintConfig, err = loadLintConfig(gitRoot, "")
+	assert.NoError(t, err)
+	assert.Equal(t, auroraconfig.SeverityOff, lintConfig.Severity(auroraconfig.RuleLatestInProd))
+}
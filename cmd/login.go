@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"os/user"
 	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -22,14 +26,21 @@ var (
 	flagUserName   string
 	flagLocalhost  bool
 	flagAPICluster string
+	flagWeb        bool
+	flagTokenStdin bool
+
+	flagLoginClusters []string
 )
 
 var loginCmd = &cobra.Command{
 	Use:     "login <AuroraConfig>",
-	Short:   "Login to all available OpenShift clusters",
+	Short:   "Login to all available OpenShift clusters, or the ones given by --clusters",
 	PreRunE: PreLogin,
 	RunE:    Login,
 	PostRun: PostLogin,
+	Annotations: map[string]string{
+		annotationLockConfig: "true",
+	},
 }
 
 func init() {
@@ -56,6 +67,9 @@ func init() {
 	loginCmd.Flags().MarkHidden("localhost")
 	loginCmd.Flags().StringVarP(&flagAPICluster, "apicluster", "", "", "select specified API cluster")
 	loginCmd.Flags().MarkHidden("apicluster")
+	loginCmd.Flags().BoolVarP(&flagWeb, "web", "", false, "log in with a browser, for accounts using single sign-on or multi-factor authentication")
+	loginCmd.Flags().StringSliceVarP(&flagLoginClusters, "clusters", "", nil, "comma separated list of clusters to log in to, default is all reachable clusters")
+	loginCmd.Flags().BoolVarP(&flagTokenStdin, "token-stdin", "", false, "read the token to log in with from standard input, and use it for the clusters where it is valid")
 }
 
 // PreLogin performs pre command validation checks for the `login` cli command
@@ -67,31 +81,189 @@ func PreLogin(cmd *cobra.Command, args []string) error {
 		AO.Affiliation = args[0]
 	}
 
-	var password string
-	if flagPassword != "" {
-		password = flagPassword
+	if flagWeb && flagTokenStdin {
+		return errors.New("--web and --token-stdin can not be used together")
 	}
-	for _, c := range AO.Clusters {
-		if !c.Reachable || c.HasValidToken() {
-			continue
-		}
+	clusters, err := getLoginClusters(flagLoginClusters)
+	if err != nil {
+		return err
+	}
+	if flagTokenStdin {
+		return loginWithToken(clusters, cmd.InOrStdin())
+	}
+
+	clusters = withoutValidTokens(clusters)
+	if len(clusters) == 0 {
+		return nil
+	}
+
+	var failures map[string]error
+	if flagWeb {
+		failures = loginWithBrowser(clusters, cmd.OutOrStdout())
+	} else {
+		password := flagPassword
 		if password == "" {
 			password = prompt.Password()
 		}
-		token, err := config.GetToken(c.LoginURL, flagUserName, password)
+		failures = loginWithPassword(clusters, flagUserName, password)
+	}
+
+	return reportLoginFailures(clusters, failures, cmd.OutOrStdout())
+}
+
+// getLoginClusters returns the named clusters, or all clusters if no names are given. Unreachable clusters are left out.
+func getLoginClusters(names []string) ([]*config.Cluster, error) {
+	if len(names) == 0 {
+		names = AO.AvailableClusters
+	} else {
+		for _, name := range names {
+			if _, found := AO.Clusters[name]; !found {
+				return nil, errors.Errorf("%s is not a valid cluster option. Choose between %v", name, AO.AvailableClusters)
+			}
+		}
+	}
+
+	var clusters []*config.Cluster
+	for _, name := range names {
+		if c := AO.Clusters[name]; c != nil && c.Reachable {
+			clusters = append(clusters, c)
+		} else if len(flagLoginClusters) > 0 {
+			logrus.Warnf("Cluster %s is not reachable", name)
+		}
+	}
+	return clusters, nil
+}
+
+// withoutValidTokens returns the clusters that need a new token, checking the tokens in parallel
+func withoutValidTokens(clusters []*config.Cluster) []*config.Cluster {
+	valid := make([]bool, len(clusters))
+	var wg sync.WaitGroup
+	for i, c := range clusters {
+		if err := AO.ResolveClusterToken(c.Name); err != nil {
+			logrus.Warn(err)
+			continue
+		}
+		wg.Add(1)
+		go func(i int, c *config.Cluster) {
+			defer wg.Done()
+			valid[i] = c.HasValidToken()
+		}(i, c)
+	}
+	wg.Wait()
+
+	var result []*config.Cluster
+	for i, c := range clusters {
+		if !valid[i] {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// loginWithPassword gets a token for each cluster in parallel, and returns the failures by cluster name
+func loginWithPassword(clusters []*config.Cluster, username, password string) map[string]error {
+	failures := make(map[string]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range clusters {
+		wg.Add(1)
+		go func(c *config.Cluster) {
+			defer wg.Done()
+			token, err := c.GetToken(username, password)
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"url":      c.URL,
+					"userName": username,
+				}).Debug(err)
+				mu.Lock()
+				failures[c.Name] = err
+				mu.Unlock()
+				return
+			}
+			c.Token = token
+		}(c)
+	}
+	wg.Wait()
+	return failures
+}
+
+// loginWithBrowser gets a token for each cluster with the OAuth login in a browser, and returns the failures by cluster name
+func loginWithBrowser(clusters []*config.Cluster, out io.Writer) map[string]error {
+	failures := make(map[string]error)
+	for _, c := range clusters {
+		token, err := c.GetTokenFromBrowser(func(url string) error {
+			fmt.Fprintf(out, "Log in to %s in the browser. If it does not open, go to:\n%s\n", c.Name, url)
+			if err := openBrowser(url); err != nil {
+				logrus.Debugf("Could not open browser: %s", err)
+			}
+			return nil
+		})
 		if err != nil {
-			indicateAoAdmRecreateConfig(err)
-			logrus.WithFields(logrus.Fields{
-				"url":      c.URL,
-				"userName": flagUserName,
-			}).Fatal(err)
+			failures[c.Name] = err
+			continue
 		}
 		c.Token = token
 	}
+	return failures
+}
+
+// reportLoginFailures prints the clusters where login failed, and returns an error if it failed for all of them
+func reportLoginFailures(clusters []*config.Cluster, failures map[string]error, out io.Writer) error {
+	if len(failures) == 0 {
+		return nil
+	}
 
+	for _, c := range clusters {
+		if err, failed := failures[c.Name]; failed {
+			indicateAoAdmRecreateConfig(err)
+			fmt.Fprintf(out, "Login to %s failed: %s\n", c.Name, err)
+		}
+	}
+	if len(failures) == len(clusters) {
+		return errors.New("login failed for all clusters")
+	}
 	return nil
 }
 
+// loginWithToken reads a token from in, and uses it for every cluster where it is valid
+func loginWithToken(clusters []*config.Cluster, in io.Reader) error {
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return errors.Wrap(err, "could not read token from standard input")
+	}
+	token := strings.TrimSpace(line)
+	if token == "" {
+		return errors.New("no token on standard input")
+	}
+
+	valid := 0
+	for _, c := range clusters {
+		previous := c.Token
+		c.Token = token
+		if !c.HasValidToken() {
+			logrus.Warnf("The token is not valid for cluster %s", c.Name)
+			c.Token = previous
+			continue
+		}
+		valid++
+	}
+	if valid == 0 {
+		return errors.New("the token is not valid for any reachable cluster")
+	}
+	return nil
+}
+
+func openBrowser(url string) error {
+	switch runtime.GOOS {
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	case "darwin":
+		return exec.Command("open", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
+
 // Login performs main part of the `login` cli command
 func Login(cmd *cobra.Command, args []string) error {
 	if AO.Localhost != flagLocalhost {
@@ -105,6 +277,9 @@ func Login(cmd *cobra.Command, args []string) error {
 		AO.APICluster = flagAPICluster
 	}
 
+	if err := AO.ResolveClusterToken(AO.APICluster); err != nil {
+		return err
+	}
 	cluster := AO.Clusters[AO.APICluster]
 	DefaultAPIClient.Token = cluster.Token
 
@@ -153,7 +328,7 @@ func Login(cmd *cobra.Command, args []string) error {
 		return errors.New(message)
 	}
 
-	AO.Update(false)
+	AO.UpdateTokenInfo()
 	return config.WriteConfig(*AO, ConfigLocation)
 }
 
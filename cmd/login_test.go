@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/skatteetaten/ao/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newLoginTestServer(password string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if _, p, ok := req.BasicAuth(); !ok || p != password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Location", "https://localhost/oauth/token/implicit#access_token=new-token")
+		w.WriteHeader(http.StatusFound)
+	}))
+}
+
+func Test_loginWithPassword(t *testing.T) {
+	ts := newLoginTestServer("secret")
+	defer ts.Close()
+	failing := newLoginTestServer("other")
+	defer failing.Close()
+
+	east := &config.Cluster{Name: "east", LoginURL: ts.URL, Reachable: true}
+	west := &config.Cluster{Name: "west", LoginURL: ts.URL, Reachable: true}
+	north := &config.Cluster{Name: "north", LoginURL: failing.URL, Reachable: true}
+	clusters := []*config.Cluster{east, west, north}
+
+	failures := loginWithPassword(clusters, "user", "secret")
+
+	assert.Equal(t, "new-token", east.Token)
+	assert.Equal(t, "new-token", west.Token)
+	assert.Empty(t, north.Token)
+	assert.Len(t, failures, 1)
+	assert.Contains(t, failures, "north")
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, reportLoginFailures(clusters, failures, out))
+	assert.Equal(t, "Login to north failed: Not authorized\n", out.String())
+
+	assert.Error(t, reportLoginFailures(clusters[2:], failures, out))
+}
+
+func Test_getLoginClusters(t *testing.T) {
+	AO = &config.AOConfig{
+		AvailableClusters: []string{"east", "west", "north"},
+		Clusters: map[string]*config.Cluster{
+			"east":  newTestCluster("east", true),
+			"west":  newTestCluster("west", false),
+			"north": newTestCluster("north", true),
+		},
+	}
+
+	clusters, err := getLoginClusters(nil)
+	assert.NoError(t, err)
+	assert.Len(t, clusters, 2)
+
+	clusters, err = getLoginClusters([]string{"north"})
+	assert.NoError(t, err)
+	assert.Len(t, clusters, 1)
+	assert.Equal(t, "north", clusters[0].Name)
+
+	_, err = getLoginClusters([]string{"south"})
+	assert.Error(t, err)
+}
@@ -1,28 +1,53 @@
 package cmd
 
 import (
+	"github.com/pkg/errors"
 	"github.com/skatteetaten/ao/pkg/config"
 	"github.com/spf13/cobra"
 )
 
+var flagLogoutClusters []string
+
 var logoutCmd = &cobra.Command{
 	Use:   "logout",
-	Short: "Logout of all connected clusters",
+	Short: "Logout of all connected clusters, or the ones given by --cluster",
 	RunE:  Logout,
+	Annotations: map[string]string{
+		annotationLockConfig: "true",
+	},
 }
 
 func init() {
 	RootCmd.AddCommand(logoutCmd)
+
+	logoutCmd.Flags().StringSliceVarP(&flagLogoutClusters, "cluster", "", nil, "cluster to log out of, can be repeated or comma separated. Keeps the affiliation")
 }
 
 // Logout performs the `logout` cli command
 func Logout(cmd *cobra.Command, args []string) error {
+	if len(flagLogoutClusters) > 0 {
+		for _, name := range flagLogoutClusters {
+			cluster, found := AO.Clusters[name]
+			if !found {
+				return errors.Errorf("%s is not a valid cluster option. Choose between %v", name, AO.AvailableClusters)
+			}
+			clearToken(cluster)
+		}
+		return config.WriteConfig(*AO, ConfigLocation)
+	}
+
 	AO.Localhost = false
 	AO.Affiliation = ""
 
 	for _, c := range AO.Clusters {
-		c.Token = ""
+		clearToken(c)
 	}
 
 	return config.WriteConfig(*AO, ConfigLocation)
 }
+
+func clearToken(cluster *config.Cluster) {
+	cluster.Token = ""
+	cluster.Username = ""
+	cluster.TokenExpiry = nil
+}
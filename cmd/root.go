@@ -2,10 +2,9 @@ package cmd
 
 import (
 	"fmt"
-	"github.com/skatteetaten/ao/pkg/prompt"
 	"os"
-	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
@@ -29,6 +28,8 @@ var (
 	pFlagRefName              string
 	pFlagNoHeader             bool
 	pFlagAnswerRecreateConfig string
+	pFlagContext              string
+	pFlagCI                   bool
 
 	// DefaultAPIClient will use APICluster from ao config as default values
 	// if persistent token and/or server api url is specified these will override default values
@@ -37,24 +38,35 @@ var (
 	AO *config.AOConfig
 	// ConfigLocation is the location of the config
 	ConfigLocation string
+
+	unlockConfig func()
 )
 
+// annotationLockConfig marks commands that modify the ao config. The config is locked from it is loaded
+// until the command is done, so that parallel ao processes do not overwrite each other's changes.
+const annotationLockConfig = "lockConfig"
+
 // RootCmd is the root of the entire `ao` cli command structure
 var RootCmd = &cobra.Command{
 	Use:               "ao command",
 	Short:             "Aurora OpenShift CLI",
 	Long:              rootLong,
 	PersistentPreRunE: initialize,
+	PersistentPostRun: notifyNewVersion,
 }
 
 func init() {
 	RootCmd.PersistentFlags().StringVarP(&pFlagLogLevel, "log", "l", "fatal", "Set log level. Valid log levels are [info, debug, warning, error, fatal]")
 	RootCmd.PersistentFlags().BoolVarP(&pFlagPrettyLog, "pretty", "p", false, "Pretty print json output for log")
 	RootCmd.PersistentFlags().StringVarP(&pFlagToken, "token", "t", "", "OpenShift authorization token to use for remote commands, overrides login")
-	RootCmd.PersistentFlags().StringVar(&pFlagRefName, "ref", "", "Set git ref name, does not affect vaults")
+	RootCmd.PersistentFlags().StringVar(&pFlagRefName, "ref", "", "Git ref of the AuroraConfig to use, overrides the ref name in the ao config")
 	RootCmd.PersistentFlags().BoolVar(&pFlagNoHeader, "no-headers", false, "Print tables without headers")
 	RootCmd.PersistentFlags().MarkHidden("no-headers")
+	RootCmd.PersistentFlags().BoolVar(&config.InsecureSkipTLSVerify, "insecure-skip-tls-verify", false, "Do not verify TLS certificates. Makes the connections insecure")
+	RootCmd.PersistentFlags().StringVar(&pFlagContext, "context", "", "Use the named context for this command instead of the current context")
+	RootCmd.PersistentFlags().BoolVar(&pFlagCI, "ci", false, "Run non-interactively, without prompting or writing the ao config. Also set by AO_CI=1")
 	RootCmd.PersistentFlags().StringVar(&pFlagAnswerRecreateConfig, "autoanswer-recreate-config", "", "Set automatic response for ao config question [y, n]")
+	RootCmd.PersistentFlags().MarkDeprecated("autoanswer-recreate-config", "the ao config is now migrated automatically")
 }
 
 func initialize(cmd *cobra.Command, args []string) error {
@@ -76,13 +88,27 @@ func initialize(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	ConfigLocation = filepath.Join(home, ".ao.json")
+	configLocation := config.ResolveConfigLocation(home, os.Getenv)
+	ConfigLocation = configLocation.Value
 
 	err = setLogging(pFlagLogLevel, pFlagPrettyLog)
 	if err != nil {
 		return err
 	}
 
+	config.CI = pFlagCI || config.CIFromEnvironment(os.Getenv)
+	_, changesConfig := cmd.Annotations[annotationLockConfig]
+	if config.CI && changesConfig {
+		return errors.Errorf("%s changes the ao config, which is not possible in CI mode. Tokens are read from %s<CLUSTER> or %s",
+			cmd.CommandPath(), config.EnvClusterTokenPrefix, config.EnvTokenDir)
+	}
+
+	if changesConfig {
+		if unlockConfig, err = config.LockConfig(ConfigLocation); err != nil {
+			return err
+		}
+	}
+
 	aoConfig, err := config.LoadConfigFile(ConfigLocation)
 	if err != nil {
 		logrus.Error(err)
@@ -93,31 +119,36 @@ func initialize(cmd *cobra.Command, args []string) error {
 		aoConfig = &config.DefaultAOConfig
 		aoConfig.InitClusters()
 		aoConfig.SelectAPICluster()
-		err = config.WriteConfig(*aoConfig, ConfigLocation)
-		if err != nil {
+	}
+
+	if aoConfig, err = migrateConfig(aoConfig, changesConfig); err != nil {
+		return err
+	}
+
+	aoConfig.ConfigureTLS()
+
+	if err := aoConfig.ActivateContext(pFlagContext); err != nil {
+		return err
+	}
+
+	if config.CI {
+		if err := aoConfig.ApplyCITokens(os.Getenv); err != nil {
 			return err
 		}
-	} else if aoConfig.FileAOVersion != config.Version {
-		logrus.Debugf("ao config file is saved with another versjon. AO-version: %s, saved version: %s", config.Version, aoConfig.FileAOVersion)
+	}
 
-		if update() {
-			RecreateConfig(cmd, args)
-			if aoConfig, err = config.LoadConfigFile(ConfigLocation); err != nil {
-				logrus.Error(fmt.Errorf("Could not load config after recreate: %w", err))
-			}
-			if pFlagAnswerRecreateConfig == "" {
-				fmt.Printf("\nThe ao configuration settings file was updated to match the current ao version.\n\n")
-			}
-		} else {
-			if pFlagAnswerRecreateConfig == "" {
-				fmt.Printf("\nNB: Using the ao configuration settings file created for another ao version may cause errors. \nIf you experience errors, try running command \"ao adm recreate-config\".\n\n")
-			}
-		}
+	aoConfig.InitSettings(configLocation)
+	if err := applyConfigLayers(aoConfig); err != nil {
+		return err
 	}
 
+	commandsWithoutAffiliation := []string{"version", "login", "logout", "adm", "update", "context", "config", "whoami", "lint"}
+	usesAffiliation := containsNone(cmd.CommandPath(), commandsWithoutAffiliation)
 	if flagAuroraConfig == "" && flagCheckoutAffiliation == "" {
-		commandsWithoutAffiliation := []string{"version", "login", "logout", "adm", "update"}
-		if containsNone(cmd.CommandPath(), commandsWithoutAffiliation) && aoConfig.Affiliation == "" {
+		if usesAffiliation && aoConfig.Affiliation == "" {
+			if config.CI {
+				return errors.Errorf("no affiliation is set, use %s or --auroraconfig in CI mode", config.EnvAffiliation)
+			}
 			return errors.New("no affiliations is set, please login")
 		}
 	}
@@ -130,7 +161,23 @@ func initialize(cmd *cobra.Command, args []string) error {
 		apiCluster = &config.Cluster{}
 	}
 
-	api := client.NewAPIClient(apiCluster.BooberURL, apiCluster.GoboURL, apiCluster.Token, aoConfig.Affiliation, aoConfig.RefName, client.CreateUUID().String())
+	if config.CI && usesAffiliation && aoConfig.Token().Value == "" {
+		return errors.Errorf("no token for api cluster %s, set %s, put it in %s/%s or use --token",
+			aoConfig.APICluster, config.ClusterTokenEnv(aoConfig.APICluster), config.EnvTokenDir, aoConfig.APICluster)
+	}
+
+	// Reading a token from the credential store may prompt for a passphrase, so only the token of the API cluster
+	// is read, and only for commands that call the API
+	if !config.CI && aoConfig.TokenOverride() == "" && callsAPI(cmd, usesAffiliation) {
+		if err := aoConfig.ResolveClusterToken(aoConfig.APICluster); err != nil {
+			return err
+		}
+	}
+
+	api := client.NewAPIClient(apiCluster.BooberURL, apiCluster.GoboURL, aoConfig.Token().Value, aoConfig.Affiliation, aoConfig.RefName, client.CreateUUID().String())
+
+	api.TLSConfig = apiCluster.TLSConfig()
+	api.Transport = client.NewTransport(api.TLSConfig, client.NewTransportOptions(aoConfig.HTTP))
 
 	if aoConfig.Localhost {
 		// TODO: Move to config?
@@ -138,30 +185,114 @@ func initialize(cmd *cobra.Command, args []string) error {
 		api.GoboHost = "http://localhost:8080"
 	}
 
-	if pFlagRefName != "" {
-		api.RefName = pFlagRefName
+	AO, DefaultAPIClient = aoConfig, api
+
+	return nil
+}
+
+// notifyNewVersion tells if a new version of ao is available. It is checked at most once a day,
+// and never in CI mode or by the commands that log in or update ao.
+func notifyNewVersion(cmd *cobra.Command, args []string) {
+	if config.CI || AO == nil || strings.Contains(cmd.Name(), cobra.ShellCompRequestCmd) {
+		return
+	}
+	if !containsNone(cmd.CommandPath(), []string{"login", "update", "version", "completion"}) {
+		return
 	}
 
-	if pFlagToken != "" {
-		api.Token = pFlagToken
+	if notice := AO.NewVersionNotice(config.UpdateCheckLocation(ConfigLocation), time.Now()); notice != "" {
+		fmt.Fprintln(cmd.ErrOrStderr(), notice)
 	}
+}
 
-	AO, DefaultAPIClient = aoConfig, api
+// UnlockConfig releases the config lock taken by commands that modify the ao config
+func UnlockConfig() {
+	if unlockConfig != nil {
+		unlockConfig()
+		unlockConfig = nil
+	}
+}
+
+// applyConfigLayers overrides the user config with a per-repo .ao.yaml, AO_* environment variables and flags, in that order
+func applyConfigLayers(aoConfig *config.AOConfig) error {
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	if repoConfigFile := config.FindRepoConfig(wd); repoConfigFile != "" {
+		repoConfig, err := config.LoadRepoConfig(repoConfigFile)
+		if err != nil {
+			return err
+		}
+		aoConfig.ApplyRepoConfig(repoConfigFile, repoConfig)
+	}
+
+	aoConfig.ApplyEnvironment(os.Getenv)
+
+	aoConfig.Override(config.SettingContext, pFlagContext, "flag --context")
+	aoConfig.Override(config.SettingRefName, pFlagRefName, "flag --ref")
+	aoConfig.Override(config.SettingToken, pFlagToken, "flag --token")
 
 	return nil
 }
 
-func update() bool {
-	// ask for update if none of the flags "token" or "autoanswer-recreate-config" are set
-	ask := pFlagToken == "" && pFlagAnswerRecreateConfig == ""
+// migrateConfig migrates the config, and writes it if it changed or has tokens to move to the credential store.
+// Commands that do not already hold the config lock take it here, and read the config again, so that changes
+// written by another ao process in the meantime, like a parallel login, are kept. The write is skipped if another
+// ao process holds the lock, and done by a later command.
+func migrateConfig(aoConfig *config.AOConfig, locked bool) (*config.AOConfig, error) {
+	changed, err := needsMigration(aoConfig)
+	if err != nil || !changed || config.CI {
+		return aoConfig, err
+	}
+
+	if !locked {
+		unlock, locked, err := config.TryLockConfig(ConfigLocation)
+		if err != nil || !locked {
+			logrus.Debug("The ao config is locked by another ao process, not writing the migrated config")
+			return aoConfig, nil
+		}
+		defer unlock()
+
+		reloaded, err := config.LoadConfigFile(ConfigLocation)
+		if err != nil && !os.IsNotExist(err) {
+			logrus.Debug("Could not read the ao config again, not writing the migrated config: ", err)
+			return aoConfig, nil
+		}
+		if reloaded != nil {
+			aoConfig = reloaded
+			if changed, err = needsMigration(aoConfig); err != nil || !changed {
+				return aoConfig, err
+			}
+		}
+	}
+
+	return aoConfig, config.WriteConfig(*aoConfig, ConfigLocation)
+}
 
-	if ask {
-		fmt.Printf("\nIt looks like ao have been updated to another version.\n")
-		message := "Do you want to recreate the ao configuration settings file with default values (recommended)?"
-		return prompt.Confirm(message, true)
+// needsMigration migrates the config, and returns true if it has to be written
+func needsMigration(aoConfig *config.AOConfig) (bool, error) {
+	migrated, err := aoConfig.Migrate()
+	if err != nil {
+		return false, err
+	}
+	if !config.CI && aoConfig.HasPlaintextTokens() {
+		logrus.Info("Moving tokens to the credential store")
+		migrated = true
 	}
+	return migrated, nil
+}
 
-	return strings.ToLower(pFlagAnswerRecreateConfig) != "n"
+// callsAPI is true for commands that call Boober or Gobo with the token of the API cluster
+func callsAPI(cmd *cobra.Command, usesAffiliation bool) bool {
+	switch cmd {
+	case schemaExportCmd:
+		return !flagSchemaOffline
+	case getAffiliationCmd:
+		return true
+	}
+	return usesAffiliation
 }
 
 func containsNone(value string, list []string) bool {
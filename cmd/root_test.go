@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skatteetaten/ao/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_callsAPI(t *testing.T) {
+	assert.True(t, callsAPI(deployCmd, true))
+	assert.True(t, callsAPI(getAffiliationCmd, false))
+	assert.False(t, callsAPI(versionCmd, false))
+
+	assert.True(t, callsAPI(schemaExportCmd, true))
+	flagSchemaOffline = true
+	defer func() { flagSchemaOffline = false }()
+	assert.False(t, callsAPI(schemaExportCmd, true), "offline schema export should not read the token")
+}
+
+func Test_migrateConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao-migrate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	defer func(location string) { ConfigLocation = location }(ConfigLocation)
+	ConfigLocation = filepath.Join(dir, ".ao.json")
+
+	writeOldConfig := func(affiliation string) {
+		old := config.AOConfig{
+			Affiliation:   affiliation,
+			SchemaVersion: config.CurrentSchemaVersion,
+			FileAOVersion: "old",
+			Clusters:      map[string]*config.Cluster{},
+		}
+		assert.NoError(t, config.WriteConfig(old, ConfigLocation))
+	}
+	load := func() *config.AOConfig {
+		loaded, err := config.LoadConfigFile(ConfigLocation)
+		assert.NoError(t, err)
+		return loaded
+	}
+
+	t.Run("Should not write when another ao process holds the lock", func(t *testing.T) {
+		writeOldConfig("paas")
+		unlock, locked, err := config.TryLockConfig(ConfigLocation)
+		assert.NoError(t, err)
+		assert.True(t, locked)
+		defer unlock()
+
+		migrated, err := migrateConfig(load(), false)
+		assert.NoError(t, err)
+		assert.Equal(t, config.Version, migrated.FileAOVersion)
+		assert.Equal(t, "old", load().FileAOVersion)
+	})
+
+	t.Run("Should keep changes written by another ao process", func(t *testing.T) {
+		writeOldConfig("paas")
+		inUse := load()
+		writeOldConfig("sales")
+
+		migrated, err := migrateConfig(inUse, false)
+		assert.NoError(t, err)
+		assert.Equal(t, "sales", migrated.Affiliation)
+		written := load()
+		assert.Equal(t, "sales", written.Affiliation)
+		assert.Equal(t, config.Version, written.FileAOVersion)
+	})
+}
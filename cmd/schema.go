@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/skatteetaten/ao/pkg/auroraconfig"
+	"github.com/skatteetaten/ao/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+const schemaExportLong = `Export a JSON Schema for AuroraConfig files, for editors and CI to validate the files with.
+
+The kind of file is one of application, about and env. The schema has the fields ao knows,
+and the fields found in the deploy specs of the current AuroraConfig on the server.
+Use --dir to write a schema for each kind of file to <dir>/<kind>.schema.json.`
+
+var (
+	flagSchemaDir     string
+	flagSchemaOffline bool
+)
+
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Describe the fields of the AuroraConfig files",
+}
+
+var schemaExportCmd = &cobra.Command{
+	Use:   "export [application|about|env]",
+	Short: "Export a JSON Schema for AuroraConfig files",
+	Long:  schemaExportLong,
+	RunE:  ExportSchema,
+}
+
+func init() {
+	RootCmd.AddCommand(schemaCmd)
+	schemaCmd.AddCommand(schemaExportCmd)
+	schemaExportCmd.Flags().StringVar(&flagSchemaDir, "dir", "", "write a schema for each kind of file to this directory")
+	schemaExportCmd.Flags().BoolVar(&flagSchemaOffline, "offline", false, "only use the fields ao knows, without calling the server")
+}
+
+// ExportSchema is the entry point of the `schema export` cli command
+func ExportSchema(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 || (len(args) == 1 && flagSchemaDir != "") {
+		return cmd.Usage()
+	}
+
+	fields := auroraconfig.Fields
+	if !flagSchemaOffline {
+		var err error
+		if fields, err = getSchemaFields(DefaultAPIClient, DefaultAPIClient); err != nil {
+			return errors.Wrap(err, "could not get the deploy specs from the server, use --offline to export the fields ao knows")
+		}
+	}
+
+	if flagSchemaDir == "" {
+		kind := "application"
+		if len(args) == 1 {
+			kind = args[0]
+		}
+		data, err := marshalSchema(kind, fields)
+		if err != nil {
+			return err
+		}
+		cmd.Println(string(data))
+		return nil
+	}
+
+	var kinds []string
+	for kind := range auroraconfig.FileKinds {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		data, err := marshalSchema(kind, fields)
+		if err != nil {
+			return err
+		}
+		file := filepath.Join(flagSchemaDir, kind+".schema.json")
+		if err := ioutil.WriteFile(file, append(data, '\n'), 0644); err != nil {
+			return err
+		}
+		cmd.Printf("Wrote %s\n", file)
+	}
+	return nil
+}
+
+// getSchemaFields adds the fields of the deploy specs of all ApplicationDeploymentRefs to the fields ao knows
+func getSchemaFields(auroraConfigClient client.AuroraConfigClient, deploySpecClient client.DeploySpecClient) ([]auroraconfig.Field, error) {
+	fileNames, err := auroraConfigClient.GetFileNames()
+	if err != nil {
+		return nil, err
+	}
+	refs := fileNames.GetApplicationDeploymentRefs()
+	if len(refs) == 0 {
+		return auroraconfig.Fields, nil
+	}
+
+	specs, err := deploySpecClient.GetAuroraDeploySpec(refs, true, true)
+	if err != nil {
+		return nil, err
+	}
+	return auroraconfig.MergeDeploySpecFields(auroraconfig.Fields, specs), nil
+}
+
+func marshalSchema(kind string, fields []auroraconfig.Field) ([]byte, error) {
+	schema, err := auroraconfig.JSONSchema(kind, fields)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(schema, "", "  ")
+}
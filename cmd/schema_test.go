@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/skatteetaten/ao/pkg/auroraconfig"
+	"github.com/skatteetaten/ao/pkg/client"
+	"github.com/skatteetaten/ao/pkg/deploymentspec"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_getSchemaFields(t *testing.T) {
+	spec := deploymentspec.NewDeploymentSpec("foo", "utv", "east", "1.0.0")
+	spec["applicationDeploymentRef"] = map[string]interface{}{"source": "static", "value": "utv/foo"}
+	spec["jolokia"] = map[string]interface{}{"source": "default", "value": true}
+
+	t.Run("Should add the fields of the deploy specs", func(t *testing.T) {
+		auroraConfigClient := client.NewAuroraConfigClientMock(auroraconfig.FileNames{"about.json", "foo.json", "utv/about.json", "utv/foo.json"})
+		deploySpecClient := client.NewDeploySpecClientMock([]deploymentspec.DeploymentSpec{spec})
+
+		fields, err := getSchemaFields(auroraConfigClient, deploySpecClient)
+		assert.NoError(t, err)
+		assert.NotNil(t, auroraconfig.FindField(fields, "jolokia"))
+		assert.Len(t, fields, len(auroraconfig.Fields)+1)
+	})
+
+	t.Run("Should use the fields ao knows when there are no applications", func(t *testing.T) {
+		auroraConfigClient := client.NewAuroraConfigClientMock(auroraconfig.FileNames{"about.json"})
+		deploySpecClient := client.NewDeploySpecClientMock([]deploymentspec.DeploymentSpec{spec})
+
+		fields, err := getSchemaFields(auroraConfigClient, deploySpecClient)
+		assert.NoError(t, err)
+		assert.Equal(t, auroraconfig.Fields, fields)
+	})
+}
+
+func Test_marshalSchema(t *testing.T) {
+	data, err := marshalSchema("env", auroraconfig.Fields)
+	assert.NoError(t, err)
+
+	var schema map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &schema))
+	assert.Equal(t, "AuroraConfig env file", schema["title"])
+
+	_, err = marshalSchema("unknown", auroraconfig.Fields)
+	assert.Error(t, err)
+}
@@ -4,13 +4,37 @@ import (
 	"fmt"
 	"runtime"
 
+	"github.com/skatteetaten/ao/pkg/config"
 	"github.com/spf13/cobra"
 )
 
+const updateLong = `Available updates are searched for using a service in the OpenShift cluster.
+The new version is verified against a signed SHA-256 manifest, and only applied if it can show its version.
+The replaced version is kept as ao.previous next to ao, and can be restored with --rollback.
+
+Updates are taken from the stable release channel, unless the beta channel is selected with
+"ao adm update-channel beta".
+
+With --check, ao only tells if an update is available, and exits with one of these exit codes:
+  0   ao is up to date
+  2   an update is available
+  3   the update server could not be reached`
+
+// Exit codes of `ao update --check`
+const (
+	ExitCodeUpdateAvailable   = 2
+	ExitCodeUpdateCheckFailed = 3
+)
+
+var (
+	flagRollback    bool
+	flagUpdateCheck bool
+)
+
 var updateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Check for available updates for the ao client, and downloads the update if available.",
-	Long:  `Available updates are searched for using a service in the OpenShift cluster.`,
+	Long:  updateLong,
 	RunE:  Update,
 }
 
@@ -18,10 +42,25 @@ func init() {
 	if runtime.GOOS != "windows" {
 		RootCmd.AddCommand(updateCmd)
 	}
+
+	updateCmd.Flags().BoolVarP(&flagRollback, "rollback", "", false, "restore the version of ao from before the last update")
+	updateCmd.Flags().BoolVarP(&flagUpdateCheck, "check", "", false, "only check if an update is available")
 }
 
 // Update is the entry point of the `update` cli command
 func Update(cmd *cobra.Command, args []string) error {
+	if flagRollback {
+		if err := config.Rollback(); err != nil {
+			return err
+		}
+		fmt.Println("AO has been rolled back to the previous version")
+		return nil
+	}
+
+	if flagUpdateCheck {
+		return checkForUpdate(cmd)
+	}
+
 	err := AO.Update(true)
 	if err != nil {
 		return err
@@ -30,3 +69,16 @@ func Update(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func checkForUpdate(cmd *cobra.Command) error {
+	serverVersion, err := AO.CheckForUpdate()
+	if err != nil {
+		return &ExitError{Code: ExitCodeUpdateCheckFailed, Err: err}
+	}
+	if !serverVersion.IsNewVersion() {
+		cmd.Printf("AO is up to date (%s, %s channel)\n", config.Version, AO.Channel())
+		return nil
+	}
+	cmd.Printf("A new version of AO is available (%s -> %s, %s channel)\n", config.Version, serverVersion.Version, AO.Channel())
+	return &ExitError{Code: ExitCodeUpdateAvailable}
+}
@@ -1,18 +1,35 @@
 package cmd
 
 import (
+	"fmt"
+	"io"
 	"os"
+	"time"
 
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/skatteetaten/ao/pkg/auroraconfig"
+	"github.com/skatteetaten/ao/pkg/client"
 	"github.com/skatteetaten/ao/pkg/versioncontrol"
 	"github.com/spf13/cobra"
 )
 
+const validateLong = `Validate local modifications in the current AuroraConfig.
+
+With --hook, as run by the git hooks ao installs, only the files changed by the commit or push are checked.
+The changed files are linted, and the ApplicationDeploymentRefs that depend on a changed file are validated
+by Boober. Successful validations are cached by the content of the files, the Boober and the ref, so unchanged
+files are not validated again. Full validations are never cached.`
+
 var flagFullValidation bool
 var flagRemoteValidation bool
+var flagValidateHook string
+var flagValidateVerbose bool
 
 var validateCmd = &cobra.Command{
 	Use:         "validate",
 	Short:       "Validate local modifications in the current AuroraConfig",
+	Long:        validateLong,
 	Annotations: map[string]string{"type": "local"},
 	RunE:        Validate,
 }
@@ -22,6 +39,8 @@ func init() {
 	validateCmd.Flags().StringVarP(&flagAuroraConfig, "auroraconfig", "a", "", "AuroraConfig to validate")
 	validateCmd.Flags().BoolVarP(&flagFullValidation, "full", "f", false, "Validate resources")
 	validateCmd.Flags().BoolVarP(&flagRemoteValidation, "remote", "r", false, "Validate remote AuroraConfig instead of local files")
+	validateCmd.Flags().StringVar(&flagValidateHook, "hook", "", "Validate the changes of a commit or push, as done by the git hook [pre-commit, pre-push]")
+	validateCmd.Flags().BoolVarP(&flagValidateVerbose, "verbose", "v", false, "List the files that are not part of the AuroraConfig, and why")
 }
 
 // Validate is the entry point of the `validate` cli command
@@ -41,6 +60,10 @@ func Validate(cmd *cobra.Command, args []string) error {
 		DefaultAPIClient.Affiliation = flagAuroraConfig
 	}
 
+	if flagValidateHook != "" {
+		return validateHook(cmd, gitRoot, flagValidateHook)
+	}
+
 	var warnings string
 	if flagRemoteValidation {
 		cmd.Printf("Validating remote AuroraConfig=%s@%s fullValidation=%t\n", DefaultAPIClient.Affiliation, DefaultAPIClient.RefName, flagFullValidation)
@@ -49,7 +72,7 @@ func Validate(cmd *cobra.Command, args []string) error {
 			return err
 		}
 	} else {
-		ac, err := versioncontrol.CollectAuroraConfigFilesInRepo(DefaultAPIClient.Affiliation, gitRoot)
+		ac, err := collectLocalAuroraConfig(DefaultAPIClient.Affiliation, gitRoot, flagValidateVerbose, cmd.OutOrStdout())
 		if err != nil {
 			return err
 		}
@@ -60,14 +83,126 @@ func Validate(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if warnings != "" {
-		cmd.Println("")
-		cmd.Println("AuroraConfig contains the following warnings:")
-		cmd.Println("")
-		cmd.Println(warnings)
-	} else {
-		cmd.Println("OK")
+	printValidationWarnings(warnings, cmd.OutOrStdout())
+	return nil
+}
+
+// validateHook validates the files changed by the commit or push that runs the git hook
+func validateHook(cmd *cobra.Command, gitRoot, hookType string) error {
+	lintConfig, err := loadLintConfig(gitRoot, "")
+	if err != nil {
+		return err
+	}
+	cache := versioncontrol.LoadValidationCache(gitRoot)
+	out := cmd.OutOrStdout()
+
+	switch hookType {
+	case versioncontrol.HookPreCommit:
+		changed, err := versioncontrol.StagedFiles(gitRoot)
+		if err != nil {
+			return err
+		}
+		ac, err := versioncontrol.CollectAuroraConfigFilesInIndex(DefaultAPIClient.Affiliation, gitRoot)
+		if err != nil {
+			return err
+		}
+		return validateChanges(ac, changed, lintConfig, DefaultAPIClient, DefaultAPIClient.Host, DefaultAPIClient.RefName, cache, out)
+
+	case versioncontrol.HookPrePush:
+		refs, err := versioncontrol.ReadPushedRefs(cmd.InOrStdin())
+		if err != nil {
+			return err
+		}
+		for _, ref := range refs {
+			if ref.IsDelete() {
+				continue
+			}
+			changed, err := versioncontrol.PushedFiles(gitRoot, ref)
+			if err != nil {
+				return err
+			}
+			ac, err := versioncontrol.CollectAuroraConfigFilesInCommit(DefaultAPIClient.Affiliation, gitRoot, ref.LocalSHA)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Checking %s\n", ref.LocalRef)
+			if err := validateChanges(ac, changed, lintConfig, DefaultAPIClient, DefaultAPIClient.Host, DefaultAPIClient.RefName, cache, out); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return errors.Errorf("unsupported git hook %s, must be one of %v", hookType, versioncontrol.HookTypes)
+}
+
+// validateChanges lints the changed files, and validates the ApplicationDeploymentRefs that depend on them with the
+// Boober at apiHost for the given ref. Validations without full validation are cached.
+func validateChanges(ac *auroraconfig.AuroraConfig, changed []string, lintConfig *auroraconfig.LintConfig, apiClient client.AuroraConfigClient, apiHost, ref string, cache *versioncontrol.ValidationCache, out io.Writer) error {
+	findings, err := ac.Lint(lintConfig)
+	if err != nil {
+		return err
+	}
+
+	isChanged := make(map[string]bool)
+	for _, name := range changed {
+		isChanged[name] = true
+	}
+	var changedFindings []auroraconfig.LintFinding
+	lintErrors := false
+	for _, finding := range findings {
+		if !isChanged[finding.File] {
+			continue
+		}
+		changedFindings = append(changedFindings, finding)
+		if finding.Severity == auroraconfig.SeverityError {
+			lintErrors = true
+		}
+	}
+
+	fmt.Fprintf(out, "Linting %d changed files\n", len(changed))
+	printLintFindings(changedFindings, out)
+	if lintErrors {
+		return &ExitError{Code: ExitCodeLintErrors, Err: errors.New("the changed files have lint errors")}
+	}
+
+	refs := ac.ApplicationDeploymentRefsAffectedBy(changed)
+	if len(refs) == 0 {
+		fmt.Fprintln(out, "No ApplicationDeploymentRefs are affected by the changes")
+		return nil
 	}
 
+	subset := ac.Subset(refs)
+	key := versioncontrol.ValidationCacheKey(subset, apiHost, ref)
+	if cached, found := cache.Get(key); found && !flagFullValidation {
+		fmt.Fprintf(out, "The %d affected ApplicationDeploymentRefs were validated at %s\n", len(refs), cached.ValidatedAt.Format(time.RFC3339))
+		printValidationWarnings(cached.Warnings, out)
+		return nil
+	}
+
+	fmt.Fprintf(out, "Validating %d affected ApplicationDeploymentRefs fullValidation=%t\n", len(refs), flagFullValidation)
+	warnings, err := apiClient.ValidateAuroraConfig(subset, flagFullValidation)
+	if err != nil {
+		return err
+	}
+
+	if !flagFullValidation {
+		cache.Put(key, warnings, time.Now())
+		if err := cache.Save(); err != nil {
+			logrus.Warnf("Could not save the validation cache: %s", err)
+		}
+	}
+	printValidationWarnings(warnings, out)
 	return nil
 }
+
+func printValidationWarnings(warnings string, out io.Writer) {
+	if warnings != "" {
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, "AuroraConfig contains the following warnings:")
+		fmt.Fprintln(out, "")
+		fmt.Fprintln(out, warnings)
+	} else {
+		fmt.Fprintln(out, "OK")
+	}
+}
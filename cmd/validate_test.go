@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/skatteetaten/ao/pkg/auroraconfig"
+	"github.com/skatteetaten/ao/pkg/client"
+	"github.com/skatteetaten/ao/pkg/versioncontrol"
+	"github.com/stretchr/testify/assert"
+)
+
+// validatingClientMock records the AuroraConfigs it is asked to validate
+type validatingClientMock struct {
+	*client.AuroraConfigClientMock
+	validated []*auroraconfig.AuroraConfig
+}
+
+func (api *validatingClientMock) ValidateAuroraConfig(ac *auroraconfig.AuroraConfig, fullValidation bool) (string, error) {
+	api.validated = append(api.validated, ac)
+	return "", nil
+}
+
+func Test_validateChanges(t *testing.T) {
+	gitRoot, err := ioutil.TempDir("", "ao-validate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(gitRoot)
+	assert.NoError(t, os.Mkdir(filepath.Join(gitRoot, ".git"), 0755))
+
+	ac := &auroraconfig.AuroraConfig{
+		Name: "paas",
+		Files: []auroraconfig.File{
+			{Name: "about.json", Contents: `{"affiliation": "paas"}`},
+			{Name: "utv/about.json", Contents: `{"cluster": "utv"}`},
+			{Name: "test/about.json", Contents: `{"cluster": "test", "foobar": true}`},
+			{Name: "foo.json", Contents: `{"version": "1"}`},
+			{Name: "bar.json", Contents: `{"version": "1"}`},
+			{Name: "utv/foo.json", Contents: `{}`},
+			{Name: "test/bar.json", Contents: `{}`},
+		},
+	}
+	lintConfig := auroraconfig.DefaultLintConfig()
+	const booberHost = "https://boober-utv"
+
+	t.Run("Should validate the refs affected by the changes, and cache the result", func(t *testing.T) {
+		apiClient := &validatingClientMock{AuroraConfigClientMock: client.NewAuroraConfigClientMock(nil)}
+		out := &bytes.Buffer{}
+
+		err := validateChanges(ac, []string{"foo.json"}, lintConfig, apiClient, booberHost, "master", versioncontrol.LoadValidationCache(gitRoot), out)
+		assert.NoError(t, err)
+		assert.Len(t, apiClient.validated, 1)
+		assert.Len(t, apiClient.validated[0].Files, 4)
+		assert.Contains(t, out.String(), "Validating 1 affected ApplicationDeploymentRefs")
+		assert.NotContains(t, out.String(), "foobar", "lint findings in unchanged files should not be reported")
+
+		out.Reset()
+		err = validateChanges(ac, []string{"foo.json"}, lintConfig, apiClient, booberHost, "master", versioncontrol.LoadValidationCache(gitRoot), out)
+		assert.NoError(t, err)
+		assert.Len(t, apiClient.validated, 1)
+		assert.Contains(t, out.String(), "The 1 affected ApplicationDeploymentRefs were validated at")
+
+		err = validateChanges(ac, []string{"foo.json"}, lintConfig, apiClient, "https://boober-test", "master", versioncontrol.LoadValidationCache(gitRoot), out)
+		assert.NoError(t, err)
+		err = validateChanges(ac, []string{"foo.json"}, lintConfig, apiClient, booberHost, "feature", versioncontrol.LoadValidationCache(gitRoot), out)
+		assert.NoError(t, err)
+		assert.Len(t, apiClient.validated, 3, "validations for another Boober or ref should not be reused")
+	})
+
+	t.Run("Should not cache full validations", func(t *testing.T) {
+		flagFullValidation = true
+		defer func() { flagFullValidation = false }()
+		apiClient := &validatingClientMock{AuroraConfigClientMock: client.NewAuroraConfigClientMock(nil)}
+
+		for i := 0; i < 2; i++ {
+			err := validateChanges(ac, []string{"bar.json"}, lintConfig, apiClient, booberHost, "master", versioncontrol.LoadValidationCache(gitRoot), &bytes.Buffer{})
+			assert.NoError(t, err)
+		}
+		assert.Len(t, apiClient.validated, 2)
+	})
+
+	t.Run("Should not validate when no refs are affected", func(t *testing.T) {
+		apiClient := &validatingClientMock{AuroraConfigClientMock: client.NewAuroraConfigClientMock(nil)}
+		out := &bytes.Buffer{}
+
+		err := validateChanges(ac, []string{"README.md"}, lintConfig, apiClient, booberHost, "master", versioncontrol.LoadValidationCache(gitRoot), out)
+		assert.NoError(t, err)
+		assert.Empty(t, apiClient.validated)
+		assert.Contains(t, out.String(), "No ApplicationDeploymentRefs are affected by the changes")
+	})
+
+	t.Run("Should fail without validating when the changed files have lint errors", func(t *testing.T) {
+		apiClient := &validatingClientMock{AuroraConfigClientMock: client.NewAuroraConfigClientMock(nil)}
+		broken := &auroraconfig.AuroraConfig{
+			Name:  "paas",
+			Files: append([]auroraconfig.File{{Name: "utv/baz.json", Contents: `{`}}, ac.Files...),
+		}
+		out := &bytes.Buffer{}
+
+		err := validateChanges(broken, []string{"utv/baz.json"}, lintConfig, apiClient, booberHost, "master", versioncontrol.LoadValidationCache(gitRoot), out)
+		assert.Error(t, err)
+		assert.Empty(t, apiClient.validated)
+		assert.Contains(t, out.String(), "utv/baz.json:1: error:")
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"strings"
+	"time"
 
 	"encoding/json"
 	"io/ioutil"
@@ -13,24 +14,46 @@ import (
 	"sort"
 
 	"github.com/pkg/errors"
+	"github.com/skatteetaten/ao/pkg/auroraconfig"
 	"github.com/skatteetaten/ao/pkg/client"
+	"github.com/skatteetaten/ao/pkg/config"
 	"github.com/skatteetaten/ao/pkg/editor"
 	"github.com/skatteetaten/ao/pkg/prompt"
+	"github.com/skatteetaten/ao/pkg/secrets"
 	"github.com/spf13/cobra"
 )
 
 var (
 	flagOnlyVaults bool
+	flagForce      bool
+
+	flagGenerateType   string
+	flagGenerateLength int
+	flagGenerateBits   int
+	flagGenerateKeys   []string
+
+	flagOlderThan string
 
 	errNoPermissionsSpecified = errors.New("No permission groups was specified")
 	errEmptyGroups            = errors.New("Cannot find groups in permissions")
 	errNotValidSecretArgument = errors.New("not a valid argument, must be <vaultname/secret>")
+	errRotationSecret         = errors.Errorf("%s holds the rotation metadata of the vault and is maintained by ao", client.RotationSecretName)
 )
 
 const createVaultLong = `Create a vault for storing secrets. A vault requires permissions for one or more groups. 
 These permissions are necessary to access the vault. 
 `
 
+const vaultGenerateLong = `Generate secret material in memory and add it to an existing vault. The secret is never written to disk.
+Key pairs (rsa, ed25519) are added as two secrets, the private key with the given name and the public key with a .pub suffix.
+A properties-template contains a random password for each of the given keys.
+`
+
+const vaultStaleLong = `List secrets that have not been rotated within the given age, or within their own rotation interval.
+Creation and rotation of secrets done with ao is recorded in the secret ` + client.RotationSecretName + ` in each vault, which is not listed by vault get and cannot be edited or deleted directly.
+Secrets without recorded metadata are always listed.
+`
+
 var (
 	vaultCmd = &cobra.Command{
 		Use:         "vault",
@@ -103,6 +126,38 @@ var (
 		Short: "Print the content of a secret to standard out",
 		RunE:  GetSecret,
 	}
+	vaultCopySecretCmd = &cobra.Command{
+		Use:   "copy-secret <vaultname/secretname> <vaultname>[/secretname]",
+		Short: "Copy a secret to another vault",
+		RunE:  CopySecret,
+	}
+	vaultMoveSecretCmd = &cobra.Command{
+		Use:   "move-secret <vaultname/secretname> <vaultname>[/secretname]",
+		Short: "Move a secret to another vault",
+		RunE:  MoveSecret,
+	}
+	vaultGenerateCmd = &cobra.Command{
+		Use:   "generate <vaultname/secretname>",
+		Short: "Generate a random password, key or key pair and add it as a secret to an existing vault",
+		Long:  vaultGenerateLong,
+		RunE:  GenerateSecret,
+	}
+	vaultStaleCmd = &cobra.Command{
+		Use:   "stale",
+		Short: "List secrets that are due for rotation",
+		Long:  vaultStaleLong,
+		RunE:  ListStaleSecrets,
+	}
+	vaultSetRotationIntervalCmd = &cobra.Command{
+		Use:   "set-rotation-interval <vaultname/secretname> <interval>",
+		Short: "Set how often a secret should be rotated, e.g. 90d",
+		RunE:  SetRotationInterval,
+	}
+	vaultUsageCmd = &cobra.Command{
+		Use:   "usage <vaultname>",
+		Short: "List the ApplicationDeploymentRefs that mount a vault",
+		RunE:  VaultUsage,
+	}
 )
 
 func init() {
@@ -119,9 +174,25 @@ func init() {
 	vaultCmd.AddCommand(vaultRenameCmd)
 	vaultCmd.AddCommand(vaultRenameSecretCmd)
 	vaultCmd.AddCommand(vaultGetSecretCmd)
+	vaultCmd.AddCommand(vaultUsageCmd)
+	vaultCmd.AddCommand(vaultCopySecretCmd)
+	vaultCmd.AddCommand(vaultMoveSecretCmd)
+	vaultCmd.AddCommand(vaultGenerateCmd)
+	vaultCmd.AddCommand(vaultStaleCmd)
+	vaultCmd.AddCommand(vaultSetRotationIntervalCmd)
 
 	vaultGetCmd.Flags().BoolVarP(&flagAsList, "list", "", false, "print vault/secret as a list")
 	vaultGetCmd.Flags().BoolVarP(&flagOnlyVaults, "only-vaults", "", false, "print vaults as a list")
+	vaultGenerateCmd.Flags().StringVarP(&flagGenerateType, "type", "", secrets.TypePassword, fmt.Sprintf("type of secret to generate %v", secrets.Types))
+	vaultGenerateCmd.Flags().IntVarP(&flagGenerateLength, "length", "", 0, "number of characters in passwords, or number of random bytes for hex (default 32)")
+	vaultGenerateCmd.Flags().IntVarP(&flagGenerateBits, "bits", "", 0, "size of rsa keys (default 4096)")
+	vaultGenerateCmd.Flags().StringSliceVarP(&flagGenerateKeys, "keys", "", []string{}, "property names in a properties-template (default PASSWORD)")
+	vaultStaleCmd.Flags().StringVarP(&flagOlderThan, "older-than", "", "90d", "list secrets not rotated within this age, e.g. 90d, 12w or 2160h")
+	vaultGenerateCmd.Flags().BoolVarP(&flagForce, "force", "f", false, "overwrite secrets that already exist")
+	vaultDeleteCmd.Flags().BoolVarP(&flagForce, "force", "f", false, "delete vault even if it is used by applications")
+	vaultDeleteSecretCmd.Flags().BoolVarP(&flagForce, "force", "f", false, "delete secret even if the vault is used by applications")
+	vaultCopySecretCmd.Flags().BoolVarP(&flagForce, "force", "f", false, "overwrite the destination secret if it exists")
+	vaultMoveSecretCmd.Flags().BoolVarP(&flagForce, "force", "f", false, "overwrite the destination secret if it exists, and move even if the source vault is used by applications")
 }
 
 // GetSecret is the entry point of the `vault get-secret` cli command
@@ -191,6 +262,262 @@ func RenameSecret(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// CopySecret is the entry point of the `vault copy-secret` cli command
+func CopySecret(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return cmd.Usage()
+	}
+
+	destination, err := transferSecret(DefaultAPIClient, args[0], args[1], false, flagForce)
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Secret %s has been copied to %s\n", args[0], destination)
+	return nil
+}
+
+// MoveSecret is the entry point of the `vault move-secret` cli command
+func MoveSecret(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return cmd.Usage()
+	}
+
+	sourceVault := strings.Split(args[0], "/")[0]
+	if err := checkVaultUsage(cmd, sourceVault); err != nil {
+		return err
+	}
+
+	destination, err := transferSecret(DefaultAPIClient, args[0], args[1], true, flagForce)
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Secret %s has been moved to %s\n", args[0], destination)
+	return nil
+}
+
+// transferSecret copies a secret to another vault, and removes the source secret when moving.
+// An existing destination secret is only overwritten if forced. If the source secret cannot be removed,
+// the copy is rolled back, restoring the destination secret it overwrote.
+func transferSecret(vaultClient client.VaultClient, source, destination string, move, force bool) (string, error) {
+	split := strings.Split(source, "/")
+	if len(split) != 2 {
+		return "", errNotValidSecretArgument
+	}
+	sourceVault, secretName := split[0], split[1]
+
+	split = strings.Split(destination, "/")
+	if len(split) > 2 {
+		return "", errors.New("not a valid destination, must be <vaultname>[/secret]")
+	}
+	destinationVault, destinationName := split[0], secretName
+	if len(split) == 2 && split[1] != "" {
+		destinationName = split[1]
+	}
+
+	if sourceVault == destinationVault && secretName == destinationName {
+		return "", errors.New("source and destination is the same secret")
+	}
+
+	secret, err := vaultClient.GetSecret(sourceVault, secretName)
+	if err != nil {
+		return "", err
+	}
+
+	previous, err := existingSecret(vaultClient, destinationVault, destinationName, force)
+	if err != nil {
+		return "", err
+	}
+
+	copied := client.NewSecret(destinationName, secret.Base64Content)
+	if err := vaultClient.AddSecrets(destinationVault, []client.Secret{copied}); err != nil {
+		return "", err
+	}
+
+	if move {
+		if err := vaultClient.RemoveSecrets(sourceVault, []string{secretName}); err != nil {
+			var rollbackErr error
+			if previous != nil {
+				rollbackErr = vaultClient.AddSecrets(destinationVault, []client.Secret{*previous})
+			} else {
+				rollbackErr = vaultClient.RemoveSecrets(destinationVault, []string{destinationName})
+			}
+			if rollbackErr != nil {
+				return "", errors.Errorf("Failed to delete %s: %s\nRollback of copy failed, %s/%s must be restored manually: %s", source, err, destinationVault, destinationName, rollbackErr)
+			}
+			return "", errors.Wrapf(err, "Failed to delete %s, copy has been rolled back", source)
+		}
+	}
+
+	return destinationVault + "/" + destinationName, nil
+}
+
+// GenerateSecret is the entry point of the `vault generate` cli command
+func GenerateSecret(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Usage()
+	}
+
+	options := secrets.GenerateOptions{
+		Type:   flagGenerateType,
+		Length: flagGenerateLength,
+		Bits:   flagGenerateBits,
+		Keys:   flagGenerateKeys,
+	}
+	names, err := generateSecret(DefaultAPIClient, args[0], options, flagForce)
+	if err != nil {
+		return err
+	}
+
+	cmd.Printf("Generated %s %s\n", flagGenerateType, strings.Join(names, ", "))
+	return nil
+}
+
+func generateSecret(vaultClient client.VaultClient, arg string, options secrets.GenerateOptions, force bool) ([]string, error) {
+	split := strings.Split(arg, "/")
+	if len(split) != 2 {
+		return nil, errNotValidSecretArgument
+	}
+	vaultName, secretName := split[0], split[1]
+
+	files, err := secrets.Generate(secretName, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var generated []client.Secret
+	var names []string
+	for _, file := range files {
+		if _, err := existingSecret(vaultClient, vaultName, file.Name, force); err != nil {
+			return nil, err
+		}
+		generated = append(generated, client.NewSecret(file.Name, base64.StdEncoding.EncodeToString(file.Content)))
+		names = append(names, vaultName+"/"+file.Name)
+	}
+
+	if err := vaultClient.AddSecrets(vaultName, generated); err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// existingSecret gets a secret that is about to be overwritten. It is nil if the secret does not exist, and an
+// error unless force is given if it does.
+func existingSecret(vaultClient client.VaultClient, vaultName, secretName string, force bool) (*client.Secret, error) {
+	secret, err := vaultClient.GetSecret(vaultName, secretName)
+	var notFound *client.SecretNotFoundError
+	if errors.As(err, &notFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	} else if !force {
+		return nil, errors.Errorf("Secret %s/%s already exists, use --force to overwrite it", vaultName, secretName)
+	}
+	return secret, nil
+}
+
+// ListStaleSecrets is the entry point of the `vault stale` cli command
+func ListStaleSecrets(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Usage()
+	}
+
+	olderThan, err := client.ParseRotationInterval(flagOlderThan)
+	if err != nil {
+		return err
+	}
+
+	vaults, err := DefaultAPIClient.GetVaults()
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(vaults, func(i, j int) bool {
+		return vaults[i].Name < vaults[j].Name
+	})
+
+	now := time.Now()
+	var rows []string
+	for _, vault := range vaults {
+		if !vault.HasAccess || len(vault.Secrets) == 0 {
+			continue
+		}
+
+		metadata, _, err := DefaultAPIClient.GetRotationMetadata(vault.Name)
+		if err != nil {
+			logrus.Warnf("Could not get rotation metadata for vault %s: %s", vault.Name, err)
+			continue
+		}
+
+		var secretNames []string
+		for _, secret := range vault.Secrets {
+			secretNames = append(secretNames, secret.Name)
+		}
+
+		for _, stale := range metadata.StaleSecrets(secretNames, olderThan, now) {
+			rows = append(rows, getStaleSecretRow(vault.Name, stale))
+		}
+	}
+
+	if len(rows) == 0 {
+		cmd.Println("No secrets are due for rotation")
+		return nil
+	}
+
+	DefaultTablePrinter("VAULT/SECRET\tLAST ROTATED\tREASON", rows, cmd.OutOrStdout())
+	return nil
+}
+
+func getStaleSecretRow(vaultName string, stale client.StaleSecret) string {
+	lastRotated := "unknown"
+	if !stale.LastRotated.IsZero() {
+		lastRotated = stale.LastRotated.Format("2006-01-02")
+	}
+	return fmt.Sprintf("%s/%s\t%s\t%s", vaultName, stale.Name, lastRotated, stale.Reason)
+}
+
+// SetRotationInterval is the entry point of the `vault set-rotation-interval` cli command
+func SetRotationInterval(cmd *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return cmd.Usage()
+	}
+	split := strings.Split(args[0], "/")
+	if len(split) != 2 {
+		return errNotValidSecretArgument
+	}
+	vaultName, secretName := split[0], split[1]
+	interval := args[1]
+
+	if _, err := client.ParseRotationInterval(interval); err != nil {
+		return err
+	}
+
+	if _, err := DefaultAPIClient.GetSecret(vaultName, secretName); err != nil {
+		return err
+	}
+
+	metadata, exists, err := DefaultAPIClient.GetRotationMetadata(vaultName)
+	if err != nil {
+		return err
+	}
+
+	rotation, found := metadata.Secrets[secretName]
+	if !found {
+		rotation = &client.SecretRotation{}
+		metadata.Secrets[secretName] = rotation
+	}
+	rotation.RotationInterval = interval
+
+	if err := DefaultAPIClient.SaveRotationMetadata(vaultName, metadata, exists); err != nil {
+		return err
+	}
+
+	cmd.Printf("Secret %s will be due for rotation every %s\n", args[0], interval)
+	return nil
+}
+
 // RenameVault is the entry point of the `vault rename` cli command
 func RenameVault(cmd *cobra.Command, args []string) error {
 	if len(args) != 2 {
@@ -259,6 +586,10 @@ func EditSecret(cmd *cobra.Command, args []string) error {
 	}
 
 	vaultName, secretName := split[0], split[1]
+	if secretName == client.RotationSecretName {
+		return errRotationSecret
+	}
+
 	secret, err := DefaultAPIClient.GetSecret(vaultName, secretName)
 	if err != nil {
 		return err
@@ -293,7 +624,17 @@ func DeleteSecret(cmd *cobra.Command, args []string) error {
 	}
 
 	vaultName, secret := split[0], split[1]
+	if secret == client.RotationSecretName {
+		return errRotationSecret
+	}
+
+	if err := checkVaultUsage(cmd, vaultName); err != nil {
+		return err
+	}
 
+	if config.CI {
+		return errors.New("deleting a secret asks for confirmation, which is not possible in CI mode")
+	}
 	message := fmt.Sprintf("Do you want to delete secret %s in affiliation %s?", args[0], AO.Affiliation)
 	shouldDelete := prompt.Confirm(message, false)
 	if !shouldDelete {
@@ -316,6 +657,13 @@ func DeleteVault(cmd *cobra.Command, args []string) error {
 		return cmd.Usage()
 	}
 
+	if err := checkVaultUsage(cmd, args[0]); err != nil {
+		return err
+	}
+
+	if config.CI {
+		return errors.New("deleting a vault asks for confirmation, which is not possible in CI mode")
+	}
 	message := fmt.Sprintf("Do you want to delete vault %s in affiliation %s?", args[0], AO.Affiliation)
 	shouldDelete := prompt.Confirm(message, false)
 	if !shouldDelete {
@@ -331,6 +679,70 @@ func DeleteVault(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// VaultUsage is the entry point of the `vault usage` cli command
+func VaultUsage(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmd.Usage()
+	}
+
+	usages, err := findVaultUsages(args[0])
+	if err != nil {
+		return err
+	}
+
+	if len(usages) == 0 {
+		cmd.Printf("Vault %s is not used by any application\n", args[0])
+		return nil
+	}
+
+	header, rows := getVaultUsageTable(usages)
+	DefaultTablePrinter(header, rows, cmd.OutOrStdout())
+	return nil
+}
+
+func findVaultUsages(vaultName string) ([]auroraconfig.VaultUsage, error) {
+	ac, err := DefaultAPIClient.GetAuroraConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return ac.UsagesOfVault(vaultName)
+}
+
+// checkVaultUsage refuses further operation on a vault in use, unless forced
+func checkVaultUsage(cmd *cobra.Command, vaultName string) error {
+	usages, err := findVaultUsages(vaultName)
+	if err != nil {
+		if flagForce {
+			logrus.Warnf("Could not check usage of vault %s: %s", vaultName, err)
+			return nil
+		}
+		return errors.Wrapf(err, "Could not check usage of vault %s, use --force to skip check", vaultName)
+	}
+
+	if len(usages) == 0 {
+		return nil
+	}
+
+	cmd.Printf("Vault %s is used by:\n", vaultName)
+	header, rows := getVaultUsageTable(usages)
+	DefaultTablePrinter(header, rows, cmd.OutOrStdout())
+
+	if !flagForce {
+		return errors.Errorf("Vault %s is in use, use --force to delete anyway", vaultName)
+	}
+	return nil
+}
+
+func getVaultUsageTable(usages []auroraconfig.VaultUsage) (string, []string) {
+	var rows []string
+	for _, usage := range usages {
+		rows = append(rows, fmt.Sprintf("%s\t%s", usage.ApplicationDeploymentRef, usage.Source))
+	}
+
+	return "APPLICATIONDEPLOYMENTREF\tDECLARED IN", rows
+}
+
 // ListVaults is the entry point of the `vault get` cli command
 func ListVaults(cmd *cobra.Command, args []string) error {
 	if len(args) > 1 {
@@ -341,6 +753,7 @@ func ListVaults(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	hideRotationSecrets(vaults)
 
 	var header string
 	var rows []string
@@ -371,6 +784,19 @@ func ListVaults(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// hideRotationSecrets removes the rotation metadata from the secrets of the vaults, as it is not a secret of its own
+func hideRotationSecrets(vaults []client.Vault) {
+	for i := range vaults {
+		var secrets []client.Secret
+		for _, secret := range vaults[i].Secrets {
+			if secret.Name != client.RotationSecretName {
+				secrets = append(secrets, secret)
+			}
+		}
+		vaults[i].Secrets = secrets
+	}
+}
+
 // VaultAddPermissions is the entry point of the `vault add-permissions` cli command
 func VaultAddPermissions(cmd *cobra.Command, args []string) error {
 	if len(args) < 2 {
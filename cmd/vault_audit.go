@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/skatteetaten/ao/pkg/client"
+	"github.com/spf13/cobra"
+)
+
+const (
+	findingNoPermissionGroups    = "no permission groups"
+	findingSinglePermissionGroup = "single permission group"
+	vaultAuditFormatTable        = "table"
+	vaultAuditFormatCSV          = "csv"
+	vaultAuditFormatJSON         = "json"
+	vaultAuditLong               = `Audit access to all vaults in the affiliation.
+Lists every permission group with the vaults it can read, and vaults that have a single permission group or none.
+The report can be printed as a table, or as csv and json for further processing.`
+)
+
+var flagVaultAuditFormat string
+
+var vaultAuditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Report vault permissions for all groups in the affiliation",
+	Long:  vaultAuditLong,
+	RunE:  AuditVaults,
+}
+
+// vaultAuditReport is the result of auditing the permissions of all vaults in an affiliation
+type vaultAuditReport struct {
+	Affiliation string         `json:"affiliation"`
+	Groups      []groupAccess  `json:"groups"`
+	Findings    []vaultFinding `json:"findings"`
+}
+
+// groupAccess lists the vaults a permission group can read
+type groupAccess struct {
+	Group  string   `json:"group"`
+	Vaults []string `json:"vaults"`
+}
+
+// vaultFinding is a vault with too few permission groups
+type vaultFinding struct {
+	Vault       string   `json:"vault"`
+	Permissions []string `json:"permissions"`
+	Finding     string   `json:"finding"`
+}
+
+func init() {
+	vaultCmd.AddCommand(vaultAuditCmd)
+	vaultAuditCmd.Flags().StringVarP(&flagVaultAuditFormat, "output", "o", vaultAuditFormatTable, "output format [table, csv, json]")
+}
+
+// AuditVaults is the entry point of the `vault audit` cli command
+func AuditVaults(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmd.Usage()
+	}
+
+	vaults, err := DefaultAPIClient.GetVaults()
+	if err != nil {
+		return err
+	}
+	if len(vaults) == 0 {
+		return errors.New("No vaults available")
+	}
+
+	report := createVaultAuditReport(DefaultAPIClient.Affiliation, vaults)
+
+	switch flagVaultAuditFormat {
+	case vaultAuditFormatTable:
+		printVaultAuditTable(report, cmd.OutOrStdout())
+		return nil
+	case vaultAuditFormatCSV:
+		return writeVaultAuditCSV(report, cmd.OutOrStdout())
+	case vaultAuditFormatJSON:
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		cmd.Println(string(data))
+		return nil
+	default:
+		return errors.Errorf("Unknown output format %s, must be one of [table, csv, json]", flagVaultAuditFormat)
+	}
+}
+
+func createVaultAuditReport(affiliation string, vaults []client.Vault) vaultAuditReport {
+	report := vaultAuditReport{
+		Affiliation: affiliation,
+		Groups:      []groupAccess{},
+		Findings:    []vaultFinding{},
+	}
+
+	sort.Slice(vaults, func(i, j int) bool {
+		return vaults[i].Name < vaults[j].Name
+	})
+
+	groupVaults := make(map[string][]string)
+	for _, vault := range vaults {
+		for _, group := range vault.Permissions {
+			groupVaults[group] = append(groupVaults[group], vault.Name)
+		}
+
+		switch len(vault.Permissions) {
+		case 0:
+			report.Findings = append(report.Findings, vaultFinding{
+				Vault:       vault.Name,
+				Permissions: []string{},
+				Finding:     findingNoPermissionGroups,
+			})
+		case 1:
+			report.Findings = append(report.Findings, vaultFinding{
+				Vault:       vault.Name,
+				Permissions: vault.Permissions,
+				Finding:     findingSinglePermissionGroup,
+			})
+		}
+	}
+
+	groups := make([]string, 0, len(groupVaults))
+	for group := range groupVaults {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	for _, group := range groups {
+		report.Groups = append(report.Groups, groupAccess{
+			Group:  group,
+			Vaults: groupVaults[group],
+		})
+	}
+
+	return report
+}
+
+func printVaultAuditTable(report vaultAuditReport, out io.Writer) {
+	var rows []string
+	for _, access := range report.Groups {
+		rows = append(rows, fmt.Sprintf("%s\t%s", access.Group, strings.Join(access.Vaults, ", ")))
+	}
+	DefaultTablePrinter("GROUP\tVAULTS", rows, out)
+
+	if len(report.Findings) == 0 {
+		return
+	}
+
+	fmt.Fprintln(out)
+	rows = []string{}
+	for _, finding := range report.Findings {
+		rows = append(rows, fmt.Sprintf("%s\t%s\t%s", finding.Vault, finding.Permissions, finding.Finding))
+	}
+	DefaultTablePrinter("VAULT\tPERMISSIONS\tFINDING", rows, out)
+}
+
+// writeVaultAuditCSV writes one row per group and vault, followed by one row per finding with the permission groups
+// of the vault, space separated, in the permissions column
+func writeVaultAuditCSV(report vaultAuditReport, out io.Writer) error {
+	w := csv.NewWriter(out)
+	if err := w.Write([]string{"affiliation", "group", "vault", "permissions", "finding"}); err != nil {
+		return err
+	}
+
+	for _, access := range report.Groups {
+		for _, vault := range access.Vaults {
+			if err := w.Write([]string{report.Affiliation, access.Group, vault, "", ""}); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, finding := range report.Findings {
+		record := []string{report.Affiliation, "", finding.Vault, strings.Join(finding.Permissions, " "), finding.Finding}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
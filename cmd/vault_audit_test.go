@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/skatteetaten/ao/pkg/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_createVaultAuditReport(t *testing.T) {
+	vaults := []client.Vault{
+		{Name: "shared", Permissions: []string{"devops", "paas"}},
+		{Name: "nobody", Permissions: []string{}},
+		{Name: "private", Permissions: []string{"paas"}},
+	}
+
+	report := createVaultAuditReport("paas", vaults)
+
+	assert.Equal(t, "paas", report.Affiliation)
+	assert.Equal(t, []groupAccess{
+		{Group: "devops", Vaults: []string{"shared"}},
+		{Group: "paas", Vaults: []string{"private", "shared"}},
+	}, report.Groups)
+	assert.Equal(t, []vaultFinding{
+		{Vault: "nobody", Permissions: []string{}, Finding: findingNoPermissionGroups},
+		{Vault: "private", Permissions: []string{"paas"}, Finding: findingSinglePermissionGroup},
+	}, report.Findings)
+
+	t.Run("Should write report as csv", func(t *testing.T) {
+		buffer := &bytes.Buffer{}
+		err := writeVaultAuditCSV(report, buffer)
+		assert.NoError(t, err)
+
+		expected := "affiliation,group,vault,permissions,finding\n" +
+			"paas,devops,shared,,\n" +
+			"paas,paas,private,,\n" +
+			"paas,paas,shared,,\n" +
+			"paas,,nobody,,no permission groups\n" +
+			"paas,,private,paas,single permission group\n"
+		assert.Equal(t, expected, buffer.String())
+	})
+}
@@ -7,8 +7,12 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/pkg/errors"
+	"github.com/skatteetaten/ao/pkg/auroraconfig"
 	"github.com/skatteetaten/ao/pkg/client"
+	"github.com/skatteetaten/ao/pkg/secrets"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 var (
@@ -121,3 +125,153 @@ func Test_aggregatePermissions(t *testing.T) {
 		})
 	}
 }
+
+func Test_getVaultUsageTable(t *testing.T) {
+	usages := []auroraconfig.VaultUsage{
+		{ApplicationDeploymentRef: "utv/foo", Vault: "foo-vault", Source: "foo.json"},
+		{ApplicationDeploymentRef: "test/foo", Vault: "foo-vault", Source: "test/foo.json"},
+	}
+
+	header, rows := getVaultUsageTable(usages)
+	assert.Equal(t, "APPLICATIONDEPLOYMENTREF\tDECLARED IN", header)
+	assert.Equal(t, []string{"utv/foo\tfoo.json", "test/foo\ttest/foo.json"}, rows)
+}
+
+func Test_transferSecret(t *testing.T) {
+	newVaults := func() []client.Vault {
+		return []client.Vault{
+			{Name: "source", Secrets: []client.Secret{client.NewSecret("latest.properties", "Rk9PPUJBUg==")}},
+			{Name: "destination", Secrets: []client.Secret{}},
+		}
+	}
+
+	t.Run("Should copy secret to another vault with a new name", func(t *testing.T) {
+		vaultClient := client.NewVaultClientMock(newVaults()...)
+		vaultClient.On("AddSecrets", "destination", mock.Anything).Return(nil)
+
+		destination, err := transferSecret(vaultClient, "source/latest.properties", "destination/copy.properties", false, false)
+		assert.NoError(t, err)
+		assert.Equal(t, "destination/copy.properties", destination)
+		assert.Len(t, vaultClient.Vaults[0].Secrets, 1)
+		assert.Equal(t, []client.Secret{client.NewSecret("copy.properties", "Rk9PPUJBUg==")}, vaultClient.Vaults[1].Secrets)
+		vaultClient.AssertExpectations(t)
+	})
+
+	t.Run("Should move secret to another vault", func(t *testing.T) {
+		vaultClient := client.NewVaultClientMock(newVaults()...)
+		vaultClient.On("AddSecrets", "destination", mock.Anything).Return(nil)
+		vaultClient.On("RemoveSecrets", "source", []string{"latest.properties"}).Return(nil)
+
+		destination, err := transferSecret(vaultClient, "source/latest.properties", "destination", true, false)
+		assert.NoError(t, err)
+		assert.Equal(t, "destination/latest.properties", destination)
+		assert.Empty(t, vaultClient.Vaults[0].Secrets)
+		assert.Len(t, vaultClient.Vaults[1].Secrets, 1)
+		vaultClient.AssertExpectations(t)
+	})
+
+	t.Run("Should roll back copy when move fails to delete source", func(t *testing.T) {
+		vaultClient := client.NewVaultClientMock(newVaults()...)
+		vaultClient.On("AddSecrets", "destination", mock.Anything).Return(nil)
+		vaultClient.On("RemoveSecrets", "source", []string{"latest.properties"}).Return(errors.New("access denied"))
+		vaultClient.On("RemoveSecrets", "destination", []string{"latest.properties"}).Return(nil)
+
+		_, err := transferSecret(vaultClient, "source/latest.properties", "destination", true, false)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "copy has been rolled back")
+		assert.Len(t, vaultClient.Vaults[0].Secrets, 1)
+		assert.Empty(t, vaultClient.Vaults[1].Secrets)
+		vaultClient.AssertExpectations(t)
+	})
+
+	t.Run("Should not overwrite an existing secret unless forced", func(t *testing.T) {
+		vaults := newVaults()
+		vaults[1].Secrets = []client.Secret{client.NewSecret("latest.properties", "T0xEPTE=")}
+		vaultClient := client.NewVaultClientMock(vaults...)
+
+		_, err := transferSecret(vaultClient, "source/latest.properties", "destination", true, false)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "use --force to overwrite")
+		assert.Len(t, vaultClient.Vaults[0].Secrets, 1)
+		assert.Equal(t, "T0xEPTE=", vaultClient.Vaults[1].Secrets[0].Base64Content)
+		vaultClient.AssertNotCalled(t, "AddSecrets", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Should restore an overwritten secret when move fails to delete source", func(t *testing.T) {
+		vaults := newVaults()
+		vaults[1].Secrets = []client.Secret{client.NewSecret("latest.properties", "T0xEPTE=")}
+		vaultClient := client.NewVaultClientMock(vaults...)
+		vaultClient.On("AddSecrets", "destination", mock.Anything).Return(nil)
+		vaultClient.On("RemoveSecrets", "source", []string{"latest.properties"}).Return(errors.New("access denied"))
+
+		_, err := transferSecret(vaultClient, "source/latest.properties", "destination", true, true)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "copy has been rolled back")
+		assert.Len(t, vaultClient.Vaults[0].Secrets, 1)
+		assert.Equal(t, []client.Secret{client.NewSecret("latest.properties", "T0xEPTE=")}, vaultClient.Vaults[1].Secrets)
+		vaultClient.AssertNotCalled(t, "RemoveSecrets", "destination", mock.Anything)
+	})
+
+	t.Run("Should fail on invalid arguments", func(t *testing.T) {
+		vaultClient := client.NewVaultClientMock(newVaults()...)
+
+		_, err := transferSecret(vaultClient, "source", "destination", false, false)
+		assert.Equal(t, errNotValidSecretArgument, err)
+
+		_, err = transferSecret(vaultClient, "source/latest.properties", "source", false, false)
+		assert.Error(t, err)
+	})
+}
+
+func Test_generateSecret(t *testing.T) {
+	t.Run("Should add generated key pair to vault", func(t *testing.T) {
+		vaultClient := client.NewVaultClientMock(client.Vault{Name: "keys"})
+		vaultClient.On("AddSecrets", "keys", mock.Anything).Return(nil)
+
+		names, err := generateSecret(vaultClient, "keys/signing", secrets.GenerateOptions{Type: secrets.TypeEd25519}, false)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"keys/signing", "keys/signing.pub"}, names)
+		assert.Len(t, vaultClient.Vaults[0].Secrets, 2)
+		vaultClient.AssertExpectations(t)
+	})
+
+	t.Run("Should not add anything when generation fails", func(t *testing.T) {
+		vaultClient := client.NewVaultClientMock(client.Vault{Name: "keys"})
+
+		_, err := generateSecret(vaultClient, "keys/signing", secrets.GenerateOptions{Type: "unknown"}, false)
+		assert.Error(t, err)
+		assert.Empty(t, vaultClient.Vaults[0].Secrets)
+	})
+
+	t.Run("Should only overwrite existing secrets with force", func(t *testing.T) {
+		existing := client.NewSecret("signing.pub", "b2xk")
+		vaultClient := client.NewVaultClientMock(client.Vault{Name: "keys", Secrets: []client.Secret{existing}})
+		vaultClient.On("AddSecrets", "keys", mock.Anything).Return(nil)
+
+		_, err := generateSecret(vaultClient, "keys/signing", secrets.GenerateOptions{Type: secrets.TypeEd25519}, false)
+		assert.EqualError(t, err, "Secret keys/signing.pub already exists, use --force to overwrite it")
+		vaultClient.AssertNotCalled(t, "AddSecrets", "keys", mock.Anything)
+
+		_, err = generateSecret(vaultClient, "keys/signing", secrets.GenerateOptions{Type: secrets.TypeEd25519}, true)
+		assert.NoError(t, err)
+		assert.Len(t, vaultClient.Vaults[0].Secrets, 2)
+		assert.NotEqual(t, "b2xk", vaultClient.Vaults[0].Secrets[0].Base64Content)
+	})
+}
+
+func Test_hideRotationSecrets(t *testing.T) {
+	vaults := []client.Vault{
+		{Name: "foo", Secrets: []client.Secret{{Name: "latest.properties"}, {Name: client.RotationSecretName}}},
+		{Name: "bar", Secrets: []client.Secret{{Name: client.RotationSecretName}}},
+	}
+
+	hideRotationSecrets(vaults)
+	assert.Equal(t, []client.Secret{{Name: "latest.properties"}}, vaults[0].Secrets)
+	assert.Empty(t, vaults[1].Secrets)
+}
+
+func Test_rotationSecretIsMaintainedByAO(t *testing.T) {
+	arg := "foo/" + client.RotationSecretName
+	assert.Equal(t, errRotationSecret, EditSecret(vaultEditCmd, []string{arg}))
+	assert.Equal(t, errRotationSecret, DeleteSecret(vaultDeleteSecretCmd, []string{arg}))
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/skatteetaten/ao/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var whoamiCmd = &cobra.Command{
+	Use:   "whoami",
+	Short: "Shows who you are logged in as, and when the token of each cluster expires",
+	RunE:  Whoami,
+}
+
+func init() {
+	RootCmd.AddCommand(whoamiCmd)
+}
+
+// Whoami is the entry point of the `whoami` cli command
+func Whoami(cmd *cobra.Command, args []string) error {
+	AO.UpdateTokenInfo()
+
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Affiliation: %s\n", AO.Affiliation)
+	fmt.Fprintf(out, "Ref:         %s\n", AO.RefName)
+	if context := AO.ActiveContext(); context != "" {
+		fmt.Fprintf(out, "Context:     %s\n", context)
+	}
+	fmt.Fprintln(out)
+
+	printTokenInfo(AO, out)
+	return nil
+}
+
+func printTokenInfo(aoConfig *config.AOConfig, out io.Writer) {
+	var rows []string
+	for _, name := range aoConfig.AvailableClusters {
+		cluster := aoConfig.Clusters[name]
+		if cluster == nil || !cluster.Reachable {
+			continue
+		}
+
+		user := cluster.Username
+		if user == "" {
+			user = "-"
+		}
+		rows = append(rows, fmt.Sprintf("\t%s\t%s\t%s", name, user, getTokenExpiry(cluster)))
+	}
+	DefaultTablePrinter("\tCLUSTER NAME\tUSER\tTOKEN EXPIRES IN", rows, out)
+}
+
+func getTokenExpiry(cluster *config.Cluster) string {
+	if cluster.Username == "" {
+		return "not logged in"
+	}
+	left, known := cluster.TokenExpiresIn()
+	if !known {
+		return "unknown"
+	}
+	if left <= 0 {
+		return "expired"
+	}
+	return left.Truncate(time.Minute).String()
+}
+
+// warnExpiringTokens warns about clusters in a deploy with tokens that expire soon, so that long deploys are not interrupted
+func warnExpiringTokens(partitions []DeploySpecPartition, out io.Writer) {
+	warned := make(map[string]bool)
+	for _, partition := range partitions {
+		cluster := partition.Cluster
+		if partition.OverrideToken != "" || warned[cluster.Name] || !cluster.TokenExpiresWithin(config.TokenExpiryWarning) {
+			continue
+		}
+		warned[cluster.Name] = true
+
+		if left, _ := cluster.TokenExpiresIn(); left > 0 {
+			fmt.Fprintf(out, "Warning: the token for %s expires in %s. Consider running \"ao login %s\" first.\n", cluster.Name, left.Truncate(time.Second), AO.Affiliation)
+		} else {
+			fmt.Fprintf(out, "Warning: the token for %s has expired. Run \"ao login %s\" first.\n", cluster.Name, AO.Affiliation)
+		}
+	}
+}
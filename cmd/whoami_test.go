@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/skatteetaten/ao/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_warnExpiringTokens(t *testing.T) {
+	AO = &config.AOConfig{Affiliation: "paas"}
+	soon := time.Now().Add(10 * time.Minute)
+	later := time.Now().Add(10 * time.Hour)
+
+	east := newTestCluster("east", true)
+	east.TokenExpiry = &soon
+	west := newTestCluster("west", true)
+	west.TokenExpiry = &later
+	north := newTestCluster("north", true)
+
+	partitions := []DeploySpecPartition{
+		*newDeploySpecPartition(testSpecs[0:3], *east, "paas", ""),
+		*newDeploySpecPartition(testSpecs[3:7], *east, "paas", ""),
+		*newDeploySpecPartition(testSpecs[7:11], *west, "paas", ""),
+		*newDeploySpecPartition(testSpecs[11:13], *north, "paas", ""),
+	}
+
+	out := &bytes.Buffer{}
+	warnExpiringTokens(partitions, out)
+
+	assert.Contains(t, out.String(), "the token for east expires in")
+	assert.Equal(t, 1, bytes.Count(out.Bytes(), []byte("Warning")))
+}
+
+func Test_getTokenExpiry(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	cluster := newTestCluster("east", true)
+	assert.Equal(t, "not logged in", getTokenExpiry(cluster))
+
+	cluster.Username = "k12345"
+	assert.Equal(t, "unknown", getTokenExpiry(cluster))
+
+	cluster.TokenExpiry = &expired
+	assert.Equal(t, "expired", getTokenExpiry(cluster))
+}
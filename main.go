@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"runtime"
@@ -56,7 +57,16 @@ func main() {
 	}
 	cmd.RootCmd.SetHelpTemplate(helpTemplate)
 
-	if err := cmd.RootCmd.Execute(); err != nil {
+	err := cmd.RootCmd.Execute()
+	cmd.UnlockConfig()
+	if err != nil {
+		var exitErr *cmd.ExitError
+		if errors.As(err, &exitErr) {
+			if exitErr.Err != nil {
+				fmt.Println(exitErr.Err)
+			}
+			os.Exit(exitErr.Code)
+		}
 		fmt.Println(err)
 		os.Exit(-1)
 	}
@@ -2,8 +2,11 @@ package auroraconfig
 
 import (
 	"encoding/json"
+	"fmt"
 	"regexp"
 	"strings"
+
+	"gopkg.in/yaml.v2"
 )
 
 type (
@@ -57,6 +60,49 @@ func (f *File) IsYaml() bool {
 	return (strings.HasSuffix(strings.ToLower(f.Name), ".yaml") || (strings.HasSuffix(strings.ToLower(f.Name), ".yml")))
 }
 
+// Content returns the parsed content of a JSON or YAML file
+func (f *File) Content() (map[string]interface{}, error) {
+	content := make(map[string]interface{})
+	if f.IsYaml() {
+		var yamlContent map[interface{}]interface{}
+		if err := yaml.Unmarshal([]byte(f.Contents), &yamlContent); err != nil {
+			return nil, err
+		}
+		for key, value := range yamlContent {
+			content[fmt.Sprint(key)] = normalizeYamlValue(value)
+		}
+		return content, nil
+	}
+
+	if err := json.Unmarshal([]byte(f.Contents), &content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// NameWithoutExtension returns the name of the file stripped for extension
+func (f *File) NameWithoutExtension() string {
+	return FileNames{f.Name}.WithoutExtension()[0]
+}
+
+func normalizeYamlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		normalized := make(map[string]interface{})
+		for key, value := range v {
+			normalized[fmt.Sprint(key)] = normalizeYamlValue(value)
+		}
+		return normalized
+	case []interface{}:
+		for i, value := range v {
+			v[i] = normalizeYamlValue(value)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
 func filterExcludes(expressions, applications []string) ([]string, error) {
 	apps := make([]string, len(applications))
 	copy(apps, applications)
@@ -0,0 +1,102 @@
+package auroraconfig
+
+import (
+	"sort"
+	"strings"
+)
+
+// templatesFolder holds the template files of localTemplate applications, referred to by templateFile
+const templatesFolder = "templates/"
+
+// ApplicationDeploymentRefsAffectedBy finds the ApplicationDeploymentRefs that depend on one of the changed files,
+// through about, env/about, envFile, the base file, baseFile or templateFile. Changed files may have been deleted from the AuroraConfig.
+func (ac *AuroraConfig) ApplicationDeploymentRefsAffectedBy(changed []string) []string {
+	changedNames := make(map[string]bool)
+	for _, name := range FileNames(changed).WithoutExtension() {
+		changedNames[name] = true
+	}
+
+	var affected []string
+	for ref, dependencies := range ac.dependencies() {
+		for _, name := range dependencies {
+			if changedNames[name] {
+				affected = append(affected, ref)
+				break
+			}
+		}
+	}
+	sort.Strings(affected)
+	return affected
+}
+
+// Subset returns an AuroraConfig with only the files the given ApplicationDeploymentRefs depend on
+func (ac *AuroraConfig) Subset(refs []string) *AuroraConfig {
+	dependencies := ac.dependencies()
+	included := make(map[string]bool)
+	for _, ref := range refs {
+		for _, name := range dependencies[ref] {
+			included[name] = true
+		}
+	}
+
+	subset := &AuroraConfig{Name: ac.Name}
+	for _, file := range ac.Files {
+		if included[file.NameWithoutExtension()] {
+			subset.Files = append(subset.Files, file)
+		}
+	}
+	return subset
+}
+
+// dependencies returns the names without extension of the files each ApplicationDeploymentRef depends on.
+// Files that can not be parsed are assumed to use the default about and base files. The template files are
+// not ApplicationDeploymentRefs themselves.
+func (ac *AuroraConfig) dependencies() map[string][]string {
+	files := make(map[string]*File)
+	var fileNames FileNames
+	for i := range ac.Files {
+		file := &ac.Files[i]
+		files[file.NameWithoutExtension()] = file
+		fileNames = append(fileNames, file.Name)
+	}
+
+	dependencies := make(map[string][]string)
+	for _, ref := range fileNames.GetApplicationDeploymentRefs() {
+		if strings.HasPrefix(ref, templatesFolder) {
+			continue
+		}
+		content, err := files[ref].Content()
+		if err != nil {
+			content = nil
+		}
+		names := deploymentFileNames(ref, content)
+		if templateFile := templateFileName(files, names); templateFile != "" {
+			names = append(names, templateFile)
+		}
+		dependencies[ref] = names
+	}
+	return dependencies
+}
+
+// templateFileName returns the name without extension of the template file given by the last of the files that
+// sets templateFile, or an empty string if none do
+func templateFileName(files map[string]*File, names []string) string {
+	var templateFile string
+	for _, name := range names {
+		file, found := files[name]
+		if !found {
+			continue
+		}
+		content, err := file.Content()
+		if err != nil {
+			continue
+		}
+		if value, ok := content["templateFile"].(string); ok && value != "" {
+			templateFile = value
+		}
+	}
+	if templateFile == "" {
+		return ""
+	}
+	return templatesFolder + FileNames{templateFile}.WithoutExtension()[0]
+}
@@ -0,0 +1,57 @@
+package auroraconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ApplicationDeploymentRefsAffectedBy(t *testing.T) {
+	ac := AuroraConfig{
+		Name: "paas",
+		Files: []File{
+			{Name: "about.json", Contents: `{"affiliation": "paas"}`},
+			{Name: "utv/about.json", Contents: `{"cluster": "utv"}`},
+			{Name: "utv/about-alt.json", Contents: `{"cluster": "utv02"}`},
+			{Name: "test/about.json", Contents: `{"cluster": "test"}`},
+			{Name: "foo.json", Contents: `{"version": "1"}`},
+			{Name: "bar.yaml", Contents: "version: 2\n"},
+			{Name: "utv/foo.json", Contents: `{}`},
+			{Name: "utv/bar.json", Contents: `{"envFile": "about-alt.json"}`},
+			{Name: "test/foo.json", Contents: `{}`},
+			{Name: "test/baz.json", Contents: `{"baseFile": "bar.yaml"}`},
+			{Name: "test/broken.json", Contents: `{`},
+			{Name: "templates/atomhopper.json", Contents: `{"kind": "Template"}`},
+			{Name: "atomhopper.json", Contents: `{"type": "localTemplate", "templateFile": "atomhopper.json"}`},
+			{Name: "test/atomhopper.json", Contents: `{}`},
+		},
+	}
+
+	tests := []struct {
+		changed []string
+		want    []string
+	}{
+		{[]string{"about.json"}, []string{"test/atomhopper", "test/baz", "test/broken", "test/foo", "utv/bar", "utv/foo"}},
+		{[]string{"utv/about.json"}, []string{"utv/foo"}},
+		{[]string{"utv/about-alt.json"}, []string{"utv/bar"}},
+		{[]string{"bar.yaml"}, []string{"test/baz", "utv/bar"}},
+		{[]string{"foo.json", "test/broken.json"}, []string{"test/broken", "test/foo", "utv/foo"}},
+		{[]string{"templates/atomhopper.json"}, []string{"test/atomhopper"}},
+		{[]string{"deleted.json"}, nil},
+		{nil, nil},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ac.ApplicationDeploymentRefsAffectedBy(tt.changed), "changed %v", tt.changed)
+	}
+
+	t.Run("Should only include the files the refs depend on in a subset", func(t *testing.T) {
+		subset := ac.Subset([]string{"utv/bar", "test/baz", "test/atomhopper"})
+		var names []string
+		for _, file := range subset.Files {
+			names = append(names, file.Name)
+		}
+		assert.Equal(t, "paas", subset.Name)
+		assert.Equal(t, []string{"about.json", "utv/about-alt.json", "test/about.json", "bar.yaml", "utv/bar.json", "test/baz.json",
+			"templates/atomhopper.json", "atomhopper.json", "test/atomhopper.json"}, names)
+	})
+}
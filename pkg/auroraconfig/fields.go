@@ -0,0 +1,193 @@
+package auroraconfig
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// FileKind tells which AuroraConfig files a field can be set in
+type FileKind int
+
+// Kinds of AuroraConfig files
+const (
+	// AboutFile is the about file in the root of the AuroraConfig, with defaults for the affiliation
+	AboutFile FileKind = 1 << iota
+	// EnvFile is the about file of an environment folder, or another file referenced by envFile
+	EnvFile
+	// ApplicationFile is a base file in the root of the AuroraConfig, or an application file in an environment folder
+	ApplicationFile
+
+	// AllFiles are all kinds of AuroraConfig files
+	AllFiles = AboutFile | EnvFile | ApplicationFile
+)
+
+// FileKinds are the names of the kinds of AuroraConfig files
+var FileKinds = map[string]FileKind{
+	"about":       AboutFile,
+	"env":         EnvFile,
+	"application": ApplicationFile,
+}
+
+// FileKindOf returns the kind of an AuroraConfig file, given its name
+func FileKindOf(name string) FileKind {
+	base := FileNames{filepath.Base(name)}.WithoutExtension()[0]
+	switch {
+	case !strings.ContainsRune(name, '/') && base == "about":
+		return AboutFile
+	case strings.Contains(base, "about"):
+		return EnvFile
+	}
+	return ApplicationFile
+}
+
+// Field is a field of the AuroraConfig files
+type Field struct {
+	Name string
+	// Types are the JSON types the value can have
+	Types       []string
+	Description string
+	Enum        []string
+	Files       FileKind
+	// Fields are the known fields of an object value
+	Fields []Field
+}
+
+// flag is a feature that is enabled with true, or configured with an object
+func flag(name, description string, fields ...Field) Field {
+	return Field{Name: name, Types: []string{"boolean", "object"}, Description: description, Files: AllFiles, Fields: fields}
+}
+
+func field(name, jsonType, description string, fields ...Field) Field {
+	return Field{Name: name, Types: []string{jsonType}, Description: description, Files: AllFiles, Fields: fields}
+}
+
+func enum(name, description string, values ...string) Field {
+	return Field{Name: name, Types: []string{"string"}, Description: description, Enum: values, Files: AllFiles}
+}
+
+func only(files FileKind, f Field) Field {
+	f.Files = files
+	return f
+}
+
+var probeFields = []Field{
+	field("port", "integer", "Port to probe"),
+	field("path", "string", "HTTP path to probe, a TCP probe is used if not set"),
+	field("delay", "integer", "Seconds before the first probe"),
+	field("timeout", "integer", "Seconds before the probe times out"),
+}
+
+var minMaxFields = []Field{
+	field("min", "string", "Requested amount"),
+	field("max", "string", "Limit"),
+}
+
+// Fields are the fields of the AuroraConfig files that ao knows
+var Fields = []Field{
+	field("schemaVersion", "string", "Version of the AuroraConfig schema, v1"),
+	only(AboutFile|EnvFile, field("affiliation", "string", "Affiliation of the applications, used as prefix of the OpenShift projects")),
+	only(AboutFile|EnvFile, field("segment", "string", "Segment of the affiliation")),
+	field("cluster", "string", "OpenShift cluster to deploy to"),
+	field("permissions", "object", "OpenShift groups with access to the project",
+		Field{Name: "admin", Types: []string{"string", "array"}, Description: "Groups with admin access"},
+		Field{Name: "view", Types: []string{"string", "array"}, Description: "Groups with view access"},
+		Field{Name: "adminServiceAccount", Types: []string{"string", "array"}, Description: "Service accounts with admin access"},
+	),
+	field("envName", "string", "Name of the environment, defaults to the folder name"),
+	field("env", "object", "Settings of the environment",
+		field("ttl", "string", "Time to live for the environment, e.g. 7d"),
+		field("autoDeploy", "boolean", "Deploy the environment when the AuroraConfig changes"),
+	),
+	only(ApplicationFile, field("baseFile", "string", "Base file of the application, defaults to the file with the same name in the root")),
+	only(ApplicationFile, field("envFile", "string", "Environment file to use instead of about in the environment folder")),
+	enum("type", "Type of application", "deploy", "development", "template", "localTemplate", "cronjob", "job"),
+	enum("applicationPlatform", "Platform of the application", "java", "web", "python", "doozer"),
+	field("name", "string", "Name of the application, defaults to the file name"),
+	field("description", "string", "Description of the application"),
+	field("groupId", "string", "Group id of the artifact"),
+	field("artifactId", "string", "Artifact id of the artifact, defaults to the file name"),
+	field("version", "string", "Version of the artifact to deploy"),
+	field("releaseTo", "string", "Tag to release the version to, for promotion between environments"),
+	field("replicas", "integer", "Number of replicas"),
+	field("pause", "boolean", "Scale the application down to 0 replicas"),
+	field("debug", "boolean", "Enable remote debugging"),
+	field("alarm", "boolean", "Enable alarms"),
+	field("message", "string", "Message shown for the application"),
+	field("splunkIndex", "string", "Splunk index for the logs of the application"),
+	field("serviceAccount", "string", "Service account to run the application as"),
+	field("nodeSelector", "object", "Node labels the application must be scheduled on"),
+	field("deployStrategy", "object", "How new versions are rolled out",
+		enum("type", "Deploy strategy", "rolling", "recreate"),
+		field("timeout", "integer", "Seconds before a deploy times out"),
+	),
+	field("resources", "object", "CPU and memory",
+		field("cpu", "object", "CPU in cores or millicores", minMaxFields...),
+		field("memory", "object", "Memory, e.g. 512Mi", minMaxFields...),
+	),
+	field("config", "object", "Environment variables of the application"),
+	field("mounts", "object", "Volumes mounted in the application"),
+	Field{Name: "secretVault", Types: []string{"string", "object"}, Description: "Vault mounted as environment variables", Files: AllFiles},
+	field("secretVaults", "object", "Vaults mounted as environment variables, by name"),
+	flag("certificate", "Create a certificate for the application"),
+	flag("database", "Create or use databases"),
+	field("databaseDefaults", "object", "Defaults for the databases"),
+	flag("route", "Expose the application with routes"),
+	field("routeDefaults", "object", "Defaults for the routes"),
+	flag("prometheus", "Collect metrics with Prometheus",
+		field("path", "string", "Path of the metrics"),
+		field("port", "integer", "Port of the metrics"),
+	),
+	flag("management", "Management interface of the application",
+		field("path", "string", "Path of the management interface"),
+		field("port", "integer", "Port of the management interface"),
+	),
+	flag("readiness", "Readiness probe", probeFields...),
+	flag("liveness", "Liveness probe", probeFields...),
+	flag("webseal", "Expose the application through WebSEAL",
+		field("host", "string", "WebSEAL host name"),
+		field("roles", "string", "Roles with access"),
+	),
+	flag("sts", "Create an STS certificate"),
+	flag("toxiproxy", "Run a Toxiproxy sidecar", field("version", "string", "Toxiproxy version")),
+	field("bigip", "object", "BigIP configuration"),
+	field("s3", "object", "S3 buckets"),
+	field("s3Defaults", "object", "Defaults for the S3 buckets"),
+	field("topology", "object", "Grouping of the application in the topology view"),
+	field("notification", "object", "Notifications on deploy"),
+	field("template", "string", "Name of the OpenShift template of a template application"),
+	field("templateFile", "string", "Template file of a localTemplate application"),
+	field("parameters", "object", "Parameters of the template"),
+	field("schedule", "string", "Cron schedule of a cronjob"),
+	enum("concurrentPolicy", "What to do with a cronjob that runs when the next run is due", "Allow", "Forbid", "Replace"),
+	field("failureLimit", "integer", "Number of failed jobs to keep"),
+	field("successLimit", "integer", "Number of successful jobs to keep"),
+	field("suspend", "boolean", "Suspend a cronjob"),
+}
+
+// FindField finds a field by name
+func FindField(fields []Field, name string) *Field {
+	for i := range fields {
+		if fields[i].Name == name {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// FieldHelp documents the fields that can be set in an AuroraConfig file, one line per field
+func FieldHelp(fileName string) []string {
+	kind := FileKindOf(fileName)
+	help := []string{"Fields of this file:"}
+	for _, f := range Fields {
+		if f.Files&kind == 0 {
+			continue
+		}
+		description := f.Description
+		if len(f.Enum) > 0 {
+			description += " [" + strings.Join(f.Enum, ", ") + "]"
+		}
+		help = append(help, fmt.Sprintf("  %-20s %-16s %s", f.Name, strings.Join(f.Types, "|"), description))
+	}
+	return help
+}
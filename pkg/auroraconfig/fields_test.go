@@ -0,0 +1,28 @@
+package auroraconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_FileKindOf(t *testing.T) {
+	assert.Equal(t, AboutFile, FileKindOf("about.json"))
+	assert.Equal(t, EnvFile, FileKindOf("utv/about.yaml"))
+	assert.Equal(t, EnvFile, FileKindOf("utv/about-template.json"))
+	assert.Equal(t, ApplicationFile, FileKindOf("foo.json"))
+	assert.Equal(t, ApplicationFile, FileKindOf("utv/foo.json"))
+}
+
+func Test_FieldHelp(t *testing.T) {
+	help := FieldHelp("utv/foo.json")
+	assert.Equal(t, "Fields of this file:", help[0])
+	assert.Contains(t, help, "  baseFile             string           Base file of the application, defaults to the file with the same name in the root")
+	assert.Contains(t, help, "  deployStrategy       object           How new versions are rolled out")
+	assert.Contains(t, help, "  certificate          boolean|object   Create a certificate for the application")
+	assert.Contains(t, help, "  type                 string           Type of application [deploy, development, template, localTemplate, cronjob, job]")
+
+	for _, line := range FieldHelp("about.json") {
+		assert.NotContains(t, line, "baseFile")
+	}
+}
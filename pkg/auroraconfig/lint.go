@@ -0,0 +1,546 @@
+package auroraconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	yamlnode "gopkg.in/yaml.v3"
+)
+
+// LintConfigFileName is the name of the lint config file, read from the root of the AuroraConfig repo
+const LintConfigFileName = ".aolint.yaml"
+
+// Severity of a lint finding
+type Severity string
+
+// Severities of lint rules. Rules with severity off are not checked.
+const (
+	SeverityOff     Severity = "off"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Lint rules
+const (
+	RuleInvalidSyntax    = "invalid-syntax"
+	RuleDuplicateKey     = "duplicate-key"
+	RuleUnknownKey       = "unknown-key"
+	RuleMissingBaseFile  = "missing-base-file"
+	RuleMissingReference = "missing-reference"
+	RuleMixedFormats     = "mixed-formats"
+	RuleLatestInProd     = "latest-in-prod"
+)
+
+// LintRule is a check of the files in an AuroraConfig
+type LintRule struct {
+	ID              string
+	Description     string
+	DefaultSeverity Severity
+}
+
+// LintRules are all rules checked by Lint
+var LintRules = []LintRule{
+	{RuleInvalidSyntax, "The file is not a valid JSON or YAML object", SeverityError},
+	{RuleDuplicateKey, "A key is defined more than once in the same object", SeverityError},
+	{RuleUnknownKey, "A top-level key is not a known AuroraConfig field", SeverityWarning},
+	{RuleMissingBaseFile, "An application file in an environment has no base file", SeverityError},
+	{RuleMissingReference, "baseFile or envFile refers to a file that does not exist", SeverityError},
+	{RuleMixedFormats, "The files of an application are a mix of JSON and YAML", SeverityWarning},
+	{RuleLatestInProd, "An application in a production environment is deployed with version latest", SeverityWarning},
+}
+
+// LintConfig configures the lint rules
+type LintConfig struct {
+	// Rules overrides the severity of rules, by rule id
+	Rules map[string]Severity `yaml:"rules"`
+	// KnownKeys are additional top-level keys that are not reported as unknown
+	KnownKeys []string `yaml:"knownKeys"`
+	// ProdEnvironments are regular expressions matching the names of production environments
+	ProdEnvironments []string `yaml:"prodEnvironments"`
+	// Exclude are regular expressions matching files that are not linted
+	Exclude []string `yaml:"exclude"`
+}
+
+// LintFinding is a problem found by a lint rule
+type LintFinding struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	File     string   `json:"file"`
+	// Line is 0 when the line is not known
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// DefaultLintConfig returns the lint config used when the repo has no .aolint.yaml
+func DefaultLintConfig() *LintConfig {
+	return &LintConfig{
+		ProdEnvironments: []string{"^prod$"},
+	}
+}
+
+// LoadLintConfig loads a .aolint.yaml file. Settings not given in the file have default values.
+func LoadLintConfig(file string) (*LintConfig, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	lintConfig := DefaultLintConfig()
+	if err := yaml.UnmarshalStrict(data, lintConfig); err != nil {
+		return nil, errors.Wrapf(err, "could not parse %s", file)
+	}
+	if err := lintConfig.validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid %s", file)
+	}
+	return lintConfig, nil
+}
+
+func (c *LintConfig) validate() error {
+	for id, severity := range c.Rules {
+		if findLintRule(id) == nil {
+			return errors.Errorf("unknown rule %s", id)
+		}
+		switch severity {
+		case SeverityOff, SeverityWarning, SeverityError:
+		default:
+			return errors.Errorf("rule %s has severity %s, must be one of [off, warning, error]", id, severity)
+		}
+	}
+	for _, expr := range append(append([]string{}, c.ProdEnvironments...), c.Exclude...) {
+		if _, err := regexp.Compile(expr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Severity returns the severity of a rule
+func (c *LintConfig) Severity(rule string) Severity {
+	if severity, found := c.Rules[rule]; found {
+		return severity
+	}
+	if lintRule := findLintRule(rule); lintRule != nil {
+		return lintRule.DefaultSeverity
+	}
+	return SeverityOff
+}
+
+func findLintRule(id string) *LintRule {
+	for i := range LintRules {
+		if LintRules[i].ID == id {
+			return &LintRules[i]
+		}
+	}
+	return nil
+}
+
+// linter collects the findings of the lint rules
+type linter struct {
+	config   *LintConfig
+	files    map[string]*File
+	contents map[string]map[string]interface{}
+	keys     map[string]*fileKeys
+	findings []LintFinding
+}
+
+func (l *linter) report(rule string, file *File, line int, format string, args ...interface{}) {
+	severity := l.config.Severity(rule)
+	if severity == SeverityOff {
+		return
+	}
+	l.findings = append(l.findings, LintFinding{
+		Rule:     rule,
+		Severity: severity,
+		File:     file.Name,
+		Line:     line,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Lint checks the files of the AuroraConfig without calling Boober. The findings are sorted by file and line.
+func (ac *AuroraConfig) Lint(lintConfig *LintConfig) ([]LintFinding, error) {
+	if err := lintConfig.validate(); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for i := range ac.Files {
+		names = append(names, ac.Files[i].Name)
+	}
+	included, err := filterExcludes(lintConfig.Exclude, names)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &linter{
+		config:   lintConfig,
+		files:    make(map[string]*File),
+		contents: make(map[string]map[string]interface{}),
+		keys:     make(map[string]*fileKeys),
+	}
+	for i := range ac.Files {
+		file := &ac.Files[i]
+		// Hidden files, like .ao.yaml and .aolint.yaml, are not part of the AuroraConfig
+		if isHidden(file.Name) || !contains(included, file.Name) {
+			continue
+		}
+		if other, found := l.files[file.NameWithoutExtension()]; found {
+			l.report(RuleMixedFormats, file, 0, "%s and %s are the same file in different formats", other.Name, file.Name)
+		}
+		l.files[file.NameWithoutExtension()] = file
+		l.lintFile(file)
+	}
+
+	l.lintReferences()
+	l.lintMixedFormats()
+	if err := l.lintLatestInProd(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		a, b := l.findings[i], l.findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Message < b.Message
+	})
+	return l.findings, nil
+}
+
+// lintFile checks the syntax, duplicate keys and unknown keys of a file
+func (l *linter) lintFile(file *File) {
+	var definitions *fileKeys
+	var err error
+	if file.IsYaml() {
+		definitions, err = yamlKeys(file.Contents)
+	} else {
+		definitions, err = jsonKeys(file.Contents)
+	}
+	if err != nil {
+		l.report(RuleInvalidSyntax, file, syntaxErrorLine(file, err), "%s", err)
+		return
+	}
+	content, err := file.Content()
+	if err != nil {
+		l.report(RuleInvalidSyntax, file, syntaxErrorLine(file, err), "%s", err)
+		return
+	}
+	l.contents[file.NameWithoutExtension()] = content
+	l.keys[file.NameWithoutExtension()] = definitions
+
+	for _, duplicate := range definitions.duplicates {
+		l.report(RuleDuplicateKey, file, duplicate.line, "duplicate key %s", duplicate.path)
+	}
+
+	var keys []string
+	for key := range content {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if FindField(Fields, key) == nil && !contains(l.config.KnownKeys, key) {
+			l.report(RuleUnknownKey, file, l.keyLine(file, key), "unknown key %s", key)
+		}
+	}
+}
+
+// lintReferences checks that each application file in an environment has a base file, and that baseFile and envFile exist
+func (l *linter) lintReferences() {
+	for _, ref := range l.refs() {
+		file := l.files[ref]
+		content, parsed := l.contents[ref]
+		if !parsed {
+			continue
+		}
+		env := strings.Split(ref, "/")[0]
+
+		baseFile, hasBaseFile := content["baseFile"].(string)
+		if hasBaseFile && baseFile != "" {
+			if _, found := l.files[FileNames{baseFile}.WithoutExtension()[0]]; !found {
+				l.report(RuleMissingReference, file, l.keyLine(file, "baseFile"), "baseFile %s does not exist", baseFile)
+			}
+		} else if _, found := l.files[strings.Split(ref, "/")[1]]; !found {
+			l.report(RuleMissingBaseFile, file, 0, "%s has no base file %s and no baseFile", file.Name, strings.Split(ref, "/")[1])
+		}
+
+		if envFile, ok := content["envFile"].(string); ok && envFile != "" {
+			if _, found := l.files[env+"/"+FileNames{envFile}.WithoutExtension()[0]]; !found {
+				l.report(RuleMissingReference, file, l.keyLine(file, "envFile"), "envFile %s does not exist in %s", envFile, env)
+			}
+		}
+	}
+}
+
+// lintMixedFormats reports the files of an application that are not in the format of its base file
+func (l *linter) lintMixedFormats() {
+	applications := make(map[string][]*File)
+	for name, file := range l.files {
+		app := filepath.Base(name)
+		if app == "about" {
+			continue
+		}
+		applications[app] = append(applications[app], file)
+	}
+
+	for _, files := range applications {
+		sort.Slice(files, func(i, j int) bool {
+			// The base file first, then the environments
+			iBase, jBase := !strings.ContainsRune(files[i].Name, '/'), !strings.ContainsRune(files[j].Name, '/')
+			if iBase != jBase {
+				return iBase
+			}
+			return files[i].Name < files[j].Name
+		})
+
+		first := files[0]
+		for _, file := range files[1:] {
+			if file.IsYaml() != first.IsYaml() {
+				l.report(RuleMixedFormats, file, 0, "%s is %s, but %s is %s", file.Name, formatName(file), first.Name, formatName(first))
+			}
+		}
+	}
+}
+
+// lintLatestInProd reports ApplicationDeploymentRefs in production environments that get version latest
+func (l *linter) lintLatestInProd() error {
+	prodEnvironments, err := compileAll(l.config.ProdEnvironments)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range l.refs() {
+		env := strings.Split(ref, "/")[0]
+		if !matchesAny(prodEnvironments, env) {
+			continue
+		}
+		content, parsed := l.contents[ref]
+		if !parsed {
+			continue
+		}
+
+		var version interface{}
+		var source *File
+		for _, name := range deploymentFileNames(ref, content) {
+			if value, found := l.contents[name]["version"]; found {
+				version, source = value, l.files[name]
+			}
+		}
+		if source != nil && strings.EqualFold(fmt.Sprint(version), "latest") {
+			l.report(RuleLatestInProd, source, l.keyLine(source, "version"), "%s is deployed to %s with version %v", ref, env, version)
+		}
+	}
+	return nil
+}
+
+// refs returns the ApplicationDeploymentRefs of the linted files
+func (l *linter) refs() []string {
+	var names FileNames
+	for name := range l.files {
+		names = append(names, name)
+	}
+	return names.GetApplicationDeploymentRefs()
+}
+
+// fileKeys holds the line where each key in a file is first defined, by path like config/FOO, and the keys that
+// are defined more than once in the same object
+type fileKeys struct {
+	lines      map[string]int
+	duplicates []duplicateKey
+}
+
+// duplicateKey is a key defined again in an object, at line
+type duplicateKey struct {
+	path string
+	line int
+}
+
+func newFileKeys() *fileKeys {
+	return &fileKeys{lines: make(map[string]int)}
+}
+
+// add records that the key at path is defined at line. Arrays are part of the path, so a path is only seen twice
+// when a key is defined twice in the same object.
+func (k *fileKeys) add(path string, line int) {
+	if _, found := k.lines[path]; found {
+		k.duplicates = append(k.duplicates, duplicateKey{path: path, line: line})
+		return
+	}
+	k.lines[path] = line
+}
+
+// jsonKeys returns the lines of the keys in a JSON object, or an error if it is not valid JSON
+func jsonKeys(contents string) (*fileKeys, error) {
+	decoder := json.NewDecoder(strings.NewReader(contents))
+	decoder.UseNumber()
+	keys := newFileKeys()
+	if err := jsonWalk(decoder, contents, "", keys); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("invalid character after top-level value")
+	}
+	return keys, nil
+}
+
+func jsonWalk(decoder *json.Decoder, contents, path string, keys *fileKeys) error {
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return err
+			}
+			key := keyToken.(string)
+			// The offset is just after the key, which is on the line where it is defined
+			keys.add(path+key, offsetLine(contents, decoder.InputOffset()))
+
+			if err := jsonWalk(decoder, contents, path+key+"/", keys); err != nil {
+				return err
+			}
+		}
+		_, err = decoder.Token()
+	case json.Delim('['):
+		for i := 0; decoder.More(); i++ {
+			if err := jsonWalk(decoder, contents, path+strconv.Itoa(i)+"/", keys); err != nil {
+				return err
+			}
+		}
+		_, err = decoder.Token()
+	}
+	return err
+}
+
+// yamlKeys returns the lines of the keys in a YAML mapping, or an error if it is not valid YAML
+func yamlKeys(contents string) (*fileKeys, error) {
+	var document yamlnode.Node
+	if err := yamlnode.Unmarshal([]byte(contents), &document); err != nil {
+		return nil, err
+	}
+	keys := newFileKeys()
+	yamlWalk(&document, "", keys)
+	return keys, nil
+}
+
+// yamlWalk records the keys of node. Aliases are not followed, as their keys are defined at the anchor.
+func yamlWalk(node *yamlnode.Node, path string, keys *fileKeys) {
+	switch node.Kind {
+	case yamlnode.DocumentNode:
+		for _, content := range node.Content {
+			yamlWalk(content, path, keys)
+		}
+	case yamlnode.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			keys.add(path+key.Value, key.Line)
+			yamlWalk(node.Content[i+1], path+key.Value+"/", keys)
+		}
+	case yamlnode.SequenceNode:
+		for i, item := range node.Content {
+			yamlWalk(item, path+strconv.Itoa(i)+"/", keys)
+		}
+	}
+}
+
+var yamlErrorLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// syntaxErrorLine returns the line of a JSON or YAML syntax error, or 0 if it is not known
+func syntaxErrorLine(file *File, err error) int {
+	if syntaxError, ok := err.(*json.SyntaxError); ok {
+		return offsetLine(file.Contents, syntaxError.Offset)
+	}
+	if err == io.ErrUnexpectedEOF {
+		return offsetLine(file.Contents, int64(len(file.Contents)))
+	}
+	if typeError, ok := err.(*json.UnmarshalTypeError); ok {
+		return offsetLine(file.Contents, typeError.Offset)
+	}
+	if match := yamlErrorLinePattern.FindStringSubmatch(err.Error()); match != nil {
+		line, _ := strconv.Atoi(match[1])
+		return line
+	}
+	return 0
+}
+
+func offsetLine(contents string, offset int64) int {
+	if offset > int64(len(contents)) {
+		offset = int64(len(contents))
+	}
+	return bytes.Count([]byte(contents[:offset]), []byte("\n")) + 1
+}
+
+// keyLine returns the line where the top-level key is first defined in the file, or 0 if the file could not be
+// parsed or does not define the key
+func (l *linter) keyLine(file *File, key string) int {
+	keys, found := l.keys[file.NameWithoutExtension()]
+	if !found {
+		return 0
+	}
+	return keys.lines[key]
+}
+
+func formatName(file *File) string {
+	if file.IsYaml() {
+		return "YAML"
+	}
+	return "JSON"
+}
+
+func isHidden(name string) bool {
+	for _, part := range strings.Split(filepath.ToSlash(name), "/") {
+		if strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func compileAll(expressions []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, expr := range expressions {
+		r, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, r)
+	}
+	return compiled, nil
+}
+
+func matchesAny(expressions []*regexp.Regexp, value string) bool {
+	for _, r := range expressions {
+		if r.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
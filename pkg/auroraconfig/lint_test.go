@@ -0,0 +1,171 @@
+package auroraconfig
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Lint(t *testing.T) {
+	ac := AuroraConfig{
+		Name: "paas",
+		Files: []File{
+			{Name: "about.json", Contents: `{"affiliation": "paas"}`},
+			{Name: ".aolint.yaml", Contents: "rules: {}\n"},
+			{Name: "prod/about.json", Contents: `{"cluster": "prod"}`},
+			{Name: "utv/about.yaml", Contents: "cluster: utv\n"},
+			{Name: "foo.json", Contents: "{\n  \"version\": \"latest\",\n  \"groupId\": \"no.skatteetaten\"\n}"},
+			{Name: "prod/foo.json", Contents: `{}`},
+			{Name: "utv/foo.yaml", Contents: "replicas: 1\nfoobar: true\n"},
+			{Name: "prod/bar.json", Contents: "{\n  \"version\": \"1.0.0\",\n  \"version\": \"1.0.1\"\n}"},
+			{Name: "utv/baz.json", Contents: "{\n  \"baseFile\": \"missing.json\",\n  \"envFile\": \"about-missing.json\"\n}"},
+			{Name: "utv/broken.json", Contents: "{\n  \"version\": \n"},
+			{Name: "invalid.yaml", Contents: "a: [\n"},
+		},
+	}
+
+	findings, err := ac.Lint(DefaultLintConfig())
+	assert.NoError(t, err)
+	assert.Equal(t, []LintFinding{
+		{Rule: RuleLatestInProd, Severity: SeverityWarning, File: "foo.json", Line: 2, Message: "prod/foo is deployed to prod with version latest"},
+		{Rule: RuleInvalidSyntax, Severity: SeverityError, File: "invalid.yaml", Line: 1, Message: "yaml: line 1: did not find expected node content"},
+		{Rule: RuleMissingBaseFile, Severity: SeverityError, File: "prod/bar.json", Message: "prod/bar.json has no base file bar and no baseFile"},
+		{Rule: RuleDuplicateKey, Severity: SeverityError, File: "prod/bar.json", Line: 3, Message: "duplicate key version"},
+		{Rule: RuleMissingReference, Severity: SeverityError, File: "utv/baz.json", Line: 2, Message: "baseFile missing.json does not exist"},
+		{Rule: RuleMissingReference, Severity: SeverityError, File: "utv/baz.json", Line: 3, Message: "envFile about-missing.json does not exist in utv"},
+		{Rule: RuleInvalidSyntax, Severity: SeverityError, File: "utv/broken.json", Line: 3, Message: "unexpected EOF"},
+		{Rule: RuleMixedFormats, Severity: SeverityWarning, File: "utv/foo.yaml", Message: "utv/foo.yaml is YAML, but foo.json is JSON"},
+		{Rule: RuleUnknownKey, Severity: SeverityWarning, File: "utv/foo.yaml", Line: 2, Message: "unknown key foobar"},
+	}, findings)
+
+	t.Run("Should apply rule severities, known keys and excludes", func(t *testing.T) {
+		lintConfig := &LintConfig{
+			Rules: map[string]Severity{
+				RuleMissingBaseFile: SeverityOff,
+				RuleMixedFormats:    SeverityError,
+			},
+			KnownKeys:        []string{"foobar"},
+			ProdEnvironments: []string{"^utv$"},
+			Exclude:          []string{"broken", "invalid", "baz"},
+		}
+		findings, err := ac.Lint(lintConfig)
+		assert.NoError(t, err)
+
+		var rules []string
+		for _, finding := range findings {
+			rules = append(rules, finding.Rule+" "+string(finding.Severity)+" "+finding.File)
+		}
+		assert.Equal(t, []string{
+			"latest-in-prod warning foo.json",
+			"duplicate-key error prod/bar.json",
+			"mixed-formats error utv/foo.yaml",
+		}, rules)
+	})
+
+	t.Run("Should find duplicate keys in nested YAML", func(t *testing.T) {
+		keys, err := yamlKeys("a:\n  b: 1\n  b: 2\nc:\n- d: 1\n  d: 2\nb: 3\n")
+		assert.NoError(t, err)
+		assert.Equal(t, []duplicateKey{{path: "a/b", line: 3}, {path: "c/0/d", line: 6}}, keys.duplicates)
+		assert.Equal(t, 7, keys.lines["b"], "a nested key with the same name is not the top-level key")
+	})
+
+	t.Run("Should find the lines of duplicate keys in nested JSON", func(t *testing.T) {
+		keys, err := jsonKeys("{\n  \"b\": {\n    \"a\": 1,\n    \"a\": 2\n  },\n  \"a\": 3,\n  \"b\": 4\n}")
+		assert.NoError(t, err)
+		assert.Equal(t, []duplicateKey{{path: "b/a", line: 4}, {path: "b", line: 7}}, keys.duplicates)
+		assert.Equal(t, 6, keys.lines["a"])
+	})
+
+	t.Run("Should report files with the same name in different formats", func(t *testing.T) {
+		ac := AuroraConfig{
+			Files: []File{
+				{Name: "foo.json", Contents: `{}`},
+				{Name: "foo.yaml", Contents: "{}\n"},
+			},
+		}
+		findings, err := ac.Lint(DefaultLintConfig())
+		assert.NoError(t, err)
+		assert.Len(t, findings, 1)
+		assert.Equal(t, "foo.json and foo.yaml are the same file in different formats", findings[0].Message)
+	})
+
+	t.Run("Should match production environments and about files by their whole name", func(t *testing.T) {
+		ac := AuroraConfig{
+			Name: "paas",
+			Files: []File{
+				{Name: "about.json", Contents: `{"affiliation": "paas"}`},
+				{Name: "utv/about.yaml", Contents: "cluster: utv\n"},
+				{Name: "preprod/about.json", Contents: `{"cluster": "utv"}`},
+				{Name: "foo.json", Contents: `{"version": "latest"}`},
+				{Name: "preprod/foo.json", Contents: `{}`},
+				{Name: "about-api.json", Contents: `{}`},
+				{Name: "utv/about-api.yaml", Contents: "replicas: 1\n"},
+			},
+		}
+
+		findings, err := ac.Lint(DefaultLintConfig())
+		assert.NoError(t, err)
+		assert.Equal(t, []LintFinding{
+			{Rule: RuleMixedFormats, Severity: SeverityWarning, File: "utv/about-api.yaml", Message: "utv/about-api.yaml is YAML, but about-api.json is JSON"},
+		}, findings)
+	})
+}
+
+func Test_LoadLintConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aolint")
+	assert.NoError(t, err)
+	file := filepath.Join(dir, LintConfigFileName)
+
+	t.Run("Should keep defaults that are not configured", func(t *testing.T) {
+		assert.NoError(t, ioutil.WriteFile(file, []byte("rules:\n  unknown-key: error\n"), 0644))
+		lintConfig, err := LoadLintConfig(file)
+		assert.NoError(t, err)
+		assert.Equal(t, SeverityError, lintConfig.Severity(RuleUnknownKey))
+		assert.Equal(t, SeverityError, lintConfig.Severity(RuleDuplicateKey))
+		assert.Equal(t, []string{"^prod$"}, lintConfig.ProdEnvironments)
+	})
+
+	t.Run("Should fail on unknown rules and severities", func(t *testing.T) {
+		assert.NoError(t, ioutil.WriteFile(file, []byte("rules:\n  no-such-rule: error\n"), 0644))
+		_, err := LoadLintConfig(file)
+		assert.Error(t, err)
+
+		assert.NoError(t, ioutil.WriteFile(file, []byte("rules:\n  unknown-key: fatal\n"), 0644))
+		_, err = LoadLintConfig(file)
+		assert.Error(t, err)
+	})
+}
+
+func Test_NewSARIFReport(t *testing.T) {
+	findings := []LintFinding{
+		{Rule: RuleUnknownKey, Severity: SeverityWarning, File: "utv/foo.json", Line: 3, Message: "unknown key foobar"},
+		{Rule: RuleMissingBaseFile, Severity: SeverityError, File: "utv/bar.json", Message: "no base file"},
+	}
+
+	data, err := json.Marshal(NewSARIFReport(findings, DefaultLintConfig(), "1.2.3"))
+	assert.NoError(t, err)
+
+	var report map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &report))
+	assert.Equal(t, "2.1.0", report["version"])
+
+	run := report["runs"].([]interface{})[0].(map[string]interface{})
+	driver := run["tool"].(map[string]interface{})["driver"].(map[string]interface{})
+	assert.Equal(t, "1.2.3", driver["version"])
+	assert.Len(t, driver["rules"], len(LintRules))
+
+	results := run["results"].([]interface{})
+	assert.Len(t, results, 2)
+	first := results[0].(map[string]interface{})
+	assert.Equal(t, "warning", first["level"])
+	location := first["locations"].([]interface{})[0].(map[string]interface{})["physicalLocation"].(map[string]interface{})
+	assert.Equal(t, "utv/foo.json", location["artifactLocation"].(map[string]interface{})["uri"])
+	assert.Equal(t, float64(3), location["region"].(map[string]interface{})["startLine"])
+
+	second := results[1].(map[string]interface{})
+	assert.Equal(t, "error", second["level"])
+	assert.NotContains(t, second["locations"].([]interface{})[0].(map[string]interface{})["physicalLocation"], "region")
+}
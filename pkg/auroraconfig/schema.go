@@ -0,0 +1,183 @@
+package auroraconfig
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/skatteetaten/ao/pkg/deploymentspec"
+)
+
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// serverFieldDescription documents fields that are only known from the deploy specs of the server
+const serverFieldDescription = "Field of the deploy spec on the server"
+
+// JSONSchema creates a JSON Schema for a kind of AuroraConfig file, one of about, env and application
+func JSONSchema(kind string, fields []Field) (map[string]interface{}, error) {
+	fileKind, found := FileKinds[kind]
+	if !found {
+		return nil, errors.Errorf("unknown kind of file %s, must be one of [application, about, env]", kind)
+	}
+
+	var included []Field
+	for _, f := range fields {
+		if f.Files&fileKind != 0 {
+			included = append(included, f)
+		}
+	}
+
+	return map[string]interface{}{
+		"$schema":              jsonSchemaDraft,
+		"title":                "AuroraConfig " + kind + " file",
+		"type":                 "object",
+		"properties":           schemaProperties(included),
+		"additionalProperties": false,
+	}, nil
+}
+
+func schemaProperties(fields []Field) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for _, f := range fields {
+		property := map[string]interface{}{
+			"description": f.Description,
+		}
+		if len(f.Types) == 1 {
+			property["type"] = f.Types[0]
+		} else if len(f.Types) > 1 {
+			property["type"] = f.Types
+		}
+		if len(f.Enum) > 0 {
+			property["enum"] = f.Enum
+		}
+		if len(f.Fields) > 0 {
+			property["properties"] = schemaProperties(f.Fields)
+		}
+		properties[f.Name] = property
+	}
+	return properties
+}
+
+// MergeDeploySpecFields adds the fields found in deploy specs from the server to the fields ao knows.
+// Fields that are only set by the server itself, like applicationDeploymentRef, are left out.
+func MergeDeploySpecFields(fields []Field, specs []deploymentspec.DeploymentSpec) []Field {
+	merged := copyFields(fields)
+	for _, spec := range specs {
+		merged = mergeFields(merged, deploySpecFields(spec))
+	}
+	return merged
+}
+
+// copyFields copies fields deeply, so that merging does not change the fields ao knows
+func copyFields(fields []Field) []Field {
+	if fields == nil {
+		return nil
+	}
+	copied := make([]Field, len(fields))
+	for i, f := range fields {
+		copied[i] = f
+		copied[i].Types = append([]string{}, f.Types...)
+		copied[i].Fields = copyFields(f.Fields)
+	}
+	return copied
+}
+
+func mergeFields(known, other []Field) []Field {
+	var added []Field
+	for _, f := range other {
+		existing := FindField(known, f.Name)
+		if existing == nil {
+			if addedField := FindField(added, f.Name); addedField != nil {
+				addedField.Fields = mergeFields(addedField.Fields, f.Fields)
+			} else {
+				added = append(added, f)
+			}
+			continue
+		}
+		if len(existing.Types) == 0 {
+			existing.Types = f.Types
+		}
+		if contains(existing.Types, "object") {
+			existing.Fields = mergeFields(existing.Fields, f.Fields)
+		}
+	}
+
+	sort.Slice(added, func(i, j int) bool {
+		return added[i].Name < added[j].Name
+	})
+	return append(known, added...)
+}
+
+// deploySpecFields finds the fields of a deploy spec. A field has a value and its sources, and may have nested fields.
+func deploySpecFields(node map[string]interface{}) []Field {
+	var fields []Field
+	for name, child := range node {
+		childNode, ok := child.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		f := Field{Name: name, Description: serverFieldDescription, Files: AllFiles}
+		value, isField := childNode["value"]
+		if isField {
+			if onlyStaticSources(childNode) {
+				continue
+			}
+			f.Types = []string{jsonType(value)}
+		} else {
+			f.Types = []string{"object"}
+		}
+
+		nested := make(map[string]interface{})
+		for key, value := range childNode {
+			if key != "value" && key != "source" && key != "sources" {
+				nested[key] = value
+			}
+		}
+		f.Fields = deploySpecFields(nested)
+		if len(f.Fields) > 0 && !contains(f.Types, "object") {
+			f.Types = append(f.Types, "object")
+		}
+		fields = append(fields, f)
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		return fields[i].Name < fields[j].Name
+	})
+	return fields
+}
+
+func onlyStaticSources(node map[string]interface{}) bool {
+	sources, _ := node["sources"].([]interface{})
+	if len(sources) == 0 {
+		return node["source"] == "static"
+	}
+	for _, source := range sources {
+		if s, ok := source.(map[string]interface{}); !ok || s["name"] != "static" {
+			return false
+		}
+	}
+	return true
+}
+
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "string"
+}
@@ -0,0 +1,70 @@
+package auroraconfig
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/skatteetaten/ao/pkg/deploymentspec"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_JSONSchema(t *testing.T) {
+	schema, err := JSONSchema("application", Fields)
+	assert.NoError(t, err)
+	assert.Equal(t, "AuroraConfig application file", schema["title"])
+	assert.Equal(t, false, schema["additionalProperties"])
+
+	properties := schema["properties"].(map[string]interface{})
+	assert.Contains(t, properties, "baseFile")
+	assert.NotContains(t, properties, "affiliation")
+	assert.Equal(t, map[string]interface{}{
+		"description": "Readiness probe",
+		"type":        []string{"boolean", "object"},
+		"properties":  schemaProperties(probeFields),
+	}, properties["readiness"])
+	assert.Equal(t, []string{"rolling", "recreate"},
+		properties["deployStrategy"].(map[string]interface{})["properties"].(map[string]interface{})["type"].(map[string]interface{})["enum"])
+
+	schema, err = JSONSchema("about", Fields)
+	assert.NoError(t, err)
+	properties = schema["properties"].(map[string]interface{})
+	assert.Contains(t, properties, "affiliation")
+	assert.NotContains(t, properties, "baseFile")
+
+	_, err = JSONSchema("vault", Fields)
+	assert.Error(t, err)
+}
+
+func Test_MergeDeploySpecFields(t *testing.T) {
+	var spec deploymentspec.DeploymentSpec
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"applicationDeploymentRef": {"source": "static", "value": "utv/foo", "sources": [{"name": "static", "value": "utv/foo"}]},
+		"version": {"source": "foo.json", "value": "1.0.0", "sources": [{"name": "foo.json", "value": "1.0.0"}]},
+		"deployStrategy": {
+			"type": {"source": "default", "value": "rolling", "sources": [{"name": "default", "value": "rolling"}]},
+			"maxUnavailable": {"source": "default", "value": 25, "sources": [{"name": "default", "value": 25}]}
+		},
+		"jolokia": {"source": "default", "value": true, "sources": [{"name": "default", "value": true}],
+			"port": {"source": "default", "value": 8778, "sources": [{"name": "default", "value": 8778}]}
+		}
+	}`), &spec))
+
+	merged := MergeDeploySpecFields(Fields, []deploymentspec.DeploymentSpec{spec, spec})
+
+	assert.Len(t, merged, len(Fields)+1)
+	assert.Nil(t, FindField(merged, "applicationDeploymentRef"))
+	assert.Equal(t, Field{
+		Name:        "jolokia",
+		Types:       []string{"boolean", "object"},
+		Description: serverFieldDescription,
+		Files:       AllFiles,
+		Fields: []Field{
+			{Name: "port", Types: []string{"integer"}, Description: serverFieldDescription, Files: AllFiles},
+		},
+	}, *FindField(merged, "jolokia"))
+
+	deployStrategy := FindField(merged, "deployStrategy")
+	assert.Len(t, deployStrategy.Fields, 3)
+	assert.NotNil(t, FindField(deployStrategy.Fields, "maxUnavailable"))
+	assert.Len(t, FindField(Fields, "deployStrategy").Fields, 2, "the fields ao knows should not change")
+}
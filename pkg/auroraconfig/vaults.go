@@ -0,0 +1,161 @@
+package auroraconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// VaultUsage is a reference from an ApplicationDeploymentRef to a vault
+type VaultUsage struct {
+	ApplicationDeploymentRef string
+	Vault                    string
+	// Source is the AuroraConfig file where the vault is declared
+	Source string
+}
+
+type vaultDeclaration struct {
+	name    string
+	enabled bool
+	source  string
+}
+
+// VaultUsages finds all vaults referenced with secretVault or secretVaults by the ApplicationDeploymentRefs in the AuroraConfig
+func (ac *AuroraConfig) VaultUsages() ([]VaultUsage, error) {
+	files := make(map[string]*File)
+	var fileNames FileNames
+	for i := range ac.Files {
+		file := &ac.Files[i]
+		files[file.NameWithoutExtension()] = file
+		fileNames = append(fileNames, file.Name)
+	}
+
+	var usages []VaultUsage
+	for _, ref := range fileNames.GetApplicationDeploymentRefs() {
+		declarations, err := collectVaultDeclarations(ref, files)
+		if err != nil {
+			return nil, err
+		}
+
+		keys := make([]string, 0, len(declarations))
+		for key := range declarations {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			declaration := declarations[key]
+			if !declaration.enabled || declaration.name == "" {
+				continue
+			}
+			usages = append(usages, VaultUsage{
+				ApplicationDeploymentRef: ref,
+				Vault:                    declaration.name,
+				Source:                   declaration.source,
+			})
+		}
+	}
+
+	return usages, nil
+}
+
+// UsagesOfVault finds the ApplicationDeploymentRefs that mount the given vault
+func (ac *AuroraConfig) UsagesOfVault(vaultName string) ([]VaultUsage, error) {
+	usages, err := ac.VaultUsages()
+	if err != nil {
+		return nil, err
+	}
+
+	var filtered []VaultUsage
+	for _, usage := range usages {
+		if usage.Vault == vaultName {
+			filtered = append(filtered, usage)
+		}
+	}
+	return filtered, nil
+}
+
+// collectVaultDeclarations merges vault declarations from all files contributing to an ApplicationDeploymentRef
+func collectVaultDeclarations(ref string, files map[string]*File) (map[string]*vaultDeclaration, error) {
+	deploymentFile := files[ref]
+	deploymentContent, err := deploymentFile.Content()
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not parse %s", deploymentFile.Name)
+	}
+
+	declarations := make(map[string]*vaultDeclaration)
+	for _, name := range deploymentFileNames(ref, deploymentContent) {
+		file, found := files[name]
+		if !found {
+			continue
+		}
+		content := deploymentContent
+		if file != deploymentFile {
+			content, err = file.Content()
+			if err != nil {
+				return nil, errors.Wrapf(err, "could not parse %s", file.Name)
+			}
+		}
+		mergeVaultDeclarations(declarations, content, file.Name)
+	}
+
+	return declarations, nil
+}
+
+// deploymentFileNames returns the names without extension of the files contributing to an ApplicationDeploymentRef,
+// in order of increasing precedence: about, env/about, base file and env/app. The envFile and baseFile of the
+// deployment file replace env/about and the base file.
+func deploymentFileNames(ref string, deploymentContent map[string]interface{}) []string {
+	split := strings.Split(ref, "/")
+	env, app := split[0], split[1]
+
+	baseFile := app
+	if value, ok := deploymentContent["baseFile"].(string); ok && value != "" {
+		baseFile = FileNames{value}.WithoutExtension()[0]
+	}
+	envFile := env + "/about"
+	if value, ok := deploymentContent["envFile"].(string); ok && value != "" {
+		envFile = env + "/" + FileNames{value}.WithoutExtension()[0]
+	}
+	return []string{"about", envFile, baseFile, ref}
+}
+
+func mergeVaultDeclarations(declarations map[string]*vaultDeclaration, content map[string]interface{}, source string) {
+	if secretVault, found := content["secretVault"]; found {
+		mergeVaultDeclaration(declarations, "secretVault", "", secretVault, source)
+	}
+
+	secretVaults, ok := content["secretVaults"].(map[string]interface{})
+	if !ok {
+		return
+	}
+	for key, value := range secretVaults {
+		mergeVaultDeclaration(declarations, "secretVaults/"+key, key, value, source)
+	}
+}
+
+func mergeVaultDeclaration(declarations map[string]*vaultDeclaration, key, defaultName string, value interface{}, source string) {
+	declaration, found := declarations[key]
+	if !found {
+		declaration = &vaultDeclaration{
+			name:    defaultName,
+			enabled: true,
+		}
+		declarations[key] = declaration
+	}
+	declaration.source = source
+
+	switch v := value.(type) {
+	case string:
+		declaration.name = v
+	case map[string]interface{}:
+		if name, ok := v["name"]; ok {
+			declaration.name = fmt.Sprint(name)
+		}
+		if enabled, ok := v["enabled"].(bool); ok {
+			declaration.enabled = enabled
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package auroraconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_VaultUsages(t *testing.T) {
+	ac := AuroraConfig{
+		Name: "paas",
+		Files: []File{
+			{Name: "about.json", Contents: `{"affiliation": "paas"}`},
+			{Name: "utv/about.json", Contents: `{"cluster": "utv", "secretVault": "utv-vault"}`},
+			{Name: "test/about.yaml", Contents: "cluster: test\n"},
+			{Name: "foo.json", Contents: `{"secretVaults": {"foo-vault": {"keys": ["PASSWORD"]}, "other": {"name": "shared-vault"}}}`},
+			{Name: "bar.yaml", Contents: "secretVaults:\n  shared:\n    name: shared-vault\n"},
+			{Name: "utv/foo.json", Contents: `{"secretVaults": {"other": {"enabled": false}}}`},
+			{Name: "test/foo.json", Contents: `{}`},
+			{Name: "test/bar.yaml", Contents: "baseFile: bar.yaml\n"},
+			{Name: "test/baz.json", Contents: `{"baseFile": "bar.yaml"}`},
+		},
+	}
+
+	t.Run("Should find all vaults mounted by each ApplicationDeploymentRef", func(t *testing.T) {
+		usages, err := ac.VaultUsages()
+		assert.NoError(t, err)
+		assert.Equal(t, []VaultUsage{
+			{ApplicationDeploymentRef: "test/bar", Vault: "shared-vault", Source: "bar.yaml"},
+			{ApplicationDeploymentRef: "test/baz", Vault: "shared-vault", Source: "bar.yaml"},
+			{ApplicationDeploymentRef: "test/foo", Vault: "foo-vault", Source: "foo.json"},
+			{ApplicationDeploymentRef: "test/foo", Vault: "shared-vault", Source: "foo.json"},
+			{ApplicationDeploymentRef: "utv/foo", Vault: "utv-vault", Source: "utv/about.json"},
+			{ApplicationDeploymentRef: "utv/foo", Vault: "foo-vault", Source: "foo.json"},
+		}, usages)
+	})
+
+	t.Run("Should find usages of a single vault", func(t *testing.T) {
+		usages, err := ac.UsagesOfVault("shared-vault")
+		assert.NoError(t, err)
+		assert.Len(t, usages, 3)
+
+		usages, err = ac.UsagesOfVault("unused-vault")
+		assert.NoError(t, err)
+		assert.Empty(t, usages)
+	})
+
+	t.Run("Should fail when a file can not be parsed", func(t *testing.T) {
+		broken := AuroraConfig{
+			Files: []File{
+				{Name: "utv/foo.json", Contents: `{"secretVault": `},
+			},
+		}
+		_, err := broken.VaultUsages()
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "utv/foo.json")
+	})
+}
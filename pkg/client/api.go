@@ -2,6 +2,8 @@ package client
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"fmt"
 	"github.com/google/uuid"
@@ -40,6 +42,11 @@ type APIClient struct {
 	Affiliation    string
 	RefName        string
 	Korrelasjonsid string
+	// TLSConfig is used for calls to Boober and Gobo. The default TLS configuration is used if nil.
+	TLSConfig *tls.Config
+	// Transport is used for calls to Boober and Gobo, sharing retries between them.
+	// A transport with default options is created on first use if nil.
+	Transport *Transport
 }
 
 // NewAPIClientDefaultRef creates a new, default APIClient
@@ -76,6 +83,11 @@ func (api *APIClient) Do(method string, endpoint string, payload []byte) (*Boobe
 
 // DoWithHeader performs an API call to an external endpoint with specific headers
 func (api *APIClient) DoWithHeader(method string, endpoint string, header map[string]string, payload []byte) (*ResponseBundle, error) {
+	return api.doWithContext(context.Background(), method, endpoint, header, payload)
+}
+
+// doWithContext performs an API call with a context, which may mark the call as safe to retry with WithIdempotent
+func (api *APIClient) doWithContext(ctx context.Context, method string, endpoint string, header map[string]string, payload []byte) (*ResponseBundle, error) {
 
 	url := api.Host + BooberAPIVersion + endpoint
 	logrus.WithFields(logrus.Fields{
@@ -89,7 +101,7 @@ func (api *APIClient) DoWithHeader(method string, endpoint string, header map[st
 		logrus.Debug("Payload", string(payload))
 	}
 
-	req, err := http.NewRequest(method, url, bytes.NewBuffer(payload))
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(payload))
 	if err != nil {
 		return nil, err
 	}
@@ -108,8 +120,7 @@ func (api *APIClient) DoWithHeader(method string, endpoint string, header map[st
 
 	logrus.Debug("Header Ref-Name: ", req.Header.Get("Ref-Name"))
 
-	client := http.DefaultClient
-	res, err := client.Do(req)
+	res, err := api.httpClient().Do(req)
 	if err != nil {
 		return nil, errors.Wrap(err, "Error connecting to api")
 	}
@@ -167,6 +178,14 @@ func (api *APIClient) DoWithHeader(method string, endpoint string, header map[st
 	}, nil
 }
 
+// httpClient returns a client using the transport of the API client
+func (api *APIClient) httpClient() *http.Client {
+	if api.Transport == nil {
+		api.Transport = NewTransport(api.TLSConfig, DefaultTransportOptions())
+	}
+	return api.Transport.Client()
+}
+
 func handleInternalServerError(body []byte, url string, korrelasjonsid string) error {
 	internalError := struct {
 		Message   string `json:"message"`
@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/sirupsen/logrus"
@@ -77,11 +78,15 @@ func (api *APIClient) GetAuroraConfigNames() (*auroraconfig.Names, error) {
 
 // PutAuroraConfig sets aurora configuration via API calls
 func (api *APIClient) PutAuroraConfig(endpoint string, payload []byte) (string, error) {
+	return api.putAuroraConfig(context.Background(), endpoint, payload)
+}
 
-	response, err := api.Do(http.MethodPut, endpoint, payload)
-	if err != nil {
+func (api *APIClient) putAuroraConfig(ctx context.Context, endpoint string, payload []byte) (string, error) {
+	bundle, err := api.doWithContext(ctx, http.MethodPut, endpoint, nil, payload)
+	if err != nil || bundle == nil {
 		return "", err
 	}
+	response := bundle.BooberResponse
 
 	if !response.Success {
 		return "", response.Error()
@@ -113,7 +118,8 @@ func (api *APIClient) ValidateAuroraConfig(ac *auroraconfig.AuroraConfig, fullVa
 	if err != nil {
 		return "", err
 	}
-	return api.PutAuroraConfig(endpoint, payload)
+	// Validation does not change anything, and can be retried
+	return api.putAuroraConfig(WithIdempotent(context.Background()), endpoint, payload)
 
 }
 
@@ -125,7 +131,7 @@ func (api *APIClient) ValidateRemoteAuroraConfig(fullValidation bool) (string, e
 	}
 	endpoint := fmt.Sprintf("/auroraconfig/%s/validate?resourceValidation=%s&mergeWithRemoteConfig=true", api.Affiliation, resourceValidation)
 
-	return api.PutAuroraConfig(endpoint, nil)
+	return api.putAuroraConfig(WithIdempotent(context.Background()), endpoint, nil)
 }
 
 func formatWarnings(warnings []string) string {
@@ -19,7 +19,8 @@ func (api *APIClient) RunGraphQl(graphQlRequest string, vars map[string]interfac
 		req.Var(key, value)
 	}
 
-	ctx := context.Background()
+	// Queries do not change anything, and can be retried
+	ctx := WithIdempotent(context.Background())
 
 	if err := client.Run(ctx, req, response); err != nil {
 		extractederr := extractGraphqlErrorMsgs(err)
@@ -45,7 +46,7 @@ func (api *APIClient) RunGraphQlMutation(graphQlRequest *graphql.Request, respon
 
 func (api *APIClient) getGraphQlClient() *graphql.Client {
 	endpoint := fmt.Sprintf("%s/graphql", api.GoboHost)
-	client := graphql.NewClient(endpoint)
+	client := graphql.NewClient(endpoint, graphql.WithHTTPClient(api.httpClient()))
 	client.Log = func(logEntry string) { logrus.Debug(logEntry) }
 	return client
 }
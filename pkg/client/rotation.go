@@ -0,0 +1,239 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RotationSecretName is the name of the sidecar secret holding rotation metadata for the other secrets in a vault
+const RotationSecretName = ".rotation.json"
+
+var now = time.Now
+
+// SecretRotation holds rotation metadata for a secret
+type SecretRotation struct {
+	Created          time.Time `json:"created"`
+	Rotated          time.Time `json:"rotated"`
+	RotationInterval string    `json:"rotationInterval,omitempty"`
+}
+
+// RotationMetadata holds rotation metadata for the secrets in a vault
+type RotationMetadata struct {
+	Secrets map[string]*SecretRotation `json:"secrets"`
+}
+
+// StaleSecret is a secret that is due for rotation
+type StaleSecret struct {
+	Name string
+	// LastRotated is zero when there is no rotation metadata for the secret
+	LastRotated time.Time
+	Reason      string
+}
+
+// NewRotationMetadata creates empty rotation metadata
+func NewRotationMetadata() *RotationMetadata {
+	return &RotationMetadata{Secrets: make(map[string]*SecretRotation)}
+}
+
+// ParseRotationInterval parses durations like 90d, 12w or 2160h
+func ParseRotationInterval(interval string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+	for suffix, unit := range units {
+		if strings.HasSuffix(interval, suffix) {
+			count, err := strconv.Atoi(strings.TrimSuffix(interval, suffix))
+			if err != nil || count < 0 {
+				return 0, errors.Errorf("invalid interval %s", interval)
+			}
+			return time.Duration(count) * unit, nil
+		}
+	}
+
+	duration, err := time.ParseDuration(interval)
+	if err != nil {
+		return 0, errors.Errorf("invalid interval %s, use e.g. 90d, 12w or 2160h", interval)
+	}
+	return duration, nil
+}
+
+// Record updates the metadata for a created or rotated secret
+func (m *RotationMetadata) Record(secretName string, created bool, at time.Time) {
+	rotation, found := m.Secrets[secretName]
+	if !found {
+		rotation = &SecretRotation{Created: at}
+		m.Secrets[secretName] = rotation
+	}
+	if created {
+		rotation.Created = at
+	}
+	rotation.Rotated = at
+}
+
+// Remove removes the metadata of a deleted secret. It returns false if the secret had no metadata.
+func (m *RotationMetadata) Remove(secretName string) bool {
+	if _, found := m.Secrets[secretName]; !found {
+		return false
+	}
+	delete(m.Secrets, secretName)
+	return true
+}
+
+// Rename moves the metadata of a renamed secret. It returns false if the secret had no metadata.
+func (m *RotationMetadata) Rename(oldSecretName, newSecretName string) bool {
+	rotation, found := m.Secrets[oldSecretName]
+	if !found {
+		return false
+	}
+	delete(m.Secrets, oldSecretName)
+	m.Secrets[newSecretName] = rotation
+	return true
+}
+
+// StaleSecrets finds the secrets that are older than the given age, or older than their own rotation interval
+func (m *RotationMetadata) StaleSecrets(secretNames []string, olderThan time.Duration, at time.Time) []StaleSecret {
+	var stale []StaleSecret
+	for _, name := range secretNames {
+		if name == RotationSecretName {
+			continue
+		}
+
+		rotation, found := m.Secrets[name]
+		if !found || rotation.Rotated.IsZero() {
+			stale = append(stale, StaleSecret{Name: name, Reason: "no rotation metadata"})
+			continue
+		}
+
+		age := at.Sub(rotation.Rotated)
+		if olderThan > 0 && age > olderThan {
+			stale = append(stale, StaleSecret{Name: name, LastRotated: rotation.Rotated, Reason: "older than " + formatDays(olderThan)})
+			continue
+		}
+
+		if rotation.RotationInterval == "" {
+			continue
+		}
+		interval, err := ParseRotationInterval(rotation.RotationInterval)
+		if err == nil && age > interval {
+			stale = append(stale, StaleSecret{Name: name, LastRotated: rotation.Rotated, Reason: "rotation interval " + rotation.RotationInterval + " exceeded"})
+		}
+	}
+
+	sort.Slice(stale, func(i, j int) bool {
+		return stale[i].Name < stale[j].Name
+	})
+	return stale
+}
+
+func formatDays(duration time.Duration) string {
+	return strconv.Itoa(int(duration.Hours()/24)) + "d"
+}
+
+// GetRotationMetadata gets the rotation metadata of a vault. Empty metadata is returned if the vault has none.
+func (api *APIClient) GetRotationMetadata(vaultName string) (*RotationMetadata, bool, error) {
+	secret, err := api.GetSecret(vaultName, RotationSecretName)
+	if err != nil {
+		var notFound *SecretNotFoundError
+		if errors.As(err, &notFound) {
+			return NewRotationMetadata(), false, nil
+		}
+		return nil, false, err
+	}
+
+	content, err := secret.DecodedSecret()
+	if err != nil {
+		return nil, true, err
+	}
+
+	metadata := NewRotationMetadata()
+	if err := json.Unmarshal([]byte(content), metadata); err != nil {
+		return nil, true, errors.Wrapf(err, "could not parse %s in vault %s", RotationSecretName, vaultName)
+	}
+	if metadata.Secrets == nil {
+		metadata.Secrets = make(map[string]*SecretRotation)
+	}
+	return metadata, true, nil
+}
+
+// SaveRotationMetadata saves the rotation metadata of a vault
+func (api *APIClient) SaveRotationMetadata(vaultName string, metadata *RotationMetadata, exists bool) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return api.updateSecret(vaultName, RotationSecretName, string(data))
+	}
+	secret := NewSecret(RotationSecretName, base64.StdEncoding.EncodeToString(data))
+	return api.addSecrets(vaultName, []Secret{secret})
+}
+
+// RecordSecretRotation records creation or rotation of secrets in the rotation metadata of a vault
+func (api *APIClient) RecordSecretRotation(vaultName string, secretNames []string, created bool) error {
+	return api.recordSecretRotation(vaultName, secretNames, func(string) bool {
+		return created
+	})
+}
+
+// recordSecretRotation records creation of the secrets for which created is true, and rotation of the others
+func (api *APIClient) recordSecretRotation(vaultName string, secretNames []string, created func(secretName string) bool) error {
+	var recorded []string
+	for _, name := range secretNames {
+		if name != RotationSecretName {
+			recorded = append(recorded, name)
+		}
+	}
+	if len(recorded) == 0 {
+		return nil
+	}
+
+	metadata, exists, err := api.GetRotationMetadata(vaultName)
+	if err != nil {
+		return err
+	}
+
+	at := now().UTC()
+	for _, name := range recorded {
+		metadata.Record(name, created(name), at)
+	}
+
+	return api.SaveRotationMetadata(vaultName, metadata, exists)
+}
+
+// RemoveSecretRotation removes deleted secrets from the rotation metadata of a vault
+func (api *APIClient) RemoveSecretRotation(vaultName string, secretNames []string) error {
+	return api.changeRotationMetadata(vaultName, func(metadata *RotationMetadata) bool {
+		changed := false
+		for _, name := range secretNames {
+			changed = metadata.Remove(name) || changed
+		}
+		return changed
+	})
+}
+
+// RenameSecretRotation moves the rotation metadata of a renamed secret
+func (api *APIClient) RenameSecretRotation(vaultName, oldSecretName, newSecretName string) error {
+	return api.changeRotationMetadata(vaultName, func(metadata *RotationMetadata) bool {
+		return metadata.Rename(oldSecretName, newSecretName)
+	})
+}
+
+// changeRotationMetadata saves the rotation metadata of a vault if change reports that it changed it
+func (api *APIClient) changeRotationMetadata(vaultName string, change func(metadata *RotationMetadata) bool) error {
+	metadata, exists, err := api.GetRotationMetadata(vaultName)
+	if err != nil {
+		return err
+	}
+	if !exists || !change(metadata) {
+		return nil
+	}
+	return api.SaveRotationMetadata(vaultName, metadata, exists)
+}
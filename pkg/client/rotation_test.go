@@ -0,0 +1,207 @@
+package client
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRotationInterval(t *testing.T) {
+	tests := []struct {
+		interval string
+		want     time.Duration
+		wantErr  bool
+	}{
+		{"90d", 90 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"36h", 36 * time.Hour, false},
+		{"d", 0, true},
+		{"ninety days", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.interval, func(t *testing.T) {
+			got, err := ParseRotationInterval(tt.interval)
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRotationMetadata_StaleSecrets(t *testing.T) {
+	at := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	metadata := NewRotationMetadata()
+	metadata.Record("fresh.properties", true, at.Add(-10*24*time.Hour))
+	metadata.Record("old.properties", true, at.Add(-100*24*time.Hour))
+	metadata.Record("frequent.properties", true, at.Add(-10*24*time.Hour))
+	metadata.Secrets["frequent.properties"].RotationInterval = "7d"
+
+	stale := metadata.StaleSecrets([]string{"fresh.properties", "old.properties", "frequent.properties", "unknown.properties", RotationSecretName}, 90*24*time.Hour, at)
+
+	assert.Equal(t, []StaleSecret{
+		{Name: "frequent.properties", LastRotated: at.Add(-10 * 24 * time.Hour), Reason: "rotation interval 7d exceeded"},
+		{Name: "old.properties", LastRotated: at.Add(-100 * 24 * time.Hour), Reason: "older than 90d"},
+		{Name: "unknown.properties", Reason: "no rotation metadata"},
+	}, stale)
+}
+
+func TestRotationMetadata_Record(t *testing.T) {
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	rotated := created.Add(time.Hour)
+
+	metadata := NewRotationMetadata()
+	metadata.Record("latest.properties", true, created)
+	metadata.Record("latest.properties", false, rotated)
+
+	assert.Equal(t, &SecretRotation{Created: created, Rotated: rotated}, metadata.Secrets["latest.properties"])
+}
+
+func TestRotationMetadata_RemoveAndRename(t *testing.T) {
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	metadata := NewRotationMetadata()
+	metadata.Record("old.properties", true, at)
+	metadata.Record("deleted.properties", true, at)
+
+	assert.True(t, metadata.Rename("old.properties", "new.properties"))
+	assert.True(t, metadata.Remove("deleted.properties"))
+	assert.False(t, metadata.Remove("unknown.properties"))
+	assert.False(t, metadata.Rename("unknown.properties", "other.properties"))
+	assert.Equal(t, map[string]*SecretRotation{"new.properties": {Created: at, Rotated: at}}, metadata.Secrets)
+}
+
+func TestAPIClient_RecordSecretRotation(t *testing.T) {
+	t.Run("Should create rotation metadata secret when vault has none", func(t *testing.T) {
+		now = func() time.Time { return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC) }
+		defer func() { now = time.Now }()
+
+		var requests []map[string]interface{}
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			requests = append(requests, body)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"data":{"affiliations":{"edges":[]}}}`))
+		}))
+		defer ts.Close()
+
+		api := NewAPIClientDefaultRef("", ts.URL, "test", affiliation, "")
+		err := api.RecordSecretRotation("my_test_vault", []string{"latest.properties"}, true)
+		assert.NoError(t, err)
+
+		assert.Len(t, requests, 2)
+		assert.Contains(t, requests[1]["query"], "addVaultSecrets")
+		input := requests[1]["variables"].(map[string]interface{})["addVaultSecretsInput"].(map[string]interface{})
+		secret := input["secrets"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, RotationSecretName, secret["name"])
+	})
+
+	t.Run("Should not record rotation of the rotation metadata itself", func(t *testing.T) {
+		api := NewAPIClientDefaultRef("", "http://localhost:0", "test", affiliation, "")
+		err := api.RecordSecretRotation("my_test_vault", []string{RotationSecretName}, false)
+		assert.NoError(t, err)
+	})
+}
+
+func TestAPIClient_AddSecretsRecordsRotation(t *testing.T) {
+	now = func() time.Time { return time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC) }
+	defer func() { now = time.Now }()
+
+	metadata := `{"secrets":{"secret.txt":{"created":"2019-01-01T00:00:00Z","rotated":"2019-01-01T00:00:00Z"}}}`
+	secretNames := `{"data":{"affiliations":{"edges":[{"node":{"name":"paas","vaults":[{"name":"my_test_vault",` +
+		`"secrets":[{"name":"secret.txt"},{"name":".rotation.json"}]}]}}]}}}`
+	rotationSecret := `{"data":{"affiliations":{"edges":[{"node":{"name":"paas","vaults":[{"name":"my_test_vault",` +
+		`"secrets":[{"name":".rotation.json","base64Content":"` + base64.StdEncoding.EncodeToString([]byte(metadata)) + `"}]}]}}]}}}`
+
+	var saved map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		query := body["query"].(string)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		switch {
+		case strings.Contains(query, "getSecretNames"):
+			w.Write([]byte(secretNames))
+		case strings.Contains(query, "query getVaults"):
+			w.Write([]byte(rotationSecret))
+		case strings.Contains(query, "updateVaultSecret"):
+			saved = body["variables"].(map[string]interface{})["updateVaultSecretInput"].(map[string]interface{})
+			w.Write([]byte(`{"data":{}}`))
+		default:
+			w.Write([]byte(`{"data":{}}`))
+		}
+	}))
+	defer ts.Close()
+
+	api := NewAPIClientDefaultRef("", ts.URL, "test", affiliation, "")
+	secrets := []Secret{NewSecret("secret.txt", "c2VjcmV0"), NewSecret("new.txt", "bmV3")}
+	assert.NoError(t, api.AddSecrets("my_test_vault", secrets))
+
+	if assert.NotNil(t, saved) {
+		content, err := base64.StdEncoding.DecodeString(saved["base64Content"].(string))
+		assert.NoError(t, err)
+		recorded := NewRotationMetadata()
+		assert.NoError(t, json.Unmarshal(content, recorded))
+
+		created := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+		at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+		assert.Equal(t, &SecretRotation{Created: created, Rotated: at}, recorded.Secrets["secret.txt"], "an overwritten secret is rotated")
+		assert.Equal(t, &SecretRotation{Created: at, Rotated: at}, recorded.Secrets["new.txt"])
+	}
+}
+
+func TestAPIClient_CreateVaultRecordsRotation(t *testing.T) {
+	var requests []map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(req.Body).Decode(&body)
+		requests = append(requests, body)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"data":{"affiliations":{"edges":[]}}}`))
+	}))
+	defer ts.Close()
+
+	vault := NewVault("my_test_vault")
+	vault.Secrets = []Secret{NewSecret("latest.properties", "YWJjMTIz")}
+	vault.Permissions = []string{"utv_permission"}
+	api := NewAPIClientDefaultRef("", ts.URL, "test", affiliation, "")
+	assert.NoError(t, api.CreateVault(*vault))
+
+	assert.Len(t, requests, 3)
+	assert.Contains(t, requests[0]["query"], "createVault")
+	assert.Contains(t, requests[2]["query"], "addVaultSecrets")
+	input := requests[2]["variables"].(map[string]interface{})["addVaultSecretsInput"].(map[string]interface{})
+	secret := input["secrets"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, RotationSecretName, secret["name"])
+}
+
+func TestAPIClient_RemoveSecretRotation(t *testing.T) {
+	t.Run("Should not create rotation metadata when vault has none", func(t *testing.T) {
+		var requests []map[string]interface{}
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var body map[string]interface{}
+			json.NewDecoder(req.Body).Decode(&body)
+			requests = append(requests, body)
+
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"data":{"affiliations":{"edges":[]}}}`))
+		}))
+		defer ts.Close()
+
+		api := NewAPIClientDefaultRef("", ts.URL, "test", affiliation, "")
+		assert.NoError(t, api.RemoveSecretRotation("my_test_vault", []string{"latest.properties"}))
+		assert.NoError(t, api.RenameSecretRotation("my_test_vault", "latest.properties", "next.properties"))
+		assert.Len(t, requests, 2)
+	})
+}
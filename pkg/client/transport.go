@@ -0,0 +1,271 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/skatteetaten/ao/pkg/config"
+)
+
+// Default timeouts and retries of calls to Boober and Gobo
+const (
+	DefaultConnectTimeout = 10 * time.Second
+	DefaultRequestTimeout = 0 // no timeout, deploys may take a long time
+	DefaultMaxRetries     = 3
+	DefaultRetryBudget    = 10
+
+	minRetryBackoff   = 250 * time.Millisecond
+	maxRetryBackoff   = 8 * time.Second
+	maxRetryAfterWait = 30 * time.Second
+)
+
+// TransportOptions are the timeouts and retries of a Transport
+type TransportOptions struct {
+	// ConnectTimeout limits the time used to connect to a server
+	ConnectTimeout time.Duration
+	// RequestTimeout limits the total time of a request including retries. Zero means no timeout.
+	RequestTimeout time.Duration
+	// MaxRetries is the number of times a single request is retried
+	MaxRetries int
+	// RetryBudget is the number of retries shared by all requests using the same transport
+	RetryBudget int
+}
+
+// DefaultTransportOptions returns the default timeouts and retries
+func DefaultTransportOptions() TransportOptions {
+	return TransportOptions{
+		ConnectTimeout: DefaultConnectTimeout,
+		RequestTimeout: DefaultRequestTimeout,
+		MaxRetries:     DefaultMaxRetries,
+		RetryBudget:    DefaultRetryBudget,
+	}
+}
+
+// NewTransportOptions returns the timeouts and retries from the ao config, with defaults for the ones not set
+func NewTransportOptions(httpConfig *config.HTTPConfig) TransportOptions {
+	options := DefaultTransportOptions()
+	if httpConfig == nil {
+		return options
+	}
+	options.ConnectTimeout = parseDuration(httpConfig.ConnectTimeout, options.ConnectTimeout)
+	options.RequestTimeout = parseDuration(httpConfig.RequestTimeout, options.RequestTimeout)
+	if httpConfig.MaxRetries != nil && *httpConfig.MaxRetries >= 0 {
+		options.MaxRetries = *httpConfig.MaxRetries
+	}
+	if httpConfig.RetryBudget != nil && *httpConfig.RetryBudget >= 0 {
+		options.RetryBudget = *httpConfig.RetryBudget
+	}
+	return options
+}
+
+func parseDuration(value string, defaultDuration time.Duration) time.Duration {
+	if value == "" {
+		return defaultDuration
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		logrus.Warnf("Invalid timeout %q, using %s", value, defaultDuration)
+		return defaultDuration
+	}
+	return duration
+}
+
+// retryBudget is the number of retries left, shared by all requests of an ao command
+type retryBudget struct {
+	mu        sync.Mutex
+	remaining int
+}
+
+func (b *retryBudget) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.remaining <= 0 {
+		return false
+	}
+	b.remaining--
+	return true
+}
+
+// Transport is an http.RoundTripper for calls to Boober and Gobo. It uses the proxy given by
+// HTTPS_PROXY and NO_PROXY, and retries idempotent requests that fail with a network error or
+// a temporary server error, with exponential backoff and jitter.
+type Transport struct {
+	base    http.RoundTripper
+	options TransportOptions
+	budget  *retryBudget
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+// NewTransport creates a transport with its own retry budget
+func NewTransport(tlsConfig *tls.Config, options TransportOptions) *Transport {
+	return &Transport{
+		base:    newBaseTransport(tlsConfig, options),
+		options: options,
+		budget:  &retryBudget{remaining: options.RetryBudget},
+		sleep:   sleepContext,
+	}
+}
+
+// WithTLSConfig returns a transport using another TLS configuration, sharing the retry budget of t
+func (t *Transport) WithTLSConfig(tlsConfig *tls.Config) *Transport {
+	return &Transport{
+		base:    newBaseTransport(tlsConfig, t.options),
+		options: t.options,
+		budget:  t.budget,
+		sleep:   t.sleep,
+	}
+}
+
+func newBaseTransport(tlsConfig *tls.Config, options TransportOptions) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	transport.DialContext = (&net.Dialer{
+		Timeout:   options.ConnectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = options.ConnectTimeout
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return transport
+}
+
+// Client returns an http client using the transport
+func (t *Transport) Client() *http.Client {
+	return &http.Client{
+		Transport: t,
+		Timeout:   t.options.RequestTimeout,
+	}
+}
+
+type idempotentKey struct{}
+
+// WithIdempotent marks requests with the context as safe to retry, e.g. a POST of a GraphQL query. Only requests
+// that change nothing on the server should be marked, since a failed request may still have been carried out.
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+// isIdempotent is true for safe methods, and for requests marked with WithIdempotent. PUT and DELETE are not
+// retried unless marked, since Boober uses PUT for deploys.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	idempotent, _ := req.Context().Value(idempotentKey{}).(bool)
+	return idempotent
+}
+
+// RoundTrip performs a request, retrying it if it is idempotent and failed temporarily
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	retryable := isIdempotent(req) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+
+	for attempt := 0; ; attempt++ {
+		res, err := t.base.RoundTrip(req)
+		if !retryable || attempt >= t.options.MaxRetries || !shouldRetry(req, res, err) {
+			return res, err
+		}
+
+		wait := backoff(attempt)
+		if res != nil {
+			if retryAfter, ok := parseRetryAfter(res.Header.Get("Retry-After")); ok {
+				if retryAfter > maxRetryAfterWait {
+					return res, err
+				}
+				wait = retryAfter
+			}
+		}
+		if !t.budget.take() {
+			logrus.Debug("Retry budget is used up, not retrying ", req.URL)
+			return res, err
+		}
+
+		if res != nil {
+			io.Copy(ioutil.Discard, res.Body)
+			res.Body.Close()
+			logrus.Infof("%s %s returned %d, retrying in %s", req.Method, req.URL, res.StatusCode, wait)
+		} else {
+			logrus.Infof("%s %s failed: %s, retrying in %s", req.Method, req.URL, err, wait)
+		}
+
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		if req, err = rewind(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func shouldRetry(req *http.Request, res *http.Response, err error) bool {
+	if err != nil {
+		return req.Context().Err() == nil
+	}
+	switch res.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// rewind returns a copy of the request with a fresh body, so that it can be sent again
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	return retry, nil
+}
+
+// backoff returns a random wait between half and all of an exponentially growing duration
+func backoff(attempt int) time.Duration {
+	d := minRetryBackoff << uint(attempt)
+	if d <= 0 || d > maxRetryBackoff {
+		d = maxRetryBackoff
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an HTTP date
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		wait := time.Until(date)
+		if wait < 0 {
+			wait = 0
+		}
+		return wait, true
+	}
+	return 0, false
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,193 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/skatteetaten/ao/pkg/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTransport(options TransportOptions) (*Transport, *[]time.Duration) {
+	var waits []time.Duration
+	transport := NewTransport(nil, options)
+	transport.sleep = func(ctx context.Context, d time.Duration) error {
+		waits = append(waits, d)
+		return nil
+	}
+	return transport, &waits
+}
+
+func failingServer(failures int32, status int, header map[string]string) (*httptest.Server, *int32) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := ioutil.ReadAll(req.Body)
+		if atomic.AddInt32(&calls, 1) <= failures {
+			for key, value := range header {
+				w.Header().Set(key, value)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		w.Write(body)
+	}))
+	return ts, &calls
+}
+
+func TestTransport_RetriesIdempotentRequests(t *testing.T) {
+	ts, calls := failingServer(2, http.StatusServiceUnavailable, nil)
+	defer ts.Close()
+	transport, waits := newTestTransport(DefaultTransportOptions())
+
+	req, _ := http.NewRequest(http.MethodPut, ts.URL, strings.NewReader("payload"))
+	res, err := transport.Client().Do(req.WithContext(WithIdempotent(context.Background())))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	body, _ := ioutil.ReadAll(res.Body)
+	assert.Equal(t, "payload", string(body))
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+	assert.Len(t, *waits, 2)
+}
+
+func TestTransport_DoesNotRetryUnmarkedRequests(t *testing.T) {
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodDelete} {
+		t.Run(method, func(t *testing.T) {
+			ts, calls := failingServer(1, http.StatusBadGateway, nil)
+			defer ts.Close()
+			transport, _ := newTestTransport(DefaultTransportOptions())
+
+			req, _ := http.NewRequest(method, ts.URL, strings.NewReader("payload"))
+			res, err := transport.Client().Do(req)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusBadGateway, res.StatusCode)
+			assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+		})
+	}
+}
+
+func TestTransport_RetriesMarkedPost(t *testing.T) {
+	ts, calls := failingServer(1, http.StatusServiceUnavailable, nil)
+	defer ts.Close()
+	transport, _ := newTestTransport(DefaultTransportOptions())
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL, strings.NewReader("payload"))
+	res, err := transport.Client().Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+
+	req, _ = http.NewRequest(http.MethodPost, ts.URL, strings.NewReader("payload"))
+	res, err = transport.Client().Do(req.WithContext(WithIdempotent(context.Background())))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+}
+
+func TestTransport_HonoursRetryAfter(t *testing.T) {
+	ts, _ := failingServer(1, http.StatusTooManyRequests, map[string]string{"Retry-After": "2"})
+	defer ts.Close()
+	transport, waits := newTestTransport(DefaultTransportOptions())
+
+	res, err := transport.Client().Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, []time.Duration{2 * time.Second}, *waits)
+}
+
+func TestTransport_SharesRetryBudget(t *testing.T) {
+	ts, calls := failingServer(100, http.StatusBadGateway, nil)
+	defer ts.Close()
+	options := DefaultTransportOptions()
+	options.MaxRetries = 2
+	options.RetryBudget = 3
+	transport, _ := newTestTransport(options)
+	other := transport.WithTLSConfig(nil)
+
+	res, err := transport.Client().Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, res.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(calls))
+
+	res, err = other.Client().Get(ts.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, res.StatusCode)
+	assert.Equal(t, int32(5), atomic.LoadInt32(calls))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	wait, ok := parseRetryAfter("5")
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, wait)
+
+	wait, ok = parseRetryAfter(time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat))
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), wait)
+
+	_, ok = parseRetryAfter("soon")
+	assert.False(t, ok)
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 0; attempt < 10; attempt++ {
+		wait := backoff(attempt)
+		assert.True(t, wait >= minRetryBackoff/2)
+		assert.True(t, wait <= maxRetryBackoff)
+	}
+}
+
+func TestNewTransportOptions(t *testing.T) {
+	assert.Equal(t, DefaultTransportOptions(), NewTransportOptions(nil))
+
+	retries := 0
+	options := NewTransportOptions(&config.HTTPConfig{
+		ConnectTimeout: "2s",
+		RequestTimeout: "invalid",
+		MaxRetries:     &retries,
+	})
+	assert.Equal(t, 2*time.Second, options.ConnectTimeout)
+	assert.Equal(t, time.Duration(DefaultRequestTimeout), options.RequestTimeout)
+	assert.Equal(t, 0, options.MaxRetries)
+	assert.Equal(t, DefaultRetryBudget, options.RetryBudget)
+}
+
+func TestAPIClient_RetriesOnlyValidation(t *testing.T) {
+	newServer := func() (*httptest.Server, *int32) {
+		var calls int32
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte(`{"success": true, "items": []}`))
+		}))
+		return ts, &calls
+	}
+
+	t.Run("Should not retry deploy", func(t *testing.T) {
+		ts, calls := newServer()
+		defer ts.Close()
+		api := NewAPIClientDefaultRef(ts.URL, "", "", affiliation, "")
+		api.Transport, _ = newTestTransport(DefaultTransportOptions())
+
+		api.Deploy(&DeployPayload{})
+		assert.Equal(t, int32(1), atomic.LoadInt32(calls))
+	})
+
+	t.Run("Should retry validation", func(t *testing.T) {
+		ts, calls := newServer()
+		defer ts.Close()
+		api := NewAPIClientDefaultRef(ts.URL, "", "", affiliation, "")
+		api.Transport, _ = newTestTransport(DefaultTransportOptions())
+
+		_, err := api.ValidateRemoteAuroraConfig(false)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(calls))
+	})
+}
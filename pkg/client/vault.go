@@ -2,12 +2,24 @@ package client
 
 import (
 	"encoding/base64"
+	"fmt"
+
 	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
 	"github.com/skatteetaten/graphql"
 )
 
 const FoundNoSecretsForVault = "Found no secrets for vault"
 
+// VaultClient is a an internal client facade for external vault API calls
+type VaultClient interface {
+	GetVaults() ([]Vault, error)
+	GetSecret(vaultname, secretname string) (*Secret, error)
+	AddSecrets(vaultName string, secrets []Secret) error
+	RemoveSecrets(vaultName string, secretNames []string) error
+	UpdateSecret(vaultName, secretName, modifiedContent string) error
+}
+
 const queryGetVaults = `
 	query getVaults ($affiliation: String!) {
 			 affiliations(names: [$affiliation]) {
@@ -78,12 +90,21 @@ func (api *APIClient) GetSecret(vaultname, secretname string) (*Secret, error) {
 
 	secret := respData.Secret(api.Affiliation, vaultname, secretname)
 	if secret == nil {
-		return nil, errors.Errorf("Failed to find secret %s", secretname)
+		return nil, &SecretNotFoundError{SecretName: secretname}
 	}
 
 	return secret, nil
 }
 
+// SecretNotFoundError is returned when a secret does not exist in a vault
+type SecretNotFoundError struct {
+	SecretName string
+}
+
+func (e *SecretNotFoundError) Error() string {
+	return fmt.Sprintf("Failed to find secret %s", e.SecretName)
+}
+
 type CreateVaultInput struct {
 	AffiliationName string   `json:"affiliationName"`
 	VaultName       string   `json:"vaultName"`
@@ -95,6 +116,7 @@ type CreateVaultResponse struct {
 	CreateVault Vault `json:"createVault"`
 }
 
+// CreateVault creates a vault via gobo, and records the creation of its secrets in the rotation metadata of the vault
 func (api *APIClient) CreateVault(vault Vault) error {
 	if len(vault.Permissions) == 0 {
 		return errors.New("Aborted: Vault can not be created without permissions")
@@ -121,6 +143,10 @@ func (api *APIClient) CreateVault(vault Vault) error {
 		return err
 	}
 
+	if err := api.RecordSecretRotation(vault.Name, secretNames(vault.Secrets), true); err != nil {
+		logrus.Warnf("Could not record creation of secrets in vault %s: %s", vault.Name, err)
+	}
+
 	return nil
 }
 
@@ -297,8 +323,80 @@ const addVaultSecretsRequestString = `mutation addVaultSecrets ($addVaultSecrets
   }
 }`
 
-// AddSecrets adds secrets to vault via gobo
+// AddSecrets adds secrets to vault via gobo, and records in the rotation metadata of the vault that they were
+// created, or rotated if they replaced secrets that already existed
 func (api *APIClient) AddSecrets(vaultName string, secrets []Secret) error {
+	existing, err := api.getSecretNames(vaultName)
+	if err != nil {
+		// Without the existing secrets, keep the creation time of secrets that already have rotation metadata
+		logrus.Debugf("Could not get secrets in vault %s: %s", vaultName, err)
+	}
+
+	if err := api.addSecrets(vaultName, secrets); err != nil {
+		return err
+	}
+
+	created := func(secretName string) bool {
+		return existing != nil && !existing[secretName]
+	}
+	if err := api.recordSecretRotation(vaultName, secretNames(secrets), created); err != nil {
+		logrus.Warnf("Could not record creation of secrets in vault %s: %s", vaultName, err)
+	}
+
+	return nil
+}
+
+const queryGetSecretNames = `
+	query getSecretNames ($affiliation: String!, $vaultname: [String!]!) {
+		affiliations(names: [$affiliation]) {
+			edges {
+				node {
+					name
+					vaults(names: $vaultname){
+						name
+						secrets {
+							name
+						}
+					}
+				}
+			}
+		}
+	}
+`
+
+// getSecretNames gets the names of the secrets in a vault
+func (api *APIClient) getSecretNames(vaultName string) (map[string]bool, error) {
+	var respData AffiliationsResponse
+
+	vars := map[string]interface{}{
+		"affiliation": api.Affiliation,
+		"vaultname":   []string{vaultName},
+	}
+
+	if err := api.RunGraphQl(queryGetSecretNames, vars, &respData); err != nil {
+		return nil, errors.Wrap(err, "Failed to get secrets")
+	}
+
+	names := make(map[string]bool)
+	for _, vault := range respData.Vaults(api.Affiliation) {
+		if vault.Name == vaultName {
+			for _, secret := range vault.Secrets {
+				names[secret.Name] = true
+			}
+		}
+	}
+	return names, nil
+}
+
+func secretNames(secrets []Secret) []string {
+	var names []string
+	for _, secret := range secrets {
+		names = append(names, secret.Name)
+	}
+	return names
+}
+
+func (api *APIClient) addSecrets(vaultName string, secrets []Secret) error {
 	addVaultSecretsRequest := graphql.NewRequest(addVaultSecretsRequestString)
 	addVaultSecretsInput := AddVaultSecretsInput{
 		AffiliationName: api.Affiliation,
@@ -334,8 +432,20 @@ const removeVaultSecretsRequestString = `mutation removeVaultSecrets ($removeVau
   }
 }`
 
-// RemoveSecrets removes secrets from vault via gobo
+// RemoveSecrets removes secrets from vault via gobo, and removes them from the rotation metadata of the vault
 func (api *APIClient) RemoveSecrets(vaultName string, secretNames []string) error {
+	if err := api.removeSecrets(vaultName, secretNames); err != nil {
+		return err
+	}
+
+	if err := api.RemoveSecretRotation(vaultName, secretNames); err != nil {
+		logrus.Warnf("Could not remove rotation metadata of deleted secrets in vault %s: %s", vaultName, err)
+	}
+
+	return nil
+}
+
+func (api *APIClient) removeSecrets(vaultName string, secretNames []string) error {
 	removeVaultSecretsRequest := graphql.NewRequest(removeVaultSecretsRequestString)
 	removeVaultSecretsInput := RemoveVaultSecretsInput{
 		AffiliationName: api.Affiliation,
@@ -372,8 +482,20 @@ const renameVaultSecretRequestString = `mutation renameVaultSecret ($renameVault
   }
 }`
 
-// RenameSecret renames a secret in vault via gobo
+// RenameSecret renames a secret in vault via gobo, and moves its rotation metadata
 func (api *APIClient) RenameSecret(vaultName, oldSecretName, newSecretName string) error {
+	if err := api.renameSecret(vaultName, oldSecretName, newSecretName); err != nil {
+		return err
+	}
+
+	if err := api.RenameSecretRotation(vaultName, oldSecretName, newSecretName); err != nil {
+		logrus.Warnf("Could not move rotation metadata of secret %s/%s: %s", vaultName, oldSecretName, err)
+	}
+
+	return nil
+}
+
+func (api *APIClient) renameSecret(vaultName, oldSecretName, newSecretName string) error {
 	renameVaultSecretRequest := graphql.NewRequest(renameVaultSecretRequestString)
 	renameVaultSecretInput := RenameVaultSecretInput{
 		AffiliationName: api.Affiliation,
@@ -411,8 +533,20 @@ const updateVaultSecretRequestString = `mutation updateVaultSecret ($updateVault
   }
 }`
 
-// UpdateSecret updates a secret in vault via gobo
+// UpdateSecret updates a secret in vault via gobo, and records the rotation in the rotation metadata of the vault
 func (api *APIClient) UpdateSecret(vaultName, secretName, modifiedContent string) error {
+	if err := api.updateSecret(vaultName, secretName, modifiedContent); err != nil {
+		return err
+	}
+
+	if err := api.RecordSecretRotation(vaultName, []string{secretName}, false); err != nil {
+		logrus.Warnf("Could not record rotation of secret %s/%s: %s", vaultName, secretName, err)
+	}
+
+	return nil
+}
+
+func (api *APIClient) updateSecret(vaultName, secretName, modifiedContent string) error {
 	updateVaultSecretRequest := graphql.NewRequest(updateVaultSecretRequestString)
 	updateVaultSecretInput := UpdateVaultSecretInput{
 		AffiliationName: api.Affiliation,
@@ -0,0 +1,94 @@
+package client
+
+import (
+	"github.com/pkg/errors"
+)
+
+// VaultClientMock is a base mock type
+type VaultClientMock struct {
+	APIClientMock
+	Vaults []Vault
+}
+
+// NewVaultClientMock creates a new VaultClientMock holding the given vaults
+func NewVaultClientMock(vaults ...Vault) *VaultClientMock {
+	return &VaultClientMock{Vaults: vaults}
+}
+
+// GetVaults default mock implementation
+func (api *VaultClientMock) GetVaults() ([]Vault, error) {
+	return api.Vaults, nil
+}
+
+// GetSecret default mock implementation
+func (api *VaultClientMock) GetSecret(vaultname, secretname string) (*Secret, error) {
+	vault := api.vault(vaultname)
+	if vault == nil {
+		return nil, &SecretNotFoundError{SecretName: secretname}
+	}
+	for _, secret := range vault.Secrets {
+		if secret.Name == secretname {
+			return &secret, nil
+		}
+	}
+	return nil, &SecretNotFoundError{SecretName: secretname}
+}
+
+// AddSecrets default mock implementation
+func (api *VaultClientMock) AddSecrets(vaultName string, secrets []Secret) error {
+	if args := api.Called(vaultName, secrets); args.Error(0) != nil {
+		return args.Error(0)
+	}
+	vault := api.vault(vaultName)
+	if vault == nil {
+		return errors.Errorf("Vault not found name=%s", vaultName)
+	}
+	for _, secret := range secrets {
+		replaced := false
+		for i := range vault.Secrets {
+			if vault.Secrets[i].Name == secret.Name {
+				vault.Secrets[i] = secret
+				replaced = true
+			}
+		}
+		if !replaced {
+			vault.Secrets = append(vault.Secrets, secret)
+		}
+	}
+	return nil
+}
+
+// RemoveSecrets default mock implementation
+func (api *VaultClientMock) RemoveSecrets(vaultName string, secretNames []string) error {
+	if args := api.Called(vaultName, secretNames); args.Error(0) != nil {
+		return args.Error(0)
+	}
+	vault := api.vault(vaultName)
+	if vault == nil {
+		return errors.Errorf("Vault not found name=%s", vaultName)
+	}
+	for _, name := range secretNames {
+		for i, secret := range vault.Secrets {
+			if secret.Name == name {
+				vault.Secrets = append(vault.Secrets[:i], vault.Secrets[i+1:]...)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// UpdateSecret default mock implementation
+func (api *VaultClientMock) UpdateSecret(vaultName, secretName, modifiedContent string) error {
+	api.Called(vaultName, secretName, modifiedContent)
+	return nil
+}
+
+func (api *VaultClientMock) vault(name string) *Vault {
+	for i := range api.Vaults {
+		if api.Vaults[i].Name == name {
+			return &api.Vaults[i]
+		}
+	}
+	return nil
+}
@@ -36,6 +36,13 @@ type ClusterConfig struct {
 	IsAPICluster     bool   `json:"isApiCluster"`
 	IsUpdateCluster  bool   `json:"isUpdateCluster"`
 	ClusterURLPrefix string `json:"clusterUrlPrefix"`
+	// CAFile is a bundle of CA certificates to trust for the cluster, in addition to the system CAs
+	CAFile string `json:"caFile,omitempty"`
+	// ClientCertFile and ClientKeyFile is a client certificate to present to the cluster
+	ClientCertFile string `json:"clientCertFile,omitempty"`
+	ClientKeyFile  string `json:"clientKeyFile,omitempty"`
+	// UserDefined is true for clusters added with `ao adm cluster add`, which are kept when applying a cluster catalogue
+	UserDefined bool `json:"userDefined,omitempty"`
 }
 
 // ServiceURLPatterns contains url patterns for all integrations made with AO.
@@ -57,6 +64,14 @@ type ServiceURLs struct {
 	GoboURL         string
 }
 
+// HTTPConfig holds the timeouts, as durations like 10s or 2m, and the retries of calls to Boober and Gobo
+type HTTPConfig struct {
+	ConnectTimeout string `json:"connectTimeout,omitempty"`
+	RequestTimeout string `json:"requestTimeout,omitempty"`
+	MaxRetries     *int   `json:"maxRetries,omitempty"`
+	RetryBudget    *int   `json:"retryBudget,omitempty"`
+}
+
 // AOConfig is a structure of the configuration of ao
 type AOConfig struct {
 	RefName     string              `json:"refName"`
@@ -71,12 +86,33 @@ type AOConfig struct {
 	AvailableClusters       []string `json:"availableClusters"`
 	PreferredAPIClusters    []string `json:"preferredApiClusters"`
 	AvailableUpdateClusters []string `json:"availableUpdateClusters"`
-	ClusterURLPattern       string   `json:"clusterUrlPattern"`
-	BooberURLPattern        string   `json:"booberUrlPattern"`
-	UpdateURLPattern        string   `json:"updateUrlPattern"`
-	GoboURLPattern          string   `json:"goboUrlPattern"`
 
+	// Deprecated: moved to ServiceURLPatterns by migration to schema version 1
+	ClusterURLPattern string `json:"clusterUrlPattern,omitempty"`
+	BooberURLPattern  string `json:"booberUrlPattern,omitempty"`
+	UpdateURLPattern  string `json:"updateUrlPattern,omitempty"`
+	GoboURLPattern    string `json:"goboUrlPattern,omitempty"`
+
+	CurrentContext string              `json:"currentContext,omitempty"`
+	Contexts       map[string]*Context `json:"contexts,omitempty"`
+
+	CredentialStore *CredentialStoreConfig `json:"credentialStore,omitempty"`
+	Probe           *ProbeConfig           `json:"probe,omitempty"`
+	HTTP            *HTTPConfig            `json:"http,omitempty"`
+	UpdateChannel   string                 `json:"updateChannel,omitempty"`
+
+	SchemaVersion int    `json:"schemaVersion"`
 	FileAOVersion string `json:"aoVersion"` // For detecting possible changes to saved file
+
+	activeContext string
+	settings      map[string]Setting
+	overrides     map[string]string
+
+	// tokenReferences are the tokens in the credential store the config file refers to
+	tokenReferences map[string]bool
+	// resolvedTokens are the tokens read from the credential store, by key
+	resolvedTokens map[string]string
+	staleTokens    *staleTokens
 }
 
 // DefaultAOConfig is an AOConfig with default values
@@ -178,8 +214,30 @@ func formatNonLocalhostPattern(pattern string, a ...interface{}) string {
 	return fmt.Sprintf(pattern, a...)
 }
 
-// LoadConfigFile loads an AOConfig file from file system
+// LoadConfigFile loads an AOConfig file from file system.
+// If the file can not be parsed, the backup from the last successful write is restored.
 func LoadConfigFile(configLocation string) (*AOConfig, error) {
+	c, err := parseConfigFile(configLocation)
+	if err == nil || os.IsNotExist(err) {
+		return c, err
+	}
+
+	backup := configLocation + ".bak"
+	restored, backupErr := parseConfigFile(backup)
+	if backupErr != nil {
+		return nil, err
+	}
+
+	logrus.Warnf("Could not parse %s, restoring backup %s: %s", configLocation, backup, err)
+	if data, readErr := ioutil.ReadFile(backup); readErr == nil {
+		if writeErr := writeFileAtomic(configLocation, data); writeErr != nil {
+			logrus.Warnf("Could not restore %s: %s", configLocation, writeErr)
+		}
+	}
+	return restored, nil
+}
+
+func parseConfigFile(configLocation string) (*AOConfig, error) {
 	raw, err := ioutil.ReadFile(configLocation)
 	if err != nil {
 		return nil, err
@@ -190,23 +248,73 @@ func LoadConfigFile(configLocation string) (*AOConfig, error) {
 	if err != nil {
 		return nil, err
 	}
+	if c == nil {
+		return nil, errors.Errorf("%s is empty", configLocation)
+	}
+	c.tokenReferences = c.referencedTokens()
 
 	return c, nil
 }
 
-// WriteConfig writes an AOConfig file to file system
+// WriteConfig writes an AOConfig file to file system. The file is replaced atomically,
+// and the previous file is kept as a backup if it is valid.
 func WriteConfig(ao AOConfig, configLocation string) error {
-	data, err := json.MarshalIndent(ao, "", "  ")
+	if CI {
+		return ErrCIConfigReadOnly
+	}
+	ao.restoreOverrides()
+	ao.StoreActiveContext()
+	written := ao.copyForWrite()
+	referenced, err := written.storeTokens()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(written, "", "  ")
 	if err != nil {
 		return fmt.Errorf("While marshaling ao config: %w", err)
 	}
-	if err := ioutil.WriteFile(configLocation, data, 0644); err != nil {
+
+	if previous, err := ioutil.ReadFile(configLocation); err == nil && json.Valid(previous) {
+		if err := writeFileAtomic(configLocation+".bak", previous); err != nil {
+			logrus.Warnf("Could not write backup of ao config: %s", err)
+		}
+	}
+	if err := writeFileAtomic(configLocation, data); err != nil {
 		return fmt.Errorf("While writing ao config to file: %w", err)
 	}
+	ao.staleTokens.erase()
+	ao.eraseUnreferencedTokens(referenced)
 
 	return nil
 }
 
+// writeFileAtomic writes data to a temporary file in the same directory and renames it to file,
+// so that readers never see a partially written file
+func writeFileAtomic(file string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
 // SelectAPICluster returns specified APICluster or makes a priority based selection of an APICluster
 func (ao *AOConfig) SelectAPICluster() {
 	if ao.APICluster != "" {
@@ -240,7 +348,7 @@ func (ao *AOConfig) Update(noPrompt bool) error {
 		return err
 	}
 
-	serverVersion, err := GetCurrentVersionFromServer(url)
+	serverVersion, err := GetCurrentVersionFromServer(url, ao.Channel())
 	if err != nil {
 		return err
 	}
@@ -249,6 +357,9 @@ func (ao *AOConfig) Update(noPrompt bool) error {
 		return errors.New("No update available")
 	}
 
+	if !noPrompt && CI {
+		return errors.New("ao update asks for confirmation, use --no-prompt in CI mode")
+	}
 	if !noPrompt {
 		if runtime.GOOS == "windows" {
 			message := fmt.Sprintf("New version of AO is available (%s) - please download from %s", serverVersion.Version, url)
@@ -262,43 +373,20 @@ func (ao *AOConfig) Update(noPrompt bool) error {
 		}
 	}
 
-	data, err := GetNewAOClient(url)
+	data, err := GetNewAOClient(url, ao.Channel())
 	if err != nil {
 		return err
 	}
 
-	err = ao.replaceAO(data)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (ao *AOConfig) replaceAO(data []byte) error {
 	executablePath, err := os.Executable()
 	if err != nil {
 		return err
 	}
-
-	var releasePath string
-	// First, we try to write the update to a file in the executable path
-	releasePath = executablePath + "_" + "update"
-	err = ioutil.WriteFile(releasePath, data, 0755)
-	if err != nil {
-		// Could not write to executable path, typically because binary is installed in /usr/bin or /usr/local/bin
-		// Try the OS Temp Dir
-		releasePath = filepath.Join(os.TempDir(), "ao_update")
-		err = ioutil.WriteFile(releasePath, data, 0755)
-		if err != nil {
-			return err
-		}
-	}
-	err = os.Rename(releasePath, executablePath)
+	err = replaceExecutable(executablePath, data, serverVersion.Version)
 	if err != nil {
-		err = errors.New("Could not update AO because it is installed in a different file system than temp: " + err.Error())
 		return err
 	}
+
 	return nil
 }
 
@@ -2,9 +2,11 @@ package config
 
 import (
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -29,6 +31,35 @@ func TestLoadConfigFile(t *testing.T) {
 	assert.Equal(t, "paas", ao.Affiliation)
 }
 
+func TestLoadConfigFile_RestoresBackup(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao-config")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	configLocation := filepath.Join(dir, ".ao.json")
+
+	assert.NoError(t, WriteConfig(AOConfig{Affiliation: "paas"}, configLocation))
+	assert.NoError(t, WriteConfig(AOConfig{Affiliation: "sales"}, configLocation))
+
+	info, err := os.Stat(configLocation)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	assert.NoError(t, ioutil.WriteFile(configLocation, []byte(`{"affiliation": "sa`), 0600))
+
+	ao, err := LoadConfigFile(configLocation)
+	assert.NoError(t, err)
+	assert.Equal(t, "paas", ao.Affiliation)
+
+	ao, err = LoadConfigFile(configLocation)
+	assert.NoError(t, err)
+	assert.Equal(t, "paas", ao.Affiliation)
+
+	assert.NoError(t, os.Remove(configLocation+".bak"))
+	assert.NoError(t, ioutil.WriteFile(configLocation, []byte(""), 0600))
+	_, err = LoadConfigFile(configLocation)
+	assert.Error(t, err)
+}
+
 func TestAOConfig_SelectApiCluster(t *testing.T) {
 	tests := []struct {
 		Clusters map[string]bool
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const aoClusterCataloguePath = "/assets/clusters.json"
+
+// ClusterCatalogue is the signed list of clusters published on the update server, next to version.json.
+// It lets ao pick up new clusters without a new release.
+type ClusterCatalogue struct {
+	AvailableClusters       []string                       `json:"availableClusters"`
+	PreferredAPIClusters    []string                       `json:"preferredApiClusters"`
+	AvailableUpdateClusters []string                       `json:"availableUpdateClusters"`
+	ServiceURLPatterns      map[string]*ServiceURLPatterns `json:"serviceURLPatterns"`
+	ClusterConfig           map[string]*ClusterConfig      `json:"clusterConfig"`
+}
+
+// GetClusterCatalogueFromServer gets the cluster catalogue from the update server and verifies its signature
+func GetClusterCatalogueFromServer(url string) (*ClusterCatalogue, error) {
+	data, err := fetchSignedFromUpdateServer(url, aoClusterCataloguePath, "application/json")
+	if err != nil {
+		return nil, err
+	}
+
+	var catalogue ClusterCatalogue
+	if err := json.Unmarshal(data, &catalogue); err != nil {
+		return nil, errors.Wrap(err, "could not parse cluster catalogue")
+	}
+	if err := catalogue.validate(); err != nil {
+		return nil, err
+	}
+	return &catalogue, nil
+}
+
+func (c *ClusterCatalogue) validate() error {
+	if len(c.AvailableClusters) == 0 {
+		return errors.New("cluster catalogue has no clusters")
+	}
+	for _, name := range c.AvailableClusters {
+		clusterConfig := c.ClusterConfig[name]
+		if clusterConfig == nil || clusterConfig.Type == "" {
+			return errors.Errorf("cluster catalogue is missing cluster type for cluster %s", name)
+		}
+		if _, found := c.ServiceURLPatterns[clusterConfig.Type]; !found {
+			return errors.Errorf("cluster catalogue is missing url patterns for cluster type %s", clusterConfig.Type)
+		}
+	}
+	return nil
+}
+
+// ApplyClusterCatalogue replaces the built-in clusters, or the clusters from an earlier catalogue, with the clusters
+// in the catalogue. Clusters added with `ao adm cluster add` and their url patterns are kept.
+// Returns true if the clusters were changed.
+func (ao *AOConfig) ApplyClusterCatalogue(catalogue *ClusterCatalogue) bool {
+	userDefined := func(name string) bool {
+		clusterConfig := ao.ClusterConfig[name]
+		return clusterConfig != nil && clusterConfig.UserDefined && !containsString(catalogue.AvailableClusters, name)
+	}
+	withUserDefined := func(clusters, existing []string) []string {
+		result := append([]string{}, clusters...)
+		for _, name := range existing {
+			if userDefined(name) {
+				result = append(result, name)
+			}
+		}
+		return result
+	}
+
+	availableClusters := withUserDefined(catalogue.AvailableClusters, ao.AvailableClusters)
+	preferredAPIClusters := withUserDefined(catalogue.PreferredAPIClusters, ao.PreferredAPIClusters)
+	availableUpdateClusters := withUserDefined(catalogue.AvailableUpdateClusters, ao.AvailableUpdateClusters)
+
+	clusterConfig := make(map[string]*ClusterConfig)
+	for name, config := range catalogue.ClusterConfig {
+		clusterConfig[name] = config
+	}
+	for _, name := range ao.AvailableClusters {
+		if userDefined(name) {
+			clusterConfig[name] = ao.ClusterConfig[name]
+		}
+	}
+
+	serviceURLPatterns := make(map[string]*ServiceURLPatterns)
+	for clusterType, patterns := range ao.ServiceURLPatterns {
+		serviceURLPatterns[clusterType] = patterns
+	}
+	for clusterType, patterns := range catalogue.ServiceURLPatterns {
+		serviceURLPatterns[clusterType] = patterns
+	}
+
+	changed := !reflect.DeepEqual(availableClusters, ao.AvailableClusters) ||
+		!reflect.DeepEqual(preferredAPIClusters, ao.PreferredAPIClusters) ||
+		!reflect.DeepEqual(availableUpdateClusters, ao.AvailableUpdateClusters) ||
+		!reflect.DeepEqual(clusterConfig, ao.ClusterConfig) ||
+		!reflect.DeepEqual(serviceURLPatterns, ao.ServiceURLPatterns)
+
+	ao.AvailableClusters = availableClusters
+	ao.PreferredAPIClusters = preferredAPIClusters
+	ao.AvailableUpdateClusters = availableUpdateClusters
+	ao.ClusterConfig = clusterConfig
+	ao.ServiceURLPatterns = serviceURLPatterns
+
+	return changed
+}
+
+// updateClusterCatalogue applies the cluster catalogue from the update server, if available.
+// The current clusters are kept if there is no reachable update server or the catalogue can not be verified.
+func (ao *AOConfig) updateClusterCatalogue() bool {
+	url, err := ao.getUpdateURL()
+	if err != nil {
+		logrus.Debugf("No cluster catalogue, using built-in cluster list: %s", err)
+		return false
+	}
+
+	catalogue, err := GetClusterCatalogueFromServer(url)
+	if err != nil {
+		logrus.Warnf("Could not get cluster catalogue, using built-in cluster list: %s", err)
+		return false
+	}
+
+	return ao.ApplyClusterCatalogue(catalogue)
+}
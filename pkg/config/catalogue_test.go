@@ -0,0 +1,153 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newSigningKey(t *testing.T) ed25519.PrivateKey {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	previous := UpdatePublicKey
+	UpdatePublicKey = base64.StdEncoding.EncodeToString(publicKey)
+	t.Cleanup(func() { UpdatePublicKey = previous })
+	return privateKey
+}
+
+func newCatalogueServer(t *testing.T, catalogue *ClusterCatalogue, privateKey ed25519.PrivateKey) *httptest.Server {
+	data, err := json.Marshal(catalogue)
+	assert.NoError(t, err)
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, data))
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case aoClusterCataloguePath:
+			w.Write(data)
+		case aoClusterCataloguePath + signatureSuffix:
+			w.Write([]byte(signature))
+		}
+	}))
+}
+
+func newTestCatalogue(url string) *ClusterCatalogue {
+	return &ClusterCatalogue{
+		AvailableClusters:       []string{"utv", "utv05"},
+		PreferredAPIClusters:    []string{"utv05"},
+		AvailableUpdateClusters: []string{"utv"},
+		ServiceURLPatterns: map[string]*ServiceURLPatterns{
+			"local": {
+				ClusterURLPattern: url + "/%s/cluster",
+				BooberURLPattern:  url + "/%s/boober",
+				GoboURLPattern:    url + "/%s/gobo",
+				UpdateURLPattern:  url + "/%s/update",
+			},
+		},
+		ClusterConfig: map[string]*ClusterConfig{
+			"utv":   {Type: "local"},
+			"utv05": {Type: "local"},
+		},
+	}
+}
+
+func TestGetClusterCatalogueFromServer(t *testing.T) {
+	privateKey := newSigningKey(t)
+	catalogue := newTestCatalogue("http://localhost")
+	server := newCatalogueServer(t, catalogue, privateKey)
+	defer server.Close()
+
+	fetched, err := GetClusterCatalogueFromServer(server.URL)
+	assert.NoError(t, err)
+	assert.Equal(t, catalogue, fetched)
+
+	_, otherKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	forged := newCatalogueServer(t, catalogue, otherKey)
+	defer forged.Close()
+
+	_, err = GetClusterCatalogueFromServer(forged.URL)
+	assert.Error(t, err)
+
+	UpdatePublicKey = ""
+	_, err = GetClusterCatalogueFromServer(server.URL)
+	assert.Error(t, err)
+}
+
+func TestClusterCatalogue_Validate(t *testing.T) {
+	catalogue := newTestCatalogue("http://localhost")
+	assert.NoError(t, catalogue.validate())
+
+	catalogue.ClusterConfig["utv05"].Type = "ocp4"
+	assert.Error(t, catalogue.validate())
+
+	assert.Error(t, (&ClusterCatalogue{}).validate())
+}
+
+func TestAOConfig_ApplyClusterCatalogue(t *testing.T) {
+	ao := &AOConfig{
+		AvailableClusters:       []string{"utv", "test", "private"},
+		PreferredAPIClusters:    []string{"test", "private"},
+		AvailableUpdateClusters: []string{"test"},
+		ServiceURLPatterns: map[string]*ServiceURLPatterns{
+			"ocp3":    ocp3URLPatterns,
+			"private": {BooberURLPattern: "http://boober.%s.example.com"},
+		},
+		ClusterConfig: map[string]*ClusterConfig{
+			"utv":     {Type: "ocp3"},
+			"test":    {Type: "ocp3"},
+			"private": {Type: "private", UserDefined: true},
+		},
+	}
+	catalogue := newTestCatalogue("http://localhost")
+
+	assert.True(t, ao.ApplyClusterCatalogue(catalogue))
+	assert.Equal(t, []string{"utv", "utv05", "private"}, ao.AvailableClusters)
+	assert.Equal(t, []string{"utv05", "private"}, ao.PreferredAPIClusters)
+	assert.Equal(t, []string{"utv"}, ao.AvailableUpdateClusters)
+	assert.Equal(t, []string{"local", "ocp3", "private"}, ao.ClusterTypes())
+	assert.Equal(t, "local", ao.ClusterConfig["utv"].Type)
+	assert.NotContains(t, ao.ClusterConfig, "test")
+	assert.True(t, ao.ClusterConfig["private"].UserDefined)
+
+	assert.False(t, ao.ApplyClusterCatalogue(catalogue))
+}
+
+func TestAOConfig_InitClustersWithCatalogue(t *testing.T) {
+	privateKey := newSigningKey(t)
+
+	var data, signature []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/utv/update" + aoClusterCataloguePath:
+			w.Write(data)
+		case "/utv/update" + aoClusterCataloguePath + signatureSuffix:
+			w.Write(signature)
+		}
+	}))
+	defer server.Close()
+
+	catalogue := newTestCatalogue(server.URL)
+	data, _ = json.Marshal(catalogue)
+	signature = []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, data)))
+
+	ao := &AOConfig{
+		AvailableClusters:       []string{"utv"},
+		AvailableUpdateClusters: []string{"utv"},
+		ServiceURLPatterns:      catalogue.ServiceURLPatterns,
+		ClusterConfig: map[string]*ClusterConfig{
+			"utv": {Type: "local"},
+		},
+	}
+
+	ao.InitClusters()
+	assert.Equal(t, []string{"utv", "utv05"}, ao.AvailableClusters)
+	assert.Contains(t, ao.Clusters, "utv05")
+	assert.True(t, ao.Clusters["utv05"].Reachable)
+}
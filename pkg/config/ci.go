@@ -0,0 +1,69 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Environment variables for CI mode
+const (
+	EnvCI       = "AO_CI"
+	EnvTokenDir = "AO_TOKEN_DIR"
+	// EnvClusterTokenPrefix is followed by the cluster name in upper case, with - replaced by _, e.g. AO_TOKEN_UTV04
+	EnvClusterTokenPrefix = "AO_TOKEN_"
+)
+
+// CI is set when ao runs non-interactively, e.g. in a build pipeline. ao never prompts and never writes
+// the config file in CI mode, and the tokens are only taken from the environment.
+var CI bool
+
+// ErrCIConfigReadOnly is returned when writing the config file in CI mode
+var ErrCIConfigReadOnly = errors.New("the ao config is not written in CI mode")
+
+// CIFromEnvironment returns true if AO_CI is set to a true value
+func CIFromEnvironment(getenv func(string) string) bool {
+	switch strings.ToLower(getenv(EnvCI)) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
+
+// ClusterTokenEnv returns the name of the environment variable holding the token of a cluster in CI mode
+func ClusterTokenEnv(clusterName string) string {
+	return EnvClusterTokenPrefix + strings.ToUpper(strings.Replace(clusterName, "-", "_", -1))
+}
+
+// ApplyCITokens replaces the tokens of all clusters with tokens from AO_TOKEN_<CLUSTER>, or from a file named
+// after the cluster in the directory given by AO_TOKEN_DIR. Clusters without such a token get no token.
+func (ao *AOConfig) ApplyCITokens(getenv func(string) string) error {
+	tokenDir := getenv(EnvTokenDir)
+	if tokenDir != "" {
+		if info, err := os.Stat(tokenDir); err != nil || !info.IsDir() {
+			return errors.Errorf("%s=%s is not a directory", EnvTokenDir, tokenDir)
+		}
+	}
+
+	for name, cluster := range ao.Clusters {
+		cluster.Token = getenv(ClusterTokenEnv(name))
+		cluster.Username = ""
+		cluster.TokenExpiry = nil
+		if cluster.Token != "" || tokenDir == "" {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(tokenDir, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return errors.Wrapf(err, "could not read token for cluster %s", name)
+		}
+		cluster.Token = strings.TrimSpace(string(data))
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCIFromEnvironment(t *testing.T) {
+	env := map[string]string{}
+	getenv := func(key string) string { return env[key] }
+	assert.False(t, CIFromEnvironment(getenv))
+
+	env[EnvCI] = "1"
+	assert.True(t, CIFromEnvironment(getenv))
+	env[EnvCI] = "TRUE"
+	assert.True(t, CIFromEnvironment(getenv))
+	env[EnvCI] = "0"
+	assert.False(t, CIFromEnvironment(getenv))
+}
+
+func TestClusterTokenEnv(t *testing.T) {
+	assert.Equal(t, "AO_TOKEN_UTV04", ClusterTokenEnv("utv04"))
+	assert.Equal(t, "AO_TOKEN_PROD_EAST", ClusterTokenEnv("prod-east"))
+}
+
+func TestAOConfig_ApplyCITokens(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao-tokens")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "test"), []byte("test-file-token\n"), 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "utv"), []byte("utv-file-token\n"), 0600))
+
+	env := map[string]string{
+		"AO_TOKEN_UTV": "utv-env-token",
+		EnvTokenDir:    dir,
+	}
+	ao := newContextTestConfig()
+	ao.Clusters["prod"] = &Cluster{Name: "prod", Token: "prod-token"}
+
+	assert.NoError(t, ao.ApplyCITokens(func(key string) string { return env[key] }))
+	assert.Equal(t, "utv-env-token", ao.Clusters["utv"].Token)
+	assert.Equal(t, "test-file-token", ao.Clusters["test"].Token)
+	assert.Empty(t, ao.Clusters["prod"].Token)
+
+	env[EnvTokenDir] = filepath.Join(dir, "missing")
+	assert.Error(t, ao.ApplyCITokens(func(key string) string { return env[key] }))
+}
+
+func TestWriteConfig_NotInCI(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao-config")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	CI = true
+	defer func() { CI = false }()
+
+	configLocation := filepath.Join(dir, ".ao.json")
+	assert.Equal(t, ErrCIConfigReadOnly, WriteConfig(*newContextTestConfig(), configLocation))
+	_, err = os.Stat(configLocation)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,210 @@
+package config
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ClusterOptions are the settings of a user-defined cluster
+type ClusterOptions struct {
+	Type             string
+	ClusterURLPrefix string
+	APICluster       bool
+	UpdateCluster    bool
+}
+
+// AddCluster adds a cluster of a type with service url patterns, and checks if it is reachable.
+// A cluster preferred as API cluster gets the highest priority, and becomes the API cluster if it is reachable.
+func (ao *AOConfig) AddCluster(name string, options ClusterOptions) (*Cluster, error) {
+	if name == "" {
+		return nil, errors.New("cluster name can not be empty")
+	}
+	if containsString(ao.AvailableClusters, name) {
+		return nil, errors.Errorf("cluster %s already exists", name)
+	}
+	if _, found := ao.ServiceURLPatterns[options.Type]; !found {
+		return nil, errors.Errorf("unknown cluster type %s, available types are %v", options.Type, ao.ClusterTypes())
+	}
+
+	if ao.ClusterConfig == nil {
+		ao.ClusterConfig = make(map[string]*ClusterConfig)
+	}
+	ao.ClusterConfig[name] = &ClusterConfig{
+		Type:             options.Type,
+		IsAPICluster:     options.APICluster,
+		IsUpdateCluster:  options.UpdateCluster,
+		ClusterURLPrefix: options.ClusterURLPrefix,
+		UserDefined:      true,
+	}
+	ao.AvailableClusters = append(ao.AvailableClusters, name)
+	if options.APICluster {
+		ao.PreferredAPIClusters = append([]string{name}, ao.PreferredAPIClusters...)
+	}
+	if options.UpdateCluster {
+		ao.AvailableUpdateClusters = append(ao.AvailableUpdateClusters, name)
+	}
+
+	cluster, err := ao.refreshCluster(name)
+	if err != nil {
+		return nil, err
+	}
+	if options.APICluster {
+		ao.APICluster = ""
+		ao.SelectAPICluster()
+	}
+	return cluster, nil
+}
+
+// RemoveCluster removes a cluster, its configuration and its tokens. The tokens are erased from the credential
+// store when the config is written. A new API cluster is selected if it was the API cluster.
+func (ao *AOConfig) RemoveCluster(name string) error {
+	if !containsString(ao.AvailableClusters, name) {
+		return errors.Errorf("cluster %s does not exist", name)
+	}
+
+	ao.AvailableClusters = removeString(ao.AvailableClusters, name)
+	ao.PreferredAPIClusters = removeString(ao.PreferredAPIClusters, name)
+	ao.AvailableUpdateClusters = removeString(ao.AvailableUpdateClusters, name)
+	delete(ao.ClusterConfig, name)
+	delete(ao.Clusters, name)
+
+	ao.eraseTokenOnWrite("cluster/" + name)
+	for contextName, context := range ao.Contexts {
+		if _, found := context.Tokens[name]; found {
+			ao.eraseTokenOnWrite("context/" + contextName + "/" + name)
+			delete(context.Tokens, name)
+		}
+	}
+
+	if ao.APICluster == name {
+		ao.APICluster = ""
+		ao.SelectAPICluster()
+	}
+	return nil
+}
+
+// SetClusterType changes the type of a cluster, and checks if it is reachable with the new service urls
+func (ao *AOConfig) SetClusterType(name, clusterType string) (*Cluster, error) {
+	if !containsString(ao.AvailableClusters, name) {
+		return nil, errors.Errorf("cluster %s does not exist", name)
+	}
+	if _, found := ao.ServiceURLPatterns[clusterType]; !found {
+		return nil, errors.Errorf("unknown cluster type %s, available types are %v", clusterType, ao.ClusterTypes())
+	}
+
+	if ao.ClusterConfig == nil {
+		ao.ClusterConfig = make(map[string]*ClusterConfig)
+	}
+	clusterConfig, found := ao.ClusterConfig[name]
+	if !found {
+		clusterConfig = &ClusterConfig{}
+		ao.ClusterConfig[name] = clusterConfig
+	}
+	clusterConfig.Type = clusterType
+
+	return ao.refreshCluster(name)
+}
+
+// AddURLPattern adds service url patterns for a cluster type. %s in a pattern is replaced by the cluster name.
+func (ao *AOConfig) AddURLPattern(clusterType string, patterns ServiceURLPatterns) error {
+	if clusterType == "" {
+		return errors.New("cluster type can not be empty")
+	}
+	if _, found := ao.ServiceURLPatterns[clusterType]; found {
+		return errors.Errorf("url patterns for cluster type %s already exist", clusterType)
+	}
+
+	required := map[string]string{
+		"cluster": patterns.ClusterURLPattern,
+		"boober":  patterns.BooberURLPattern,
+		"gobo":    patterns.GoboURLPattern,
+	}
+	optional := map[string]string{
+		"cluster login": patterns.ClusterLoginURLPattern,
+		"update":        patterns.UpdateURLPattern,
+	}
+	for name, pattern := range required {
+		if pattern == "" {
+			return errors.Errorf("missing %s url pattern", name)
+		}
+		optional[name] = pattern
+	}
+	for name, pattern := range optional {
+		if pattern != "" && !strings.Contains(pattern, "%s") && !strings.Contains(pattern, "localhost") {
+			return errors.Errorf("%s url pattern %s must contain %%s, which is replaced by the cluster name", name, pattern)
+		}
+	}
+
+	if ao.ServiceURLPatterns == nil {
+		ao.ServiceURLPatterns = make(map[string]*ServiceURLPatterns)
+	}
+	ao.ServiceURLPatterns[clusterType] = &patterns
+	return nil
+}
+
+// ClusterTypes returns the sorted names of cluster types with service url patterns
+func (ao *AOConfig) ClusterTypes() []string {
+	types := make([]string, 0, len(ao.ServiceURLPatterns))
+	for clusterType := range ao.ServiceURLPatterns {
+		types = append(types, clusterType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// TestCluster checks that the endpoints of a cluster are reachable, with the same checks as InitClusters
+func (ao *AOConfig) TestCluster(name string) ([]EndpointStatus, error) {
+	if !containsString(ao.AvailableClusters, name) {
+		return nil, errors.Errorf("cluster %s does not exist", name)
+	}
+	urls, err := ao.GetServiceURLs(name)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := ao.TLSConfig(name)
+	if err != nil {
+		return nil, err
+	}
+	return ao.newProber().checkEndpoints(urls, tlsConfig), nil
+}
+
+// refreshCluster recreates a cluster from its service urls, keeping its token and what is known about it
+func (ao *AOConfig) refreshCluster(name string) (*Cluster, error) {
+	urls, err := ao.GetServiceURLs(name)
+	if err != nil {
+		return nil, err
+	}
+
+	cluster := ao.newProber().newCluster(name, urls)
+	if ao.Clusters == nil {
+		ao.Clusters = make(map[string]*Cluster)
+	}
+	if existing, found := ao.Clusters[name]; found {
+		cluster.Token = existing.Token
+		cluster.Username = existing.Username
+		cluster.TokenExpiry = existing.TokenExpiry
+	}
+	ao.Clusters[name] = cluster
+	return cluster, nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(list []string, value string) []string {
+	var result []string
+	for _, v := range list {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}
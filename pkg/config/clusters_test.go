@@ -0,0 +1,158 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newClustersTestConfig(url string) *AOConfig {
+	return &AOConfig{
+		APICluster:           "utv",
+		AvailableClusters:    []string{"utv"},
+		PreferredAPIClusters: []string{"utv"},
+		Clusters: map[string]*Cluster{
+			"utv": {Name: "utv", Token: "utv-token", Reachable: true},
+		},
+		ServiceURLPatterns: map[string]*ServiceURLPatterns{
+			"local": {
+				ClusterURLPattern: url + "/%s/cluster",
+				BooberURLPattern:  url + "/%s/boober",
+				GoboURLPattern:    url + "/%s/gobo",
+			},
+		},
+		ClusterConfig: map[string]*ClusterConfig{
+			"utv": {Type: "local"},
+		},
+	}
+}
+
+func newClustersTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken/gobo" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+}
+
+func TestAOConfig_AddCluster(t *testing.T) {
+	server := newClustersTestServer()
+	defer server.Close()
+	ao := newClustersTestConfig(server.URL)
+
+	cluster, err := ao.AddCluster("private", ClusterOptions{Type: "local", APICluster: true})
+	assert.NoError(t, err)
+	assert.True(t, cluster.Reachable)
+	assert.Equal(t, server.URL+"/private/boober", cluster.BooberURL)
+	assert.Equal(t, []string{"utv", "private"}, ao.AvailableClusters)
+	assert.Equal(t, []string{"private", "utv"}, ao.PreferredAPIClusters)
+	assert.Equal(t, "private", ao.APICluster)
+	assert.Equal(t, &ClusterConfig{Type: "local", IsAPICluster: true, UserDefined: true}, ao.ClusterConfig["private"])
+
+	_, err = ao.AddCluster("private", ClusterOptions{Type: "local"})
+	assert.Error(t, err)
+
+	_, err = ao.AddCluster("other", ClusterOptions{Type: "ocp4"})
+	assert.Error(t, err)
+
+	cluster, err = ao.AddCluster("broken", ClusterOptions{Type: "local", APICluster: true})
+	assert.NoError(t, err)
+	assert.False(t, cluster.Reachable)
+	assert.Equal(t, "private", ao.APICluster, "an unreachable cluster should not become the API cluster")
+}
+
+func TestAOConfig_RemoveCluster(t *testing.T) {
+	server := newClustersTestServer()
+	defer server.Close()
+	ao := newClustersTestConfig(server.URL)
+	_, err := ao.AddCluster("private", ClusterOptions{Type: "local"})
+	assert.NoError(t, err)
+
+	assert.NoError(t, ao.RemoveCluster("utv"))
+	assert.Equal(t, []string{"private"}, ao.AvailableClusters)
+	assert.Empty(t, ao.PreferredAPIClusters)
+	assert.NotContains(t, ao.ClusterConfig, "utv")
+	assert.NotContains(t, ao.Clusters, "utv")
+	assert.Equal(t, "private", ao.APICluster)
+
+	assert.Error(t, ao.RemoveCluster("utv"))
+}
+
+func TestAOConfig_SetClusterType(t *testing.T) {
+	server := newClustersTestServer()
+	defer server.Close()
+	ao := newClustersTestConfig(server.URL)
+	assert.NoError(t, ao.AddURLPattern("other", ServiceURLPatterns{
+		ClusterURLPattern: server.URL + "/other/%s",
+		BooberURLPattern:  server.URL + "/other/%s/boober",
+		GoboURLPattern:    server.URL + "/other/%s/gobo",
+	}))
+
+	cluster, err := ao.SetClusterType("utv", "other")
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL+"/other/utv/boober", cluster.BooberURL)
+	assert.Equal(t, "utv-token", cluster.Token)
+	assert.Equal(t, "other", ao.ClusterConfig["utv"].Type)
+
+	_, err = ao.SetClusterType("utv", "unknown")
+	assert.Error(t, err)
+	_, err = ao.SetClusterType("unknown", "other")
+	assert.Error(t, err)
+}
+
+func TestAOConfig_AddURLPattern(t *testing.T) {
+	ao := &AOConfig{}
+
+	err := ao.AddURLPattern("private", ServiceURLPatterns{
+		ClusterURLPattern: "https://api.%s.example.com:6443",
+		BooberURLPattern:  "https://boober.apps.%s.example.com",
+	})
+	assert.EqualError(t, err, "missing gobo url pattern")
+
+	err = ao.AddURLPattern("private", ServiceURLPatterns{
+		ClusterURLPattern: "https://api.%s.example.com:6443",
+		BooberURLPattern:  "https://boober.apps.example.com",
+		GoboURLPattern:    "https://gobo.apps.%s.example.com",
+	})
+	assert.Error(t, err)
+
+	err = ao.AddURLPattern("private", ServiceURLPatterns{
+		ClusterURLPattern: "https://api.%s.example.com:6443",
+		BooberURLPattern:  "http://localhost:8080",
+		GoboURLPattern:    "https://gobo.apps.%s.example.com",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"private"}, ao.ClusterTypes())
+
+	err = ao.AddURLPattern("private", ServiceURLPatterns{})
+	assert.Error(t, err)
+}
+
+func TestAOConfig_TestCluster(t *testing.T) {
+	server := newClustersTestServer()
+	defer server.Close()
+	ao := newClustersTestConfig(server.URL)
+	ao.AvailableClusters = append(ao.AvailableClusters, "broken")
+	ao.ClusterConfig["broken"] = &ClusterConfig{Type: "local"}
+
+	checks, err := ao.TestCluster("utv")
+	assert.NoError(t, err)
+	assert.Len(t, checks, 3)
+	for _, check := range checks {
+		assert.True(t, check.OK())
+		assert.Equal(t, http.StatusOK, check.StatusCode)
+	}
+
+	checks, err = ao.TestCluster("broken")
+	assert.NoError(t, err)
+	assert.Equal(t, EndpointGobo, checks[1].Endpoint)
+	assert.False(t, checks[1].OK())
+	assert.Equal(t, ErrorTypeHTTP, checks[1].ErrorType)
+	assert.Equal(t, http.StatusBadGateway, checks[1].StatusCode)
+	assert.True(t, checks[0].OK())
+
+	_, err = ao.TestCluster("unknown")
+	assert.Error(t, err)
+}
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultContextName is the name of the context holding the settings in use when the first context is created
+const DefaultContextName = "default"
+
+// Context is a named set of affiliation, ref, api cluster and tokens, like a kube context.
+type Context struct {
+	Affiliation string            `json:"affiliation"`
+	RefName     string            `json:"refName"`
+	APICluster  string            `json:"apiCluster"`
+	Tokens      map[string]string `json:"tokens"`
+}
+
+// ContextNames returns the sorted names of all contexts
+func (ao *AOConfig) ContextNames() []string {
+	names := make([]string, 0, len(ao.Contexts))
+	for name := range ao.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ActiveContext returns the name of the context in use, which is either given by ActivateContext or the current context
+func (ao *AOConfig) ActiveContext() string {
+	if ao.activeContext != "" {
+		return ao.activeContext
+	}
+	return ao.CurrentContext
+}
+
+// ActivateContext loads a context for this run of ao, without changing the current context.
+// An empty name activates the current context, if any.
+func (ao *AOConfig) ActivateContext(name string) error {
+	if name == "" {
+		name = ao.CurrentContext
+	}
+	if name == "" {
+		return nil
+	}
+
+	context, found := ao.Contexts[name]
+	if !found {
+		return errors.Errorf("context %s does not exist, available contexts are %v", name, ao.ContextNames())
+	}
+
+	ao.activeContext = name
+	ao.Affiliation = context.Affiliation
+	ao.RefName = context.RefName
+	if context.APICluster != "" {
+		ao.APICluster = context.APICluster
+	}
+	for clusterName, cluster := range ao.Clusters {
+		if token := context.Tokens[clusterName]; token != cluster.Token {
+			cluster.Token = token
+			cluster.Username = ""
+			cluster.TokenExpiry = nil
+		}
+	}
+
+	return nil
+}
+
+// UseContext saves the active context and makes the named context the current context
+func (ao *AOConfig) UseContext(name string) error {
+	ao.StoreActiveContext()
+
+	if err := ao.ActivateContext(name); err != nil {
+		return err
+	}
+	ao.CurrentContext = name
+	ao.activeContext = ""
+
+	return nil
+}
+
+// CreateContext creates a new context. When no contexts exist, the settings in use are
+// first saved to the context named default, which becomes the current context.
+func (ao *AOConfig) CreateContext(name string, context Context) error {
+	if name == "" {
+		return errors.New("context name can not be empty")
+	}
+
+	if len(ao.Contexts) == 0 {
+		ao.Contexts = map[string]*Context{
+			DefaultContextName: {},
+		}
+		ao.CurrentContext = DefaultContextName
+		ao.StoreActiveContext()
+	}
+
+	if _, found := ao.Contexts[name]; found {
+		return errors.Errorf("context %s already exists", name)
+	}
+	if context.Tokens == nil {
+		context.Tokens = make(map[string]string)
+	}
+	ao.Contexts[name] = &context
+
+	return nil
+}
+
+// DeleteContext deletes a context. The current context can not be deleted.
+func (ao *AOConfig) DeleteContext(name string) error {
+	if _, found := ao.Contexts[name]; !found {
+		return errors.Errorf("context %s does not exist", name)
+	}
+	if name == ao.ActiveContext() {
+		return errors.Errorf("context %s is in use, switch to another context before deleting it", name)
+	}
+
+	delete(ao.Contexts, name)
+	return nil
+}
+
+// StoreActiveContext saves affiliation, ref, api cluster and tokens in use to the active context
+func (ao *AOConfig) StoreActiveContext() {
+	name := ao.ActiveContext()
+	if name == "" {
+		return
+	}
+	context, found := ao.Contexts[name]
+	if !found {
+		return
+	}
+
+	context.Affiliation = ao.Affiliation
+	context.RefName = ao.RefName
+	context.APICluster = ao.APICluster
+	context.Tokens = make(map[string]string)
+	for clusterName, cluster := range ao.Clusters {
+		if cluster.Token != "" {
+			context.Tokens[clusterName] = cluster.Token
+		}
+	}
+}
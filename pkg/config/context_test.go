@@ -0,0 +1,91 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newContextTestConfig() *AOConfig {
+	return &AOConfig{
+		Affiliation: "paas",
+		RefName:     "master",
+		APICluster:  "utv",
+		Clusters: map[string]*Cluster{
+			"utv":  {Name: "utv", Token: "utv-token"},
+			"test": {Name: "test", Token: "test-token"},
+		},
+	}
+}
+
+func TestAOConfig_CreateContext(t *testing.T) {
+	ao := newContextTestConfig()
+
+	err := ao.CreateContext("sales", Context{Affiliation: "sales", RefName: "develop", APICluster: "test"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"default", "sales"}, ao.ContextNames())
+	assert.Equal(t, DefaultContextName, ao.CurrentContext)
+	assert.Equal(t, &Context{
+		Affiliation: "paas",
+		RefName:     "master",
+		APICluster:  "utv",
+		Tokens:      map[string]string{"utv": "utv-token", "test": "test-token"},
+	}, ao.Contexts[DefaultContextName])
+
+	err = ao.CreateContext("sales", Context{})
+	assert.Error(t, err)
+}
+
+func TestAOConfig_UseContext(t *testing.T) {
+	ao := newContextTestConfig()
+	assert.NoError(t, ao.CreateContext("sales", Context{Affiliation: "sales", RefName: "develop", APICluster: "test"}))
+
+	assert.NoError(t, ao.UseContext("sales"))
+	assert.Equal(t, "sales", ao.CurrentContext)
+	assert.Equal(t, "sales", ao.Affiliation)
+	assert.Equal(t, "develop", ao.RefName)
+	assert.Equal(t, "test", ao.APICluster)
+	assert.Empty(t, ao.Clusters["utv"].Token)
+
+	ao.Clusters["utv"].Token = "sales-token"
+	assert.NoError(t, ao.UseContext(DefaultContextName))
+	assert.Equal(t, "paas", ao.Affiliation)
+	assert.Equal(t, "utv-token", ao.Clusters["utv"].Token)
+	assert.Equal(t, map[string]string{"utv": "sales-token"}, ao.Contexts["sales"].Tokens)
+
+	assert.Error(t, ao.UseContext("unknown"))
+}
+
+func TestAOConfig_ActivateContext(t *testing.T) {
+	defer os.Remove(configTmpFile)
+
+	ao := newContextTestConfig()
+	assert.NoError(t, ao.CreateContext("sales", Context{Affiliation: "sales", RefName: "master"}))
+
+	assert.NoError(t, ao.ActivateContext("sales"))
+	assert.Equal(t, "sales", ao.ActiveContext())
+	assert.Equal(t, DefaultContextName, ao.CurrentContext)
+	assert.Error(t, ao.DeleteContext("sales"), "Should not delete active context")
+
+	ao.Clusters["test"].Token = "new-sales-token"
+	assert.NoError(t, WriteConfig(*ao, configTmpFile))
+
+	loaded, err := LoadConfigFile(configTmpFile)
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultContextName, loaded.CurrentContext)
+	assert.NoError(t, loaded.ActivateContext(""))
+	assert.Equal(t, "paas", loaded.Affiliation)
+	assert.Equal(t, map[string]string{"test": "new-sales-token"}, loaded.Contexts["sales"].Tokens)
+}
+
+func TestAOConfig_DeleteContext(t *testing.T) {
+	ao := newContextTestConfig()
+	assert.NoError(t, ao.CreateContext("sales", Context{}))
+
+	assert.Error(t, ao.DeleteContext(DefaultContextName), "Should not delete current context")
+	assert.NoError(t, ao.DeleteContext("sales"))
+	assert.Error(t, ao.DeleteContext("sales"))
+	assert.Equal(t, []string{"default"}, ao.ContextNames())
+}
@@ -0,0 +1,545 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/skatteetaten/ao/pkg/prompt"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Types of credential stores
+const (
+	CredentialStorePlaintext = "plaintext"
+	CredentialStoreKeyring   = "keyring"
+	CredentialStoreFile      = "file"
+	CredentialStoreHelper    = "helper"
+)
+
+// EnvCredentialPassphrase holds the passphrase of an encrypted credential file, instead of prompting for it
+const EnvCredentialPassphrase = "AO_CREDENTIAL_PASSPHRASE"
+
+// tokenReferencePrefix marks a token in the config file as a reference to a token in the credential store
+const tokenReferencePrefix = "credential:"
+
+const (
+	pbkdf2Iterations = 200000
+	saltSize         = 16
+	keySize          = 32
+)
+
+// CredentialStoreConfig selects where tokens are stored. Tokens are stored in plaintext in the config file by default.
+type CredentialStoreConfig struct {
+	Type string `json:"type"`
+	// File is the location of the encrypted credential file
+	File string `json:"file,omitempty"`
+	// Helper is the credential helper command, called with get, store or erase like a git credential helper
+	Helper string `json:"helper,omitempty"`
+}
+
+// CredentialStore stores tokens outside of the ao config file
+type CredentialStore interface {
+	Get(key string) (string, error)
+	Store(key, token string) error
+	Erase(key string) error
+}
+
+// NewCredentialStore creates the credential store given by the configuration. Returns nil for plaintext storage.
+func NewCredentialStore(storeConfig *CredentialStoreConfig) (CredentialStore, error) {
+	if storeConfig == nil {
+		return nil, nil
+	}
+
+	switch storeConfig.Type {
+	case "", CredentialStorePlaintext:
+		return nil, nil
+	case CredentialStoreKeyring:
+		return &keyringStore{}, nil
+	case CredentialStoreFile:
+		if storeConfig.File == "" {
+			return nil, errors.New("missing file for encrypted credential store")
+		}
+		return &encryptedFileStore{file: storeConfig.File}, nil
+	case CredentialStoreHelper:
+		if strings.TrimSpace(storeConfig.Helper) == "" {
+			return nil, errors.New("missing command for credential helper")
+		}
+		return &helperStore{command: strings.Fields(storeConfig.Helper)}, nil
+	default:
+		return nil, errors.Errorf("unknown credential store %s, must be one of [%s, %s, %s, %s]", storeConfig.Type,
+			CredentialStorePlaintext, CredentialStoreKeyring, CredentialStoreFile, CredentialStoreHelper)
+	}
+}
+
+// ResolveTokens replaces all token references in the config with tokens from the credential store
+func (ao *AOConfig) ResolveTokens() error {
+	return ao.forEachToken(func(key string, token *string) error {
+		return ao.resolveToken(key, token)
+	})
+}
+
+// ResolveClusterToken replaces the token reference of a cluster with the token from the credential store.
+// Tokens are only read when they are used, since reading them may prompt for a passphrase or start a process.
+func (ao *AOConfig) ResolveClusterToken(name string) error {
+	cluster, found := ao.Clusters[name]
+	if !found {
+		return nil
+	}
+	return ao.resolveToken("cluster/"+name, &cluster.Token)
+}
+
+func (ao *AOConfig) resolveToken(key string, token *string) error {
+	if !strings.HasPrefix(*token, tokenReferencePrefix) {
+		return nil
+	}
+	reference := strings.TrimPrefix(*token, tokenReferencePrefix)
+	if value, found := ao.resolvedTokens[reference]; found {
+		*token = value
+		return nil
+	}
+
+	store, err := NewCredentialStore(ao.CredentialStore)
+	if err != nil {
+		return err
+	}
+	if store == nil {
+		return errors.Errorf("token for %s is stored in a credential store, but no credential store is configured", key)
+	}
+	value, err := store.Get(reference)
+	if err != nil {
+		return errors.Wrapf(err, "could not get token for %s from credential store", key)
+	}
+	if ao.resolvedTokens == nil {
+		ao.resolvedTokens = make(map[string]string)
+	}
+	ao.resolvedTokens[reference] = value
+	*token = value
+	return nil
+}
+
+// referencedTokens returns the keys of the tokens in the credential store that the config refers to
+func (ao *AOConfig) referencedTokens() map[string]bool {
+	referenced := make(map[string]bool)
+	ao.forEachToken(func(key string, token *string) error {
+		if strings.HasPrefix(*token, tokenReferencePrefix) {
+			referenced[strings.TrimPrefix(*token, tokenReferencePrefix)] = true
+		}
+		return nil
+	})
+	return referenced
+}
+
+// SetCredentialStore changes where tokens are stored. Tokens are moved to the new credential store on the next
+// WriteConfig, and erased from the previous credential store once the config file no longer refers to them.
+func (ao *AOConfig) SetCredentialStore(storeConfig *CredentialStoreConfig) error {
+	store, err := NewCredentialStore(storeConfig)
+	if err != nil {
+		return err
+	}
+	if err := ao.ResolveTokens(); err != nil {
+		return err
+	}
+
+	previous, err := NewCredentialStore(ao.CredentialStore)
+	if err == nil && previous != nil && !sameCredentialStore(ao.CredentialStore, storeConfig) {
+		stale := &staleTokens{store: previous}
+		for key := range ao.tokenReferences {
+			stale.keys = append(stale.keys, key)
+		}
+		ao.staleTokens = stale
+	}
+	ao.tokenReferences = make(map[string]bool)
+	ao.resolvedTokens = nil
+
+	if store == nil {
+		ao.CredentialStore = nil
+	} else {
+		ao.CredentialStore = storeConfig
+	}
+	return nil
+}
+
+func sameCredentialStore(a, b *CredentialStoreConfig) bool {
+	return a != nil && b != nil && *a == *b
+}
+
+// staleTokens are tokens left in a previous credential store
+type staleTokens struct {
+	store CredentialStore
+	keys  []string
+}
+
+// erase removes the stale tokens from the previous credential store
+func (s *staleTokens) erase() {
+	if s == nil {
+		return
+	}
+	for _, key := range s.keys {
+		if err := s.store.Erase(key); err != nil {
+			logrus.Warnf("Could not erase token for %s from the previous credential store: %s", key, err)
+		}
+	}
+	s.keys = nil
+}
+
+// HasPlaintextTokens returns true if a credential store is configured and there are tokens not yet moved to it.
+// Tokens are kept in plaintext in the config file until a credential store is chosen with ao adm credential-store.
+func (ao *AOConfig) HasPlaintextTokens() bool {
+	store, err := NewCredentialStore(ao.CredentialStore)
+	if err != nil || store == nil {
+		return false
+	}
+
+	resolved := make(map[string]bool)
+	for _, value := range ao.resolvedTokens {
+		resolved[value] = true
+	}
+	found := false
+	ao.forEachToken(func(key string, token *string) error {
+		if *token != "" && !strings.HasPrefix(*token, tokenReferencePrefix) && !resolved[*token] {
+			found = true
+		}
+		return nil
+	})
+	return found
+}
+
+// storeTokens moves all tokens to the credential store and replaces them with references. Tokens that have not
+// been read from the credential store are kept as references. Returns the keys of the tokens referred to.
+// Must only be called on a copy made by copyForWrite.
+func (ao *AOConfig) storeTokens() (map[string]bool, error) {
+	store, err := NewCredentialStore(ao.CredentialStore)
+	if err != nil || store == nil {
+		return nil, err
+	}
+
+	referenced := make(map[string]bool)
+	err = ao.forEachToken(func(key string, token *string) error {
+		if *token == "" {
+			return nil
+		}
+		if strings.HasPrefix(*token, tokenReferencePrefix) {
+			referenced[strings.TrimPrefix(*token, tokenReferencePrefix)] = true
+			return nil
+		}
+		if value, found := ao.resolvedTokens[key]; !found || value != *token {
+			if err := store.Store(key, *token); err != nil {
+				return errors.Wrapf(err, "could not save token for %s in credential store", key)
+			}
+		}
+		referenced[key] = true
+		*token = tokenReferencePrefix + key
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return referenced, nil
+}
+
+// eraseUnreferencedTokens erases the tokens the config referred to when it was read, but no longer refers to
+func (ao *AOConfig) eraseUnreferencedTokens(referenced map[string]bool) {
+	store, err := NewCredentialStore(ao.CredentialStore)
+	if err != nil || store == nil || ao.tokenReferences == nil {
+		return
+	}
+	for key := range ao.tokenReferences {
+		if referenced[key] {
+			continue
+		}
+		if err := store.Erase(key); err != nil {
+			logrus.Warnf("Could not erase token for %s from the credential store: %s", key, err)
+		}
+		delete(ao.tokenReferences, key)
+	}
+	for key := range referenced {
+		ao.tokenReferences[key] = true
+	}
+}
+
+// eraseTokenOnWrite erases the token with key from the credential store on the next WriteConfig, unless the config
+// still refers to it then
+func (ao *AOConfig) eraseTokenOnWrite(key string) {
+	if ao.tokenReferences == nil {
+		ao.tokenReferences = make(map[string]bool)
+	}
+	ao.tokenReferences[key] = true
+}
+
+// forEachToken calls fn with a key identifying each token in clusters and contexts
+func (ao *AOConfig) forEachToken(fn func(key string, token *string) error) error {
+	for name, cluster := range ao.Clusters {
+		if err := fn("cluster/"+name, &cluster.Token); err != nil {
+			return err
+		}
+	}
+	for contextName, context := range ao.Contexts {
+		for clusterName, token := range context.Tokens {
+			value := token
+			if err := fn("context/"+contextName+"/"+clusterName, &value); err != nil {
+				return err
+			}
+			context.Tokens[clusterName] = value
+		}
+	}
+	return nil
+}
+
+// copyForWrite copies clusters and contexts, so that tokens can be replaced by references without changing the config in use
+func (ao AOConfig) copyForWrite() AOConfig {
+	clusters := make(map[string]*Cluster, len(ao.Clusters))
+	for name, cluster := range ao.Clusters {
+		clusterCopy := *cluster
+		clusters[name] = &clusterCopy
+	}
+	ao.Clusters = clusters
+
+	if ao.Contexts != nil {
+		contexts := make(map[string]*Context, len(ao.Contexts))
+		for name, context := range ao.Contexts {
+			contextCopy := *context
+			contextCopy.Tokens = make(map[string]string, len(context.Tokens))
+			for cluster, token := range context.Tokens {
+				contextCopy.Tokens[cluster] = token
+			}
+			contexts[name] = &contextCopy
+		}
+		ao.Contexts = contexts
+	}
+	return ao
+}
+
+// keyringStore stores tokens in the Secret Service keyring using secret-tool from libsecret
+type keyringStore struct{}
+
+func (s *keyringStore) Get(key string) (string, error) {
+	out, err := exec.Command("secret-tool", "lookup", "service", "ao", "key", key).Output()
+	if err != nil {
+		return "", errors.Wrap(err, "secret-tool lookup failed")
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func (s *keyringStore) Store(key, token string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", "ao "+key, "service", "ao", "key", key)
+	cmd.Stdin = strings.NewReader(token)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Errorf("secret-tool store failed: %s %s", err, out)
+	}
+	return nil
+}
+
+func (s *keyringStore) Erase(key string) error {
+	return exec.Command("secret-tool", "clear", "service", "ao", "key", key).Run()
+}
+
+// helperStore stores tokens with an external credential helper, using the same protocol as git credential helpers.
+// The helper is called with get, store or erase and reads key=<key> and token=<token> lines from standard input.
+// On get it must print token=<token>.
+type helperStore struct {
+	command []string
+}
+
+func (s *helperStore) run(action string, input map[string]string) (map[string]string, error) {
+	var stdin bytes.Buffer
+	for _, name := range []string{"key", "token"} {
+		if value, ok := input[name]; ok {
+			fmt.Fprintf(&stdin, "%s=%s\n", name, value)
+		}
+	}
+	stdin.WriteString("\n")
+
+	args := append(s.command[1:], action)
+	cmd := exec.Command(s.command[0], args...)
+	cmd.Stdin = &stdin
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Wrapf(err, "credential helper %s %s failed", s.command[0], action)
+	}
+
+	output := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		split := strings.SplitN(scanner.Text(), "=", 2)
+		if len(split) == 2 {
+			output[split[0]] = split[1]
+		}
+	}
+	return output, nil
+}
+
+func (s *helperStore) Get(key string) (string, error) {
+	output, err := s.run("get", map[string]string{"key": key})
+	if err != nil {
+		return "", err
+	}
+	token, found := output["token"]
+	if !found {
+		return "", errors.Errorf("credential helper returned no token for %s", key)
+	}
+	return token, nil
+}
+
+func (s *helperStore) Store(key, token string) error {
+	_, err := s.run("store", map[string]string{"key": key, "token": token})
+	return err
+}
+
+func (s *helperStore) Erase(key string) error {
+	_, err := s.run("erase", map[string]string{"key": key})
+	return err
+}
+
+// passphrase is kept for the rest of the ao run once it is given
+var passphrase string
+
+func getPassphrase() string {
+	if passphrase != "" {
+		return passphrase
+	}
+	if passphrase = os.Getenv(EnvCredentialPassphrase); passphrase != "" {
+		return passphrase
+	}
+	fmt.Println("Passphrase for ao credential file:")
+	passphrase = prompt.Password()
+	return passphrase
+}
+
+// encryptedFileStore stores tokens in a file encrypted with AES-GCM, using a key derived from a passphrase
+type encryptedFileStore struct {
+	file   string
+	tokens map[string]string
+}
+
+type encryptedFile struct {
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func (s *encryptedFileStore) load() error {
+	if s.tokens != nil {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(s.file)
+	if os.IsNotExist(err) {
+		s.tokens = make(map[string]string)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var encrypted encryptedFile
+	if err := json.Unmarshal(data, &encrypted); err != nil {
+		return errors.Wrapf(err, "could not parse %s", s.file)
+	}
+
+	gcm, err := newGCM(getPassphrase(), encrypted.Salt)
+	if err != nil {
+		return err
+	}
+	plaintext, err := gcm.Open(nil, encrypted.Nonce, encrypted.Ciphertext, nil)
+	if err != nil {
+		passphrase = ""
+		return errors.Errorf("could not decrypt %s, wrong passphrase?", s.file)
+	}
+
+	tokens := make(map[string]string)
+	if err := json.Unmarshal(plaintext, &tokens); err != nil {
+		return err
+	}
+	s.tokens = tokens
+	return nil
+}
+
+func (s *encryptedFileStore) save() error {
+	plaintext, err := json.Marshal(s.tokens)
+	if err != nil {
+		return err
+	}
+
+	encrypted := encryptedFile{
+		Salt: make([]byte, saltSize),
+	}
+	if _, err := rand.Read(encrypted.Salt); err != nil {
+		return err
+	}
+	gcm, err := newGCM(getPassphrase(), encrypted.Salt)
+	if err != nil {
+		return err
+	}
+	encrypted.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(encrypted.Nonce); err != nil {
+		return err
+	}
+	encrypted.Ciphertext = gcm.Seal(nil, encrypted.Nonce, plaintext, nil)
+
+	data, err := json.Marshal(encrypted)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.file), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.file, data, 0600)
+}
+
+func (s *encryptedFileStore) Get(key string) (string, error) {
+	if err := s.load(); err != nil {
+		return "", err
+	}
+	token, found := s.tokens[key]
+	if !found {
+		return "", errors.Errorf("no token for %s in %s", key, s.file)
+	}
+	return token, nil
+}
+
+func (s *encryptedFileStore) Store(key, token string) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+	if s.tokens[key] == token {
+		return nil
+	}
+	s.tokens[key] = token
+	return s.save()
+}
+
+func (s *encryptedFileStore) Erase(key string) error {
+	if err := s.load(); err != nil {
+		return err
+	}
+	if _, found := s.tokens[key]; !found {
+		return nil
+	}
+	delete(s.tokens, key)
+	return s.save()
+}
+
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase for credential file can not be empty")
+	}
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, pbkdf2Iterations, keySize, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
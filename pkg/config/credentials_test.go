@@ -0,0 +1,250 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCredentialStore(t *testing.T) {
+	store, err := NewCredentialStore(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, store)
+
+	store, err = NewCredentialStore(&CredentialStoreConfig{Type: CredentialStorePlaintext})
+	assert.NoError(t, err)
+	assert.Nil(t, store)
+
+	_, err = NewCredentialStore(&CredentialStoreConfig{Type: CredentialStoreFile})
+	assert.Error(t, err)
+
+	_, err = NewCredentialStore(&CredentialStoreConfig{Type: CredentialStoreHelper})
+	assert.Error(t, err)
+
+	_, err = NewCredentialStore(&CredentialStoreConfig{Type: "vault"})
+	assert.Error(t, err)
+}
+
+func TestEncryptedFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao-credentials")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	passphrase = "secret passphrase"
+	defer func() { passphrase = "" }()
+
+	file := filepath.Join(dir, ".ao-credentials")
+	store := &encryptedFileStore{file: file}
+	assert.NoError(t, store.Store("cluster/utv", "utv-token"))
+	assert.NoError(t, store.Store("cluster/test", "test-token"))
+	assert.NoError(t, store.Erase("cluster/test"))
+
+	data, err := ioutil.ReadFile(file)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "utv-token")
+
+	store = &encryptedFileStore{file: file}
+	token, err := store.Get("cluster/utv")
+	assert.NoError(t, err)
+	assert.Equal(t, "utv-token", token)
+	_, err = store.Get("cluster/test")
+	assert.Error(t, err)
+
+	passphrase = "wrong passphrase"
+	store = &encryptedFileStore{file: file}
+	_, err = store.Get("cluster/utv")
+	assert.Error(t, err)
+}
+
+func TestHelperStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao-credentials")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	helper := filepath.Join(dir, "helper.sh")
+	script := `#!/bin/sh
+key=$(sed -n 's/^key=//p' | tr '/' '_')
+case "$1" in
+get) sed 's/^/token=/' "` + dir + `/$key" ;;
+erase) rm -f "` + dir + `/$key" ;;
+esac
+`
+	assert.NoError(t, ioutil.WriteFile(helper, []byte(script), 0700))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "cluster_utv"), []byte("utv-token\n"), 0600))
+
+	store := &helperStore{command: []string{helper}}
+	token, err := store.Get("cluster/utv")
+	assert.NoError(t, err)
+	assert.Equal(t, "utv-token", token)
+
+	assert.NoError(t, store.Erase("cluster/utv"))
+	_, err = store.Get("cluster/utv")
+	assert.Error(t, err)
+}
+
+func TestWriteConfig_StoresTokensByReference(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao-credentials")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	passphrase = "secret passphrase"
+	defer func() { passphrase = "" }()
+
+	configLocation := filepath.Join(dir, ".ao.json")
+	ao := newContextTestConfig()
+	assert.NoError(t, ao.SetCredentialStore(&CredentialStoreConfig{
+		Type: CredentialStoreFile,
+		File: filepath.Join(dir, ".ao-credentials"),
+	}))
+	assert.True(t, ao.HasPlaintextTokens())
+
+	assert.NoError(t, WriteConfig(*ao, configLocation))
+	assert.Equal(t, "utv-token", ao.Clusters["utv"].Token)
+
+	info, err := os.Stat(configLocation)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	data, err := ioutil.ReadFile(configLocation)
+	assert.NoError(t, err)
+	assert.False(t, strings.Contains(string(data), "utv-token"))
+
+	var written AOConfig
+	assert.NoError(t, json.Unmarshal(data, &written))
+	assert.Equal(t, "credential:cluster/utv", written.Clusters["utv"].Token)
+
+	loaded, err := LoadConfigFile(configLocation)
+	assert.NoError(t, err)
+	assert.NoError(t, loaded.ResolveTokens())
+	assert.Equal(t, "utv-token", loaded.Clusters["utv"].Token)
+	assert.Equal(t, "test-token", loaded.Clusters["test"].Token)
+	assert.False(t, loaded.HasPlaintextTokens())
+}
+
+func TestAOConfig_ResolveTokensWithoutStore(t *testing.T) {
+	ao := newContextTestConfig()
+	ao.Clusters["utv"].Token = "credential:cluster/utv"
+
+	assert.Error(t, ao.ResolveTokens())
+	assert.False(t, ao.HasPlaintextTokens())
+}
+
+func TestSetCredentialStore_ErasesPreviousTokensAfterWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao-credentials")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	passphrase = "secret passphrase"
+	defer func() { passphrase = "" }()
+
+	configLocation := filepath.Join(dir, ".ao.json")
+	previousFile := filepath.Join(dir, ".ao-credentials")
+	ao := newContextTestConfig()
+	assert.NoError(t, ao.SetCredentialStore(&CredentialStoreConfig{Type: CredentialStoreFile, File: previousFile}))
+	assert.NoError(t, WriteConfig(*ao, configLocation))
+	previous, err := NewCredentialStore(ao.CredentialStore)
+	assert.NoError(t, err)
+
+	assert.NoError(t, ao.SetCredentialStore(&CredentialStoreConfig{Type: CredentialStoreFile, File: filepath.Join(dir, ".ao-credentials-new")}))
+	assert.Error(t, WriteConfig(*ao, filepath.Join(dir, "missing", ".ao.json")))
+	token, err := previous.Get("cluster/utv")
+	assert.NoError(t, err, "tokens should be kept in the previous store when the config could not be written")
+	assert.Equal(t, "utv-token", token)
+
+	assert.NoError(t, WriteConfig(*ao, configLocation))
+	previous, err = NewCredentialStore(&CredentialStoreConfig{Type: CredentialStoreFile, File: previousFile})
+	assert.NoError(t, err)
+	_, err = previous.Get("cluster/utv")
+	assert.Error(t, err)
+}
+
+// writeLoggingHelper writes a credential helper storing tokens in dir. readCalls returns the calls made since it was
+// last called, one "<operation> <key>" per line with / in keys replaced by _.
+func writeLoggingHelper(t *testing.T, dir string) (helper string, readCalls func() string) {
+	helper = filepath.Join(dir, "helper.sh")
+	calls := filepath.Join(dir, "calls")
+	script := `#!/bin/sh
+input=$(cat)
+key=$(echo "$input" | sed -n 's/^key=//p' | tr '/' '_')
+token=$(echo "$input" | sed -n 's/^token=//p')
+echo "$1 $key" >> "` + calls + `"
+case "$1" in
+get) sed 's/^/token=/' "` + dir + `/$key" ;;
+store) echo "$token" > "` + dir + `/$key" ;;
+erase) rm -f "` + dir + `/$key" ;;
+esac
+`
+	assert.NoError(t, ioutil.WriteFile(helper, []byte(script), 0700))
+	readCalls = func() string {
+		data, _ := ioutil.ReadFile(calls)
+		os.Remove(calls)
+		return string(data)
+	}
+	return helper, readCalls
+}
+
+func TestAOConfig_ReadsTokensFromCredentialStoreWhenUsed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao-credentials")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	helper, readCalls := writeLoggingHelper(t, dir)
+
+	configLocation := filepath.Join(dir, ".ao.json")
+	ao := newContextTestConfig()
+	assert.NoError(t, ao.SetCredentialStore(&CredentialStoreConfig{Type: CredentialStoreHelper, Helper: helper}))
+	assert.NoError(t, WriteConfig(*ao, configLocation))
+	readCalls()
+
+	loaded, err := LoadConfigFile(configLocation)
+	assert.NoError(t, err)
+	assert.Equal(t, "credential:cluster/utv", loaded.Clusters["utv"].Token)
+	assert.False(t, loaded.HasPlaintextTokens())
+
+	assert.NoError(t, loaded.ResolveClusterToken("utv"))
+	assert.Equal(t, "utv-token", loaded.Clusters["utv"].Token)
+	assert.Equal(t, "credential:cluster/test", loaded.Clusters["test"].Token)
+	assert.False(t, loaded.HasPlaintextTokens())
+	assert.Equal(t, "get cluster_utv\n", readCalls(), "only the token in use should be read")
+
+	loaded.Clusters["utv"].Token = ""
+	assert.NoError(t, WriteConfig(*loaded, configLocation))
+	assert.Equal(t, "erase cluster_utv\n", readCalls(), "tokens not read should be kept, and the removed token erased")
+
+	written, err := LoadConfigFile(configLocation)
+	assert.NoError(t, err)
+	assert.Equal(t, "credential:cluster/test", written.Clusters["test"].Token)
+	assert.NoError(t, written.ResolveTokens())
+	assert.Equal(t, "test-token", written.Clusters["test"].Token)
+}
+
+func TestAOConfig_RemoveClusterErasesItsTokens(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao-credentials")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	helper, readCalls := writeLoggingHelper(t, dir)
+
+	configLocation := filepath.Join(dir, ".ao.json")
+	ao := newContextTestConfig()
+	ao.AvailableClusters = []string{"utv", "test"}
+	sales := Context{Affiliation: "sales", RefName: "master", APICluster: "test", Tokens: map[string]string{"utv": "sales-token"}}
+	assert.NoError(t, ao.CreateContext("sales", sales))
+	assert.NoError(t, ao.SetCredentialStore(&CredentialStoreConfig{Type: CredentialStoreHelper, Helper: helper}))
+	assert.NoError(t, WriteConfig(*ao, configLocation))
+	readCalls()
+
+	loaded, err := LoadConfigFile(configLocation)
+	assert.NoError(t, err)
+	assert.NoError(t, loaded.RemoveCluster("utv"))
+	for name, context := range loaded.Contexts {
+		assert.NotContains(t, context.Tokens, "utv", name)
+	}
+	assert.NoError(t, WriteConfig(*loaded, configLocation))
+
+	calls := strings.Split(readCalls(), "\n")
+	assert.Contains(t, calls, "erase cluster_utv")
+	assert.Contains(t, calls, "erase context_default_utv")
+	assert.Contains(t, calls, "erase context_sales_utv")
+	assert.NotContains(t, calls, "erase cluster_test")
+}
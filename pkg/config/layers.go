@@ -0,0 +1,217 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// Environment variables overriding the ao config
+const (
+	EnvConfig      = "AO_CONFIG"
+	EnvAffiliation = "AO_AFFILIATION"
+	EnvRefName     = "AO_REF"
+	EnvAPICluster  = "AO_API_CLUSTER"
+	EnvToken       = "AO_TOKEN"
+)
+
+// RepoConfigFileName is the name of the per-repo config file, found by walking up from the working directory
+const RepoConfigFileName = ".ao.yaml"
+
+// Names of settings that can be set in the configuration layers
+const (
+	SettingConfig      = "config"
+	SettingContext     = "context"
+	SettingAffiliation = "affiliation"
+	SettingRefName     = "refName"
+	SettingAPICluster  = "apiCluster"
+	SettingToken       = "token"
+)
+
+// SourceDefault is the source of settings with built-in default values
+const SourceDefault = "default"
+
+var settingNames = []string{SettingConfig, SettingContext, SettingAffiliation, SettingRefName, SettingAPICluster, SettingToken}
+
+// Setting is an effective configuration value and where it came from
+type Setting struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// RepoConfig holds the settings of a per-repo .ao.yaml file
+type RepoConfig struct {
+	Affiliation string `yaml:"affiliation"`
+	RefName     string `yaml:"refName"`
+	APICluster  string `yaml:"apiCluster"`
+}
+
+// ResolveConfigLocation returns the location of the user config file, given by AO_CONFIG or ~/.ao.json
+func ResolveConfigLocation(home string, getenv func(string) string) Setting {
+	if location := getenv(EnvConfig); location != "" {
+		return Setting{Name: SettingConfig, Value: location, Source: "env " + EnvConfig}
+	}
+	return Setting{Name: SettingConfig, Value: filepath.Join(home, ".ao.json"), Source: SourceDefault}
+}
+
+// FindRepoConfig searches for a .ao.yaml file in path and its parent directories. Returns empty string if none is found.
+func FindRepoConfig(path string) string {
+	for {
+		candidate := filepath.Join(path, RepoConfigFileName)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+
+		parent := filepath.Dir(path)
+		if parent == path {
+			return ""
+		}
+		path = parent
+	}
+}
+
+// LoadRepoConfig loads a per-repo .ao.yaml file
+func LoadRepoConfig(file string) (*RepoConfig, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var repoConfig RepoConfig
+	if err := yaml.UnmarshalStrict(data, &repoConfig); err != nil {
+		return nil, errors.Wrapf(err, "could not parse %s", file)
+	}
+	return &repoConfig, nil
+}
+
+// InitSettings records the config location and the values loaded from the user config file and active context
+func (ao *AOConfig) InitSettings(configLocation Setting) {
+	ao.settings = make(map[string]Setting)
+	ao.overrides = make(map[string]string)
+	ao.setSetting(configLocation.Name, configLocation.Value, configLocation.Source)
+
+	source := "file " + configLocation.Value
+	if context := ao.ActiveContext(); context != "" {
+		ao.setSetting(SettingContext, context, source)
+		source = "context " + context
+	}
+	for _, name := range []string{SettingAffiliation, SettingRefName, SettingAPICluster} {
+		ao.setSetting(name, *ao.settingField(name), source)
+	}
+}
+
+// ApplyRepoConfig overrides settings with values from a per-repo .ao.yaml file
+func (ao *AOConfig) ApplyRepoConfig(file string, repoConfig *RepoConfig) {
+	source := "file " + file
+	ao.Override(SettingAffiliation, repoConfig.Affiliation, source)
+	ao.Override(SettingRefName, repoConfig.RefName, source)
+	ao.Override(SettingAPICluster, repoConfig.APICluster, source)
+}
+
+// ApplyEnvironment overrides settings with values from AO_* environment variables
+func (ao *AOConfig) ApplyEnvironment(getenv func(string) string) {
+	ao.Override(SettingAffiliation, getenv(EnvAffiliation), "env "+EnvAffiliation)
+	ao.Override(SettingRefName, getenv(EnvRefName), "env "+EnvRefName)
+	ao.Override(SettingAPICluster, getenv(EnvAPICluster), "env "+EnvAPICluster)
+	ao.Override(SettingToken, getenv(EnvToken), "env "+EnvToken)
+}
+
+// Override sets a setting from a configuration layer with higher precedence than the user config file.
+// Empty values are ignored. Overridden values are not written back to the user config file.
+func (ao *AOConfig) Override(name, value, source string) {
+	if value == "" {
+		return
+	}
+	if ao.overrides == nil {
+		ao.overrides = make(map[string]string)
+	}
+
+	if field := ao.settingField(name); field != nil {
+		if _, found := ao.overrides[name]; !found {
+			ao.overrides[name] = *field
+		}
+		*field = value
+	}
+	ao.setSetting(name, value, source)
+}
+
+// Token returns the token to use for the API cluster, either overridden or from the API cluster
+func (ao *AOConfig) Token() Setting {
+	if setting, found := ao.settings[SettingToken]; found {
+		return setting
+	}
+
+	setting := Setting{Name: SettingToken, Source: "cluster " + ao.APICluster}
+	if cluster, found := ao.Clusters[ao.APICluster]; found {
+		setting.Value = cluster.Token
+	}
+	return setting
+}
+
+// TokenOverride returns the token given by AO_TOKEN or --token, which is used for every cluster instead of
+// the tokens from login. It is empty when the token is not overridden.
+func (ao *AOConfig) TokenOverride() string {
+	return ao.settings[SettingToken].Value
+}
+
+// Setting returns the effective value of a setting and where it came from
+func (ao *AOConfig) Setting(name string) Setting {
+	if name == SettingToken {
+		return ao.Token()
+	}
+	if setting, found := ao.settings[name]; found {
+		return setting
+	}
+
+	setting := Setting{Name: name, Source: SourceDefault}
+	if field := ao.settingField(name); field != nil {
+		setting.Value = *field
+	}
+	return setting
+}
+
+// Settings returns the effective value of all settings
+func (ao *AOConfig) Settings() []Setting {
+	settings := make([]Setting, 0, len(settingNames))
+	for _, name := range settingNames {
+		settings = append(settings, ao.Setting(name))
+	}
+	return settings
+}
+
+func (ao *AOConfig) setSetting(name, value, source string) {
+	if ao.settings == nil {
+		ao.settings = make(map[string]Setting)
+	}
+	if value == "" {
+		source = SourceDefault
+	}
+	ao.settings[name] = Setting{Name: name, Value: value, Source: source}
+}
+
+func (ao *AOConfig) settingField(name string) *string {
+	switch name {
+	case SettingAffiliation:
+		return &ao.Affiliation
+	case SettingRefName:
+		return &ao.RefName
+	case SettingAPICluster:
+		return &ao.APICluster
+	}
+	return nil
+}
+
+// restoreOverrides resets overridden settings to the values from the user config file,
+// unless they have been changed after they were overridden
+func (ao *AOConfig) restoreOverrides() {
+	for name, persisted := range ao.overrides {
+		field := ao.settingField(name)
+		if field != nil && *field == ao.settings[name].Value {
+			*field = persisted
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveConfigLocation(t *testing.T) {
+	location := ResolveConfigLocation("/home/user", func(string) string { return "" })
+	assert.Equal(t, Setting{Name: SettingConfig, Value: "/home/user/.ao.json", Source: SourceDefault}, location)
+
+	location = ResolveConfigLocation("/home/user", func(key string) string {
+		if key == EnvConfig {
+			return "/etc/ao.json"
+		}
+		return ""
+	})
+	assert.Equal(t, Setting{Name: SettingConfig, Value: "/etc/ao.json", Source: "env AO_CONFIG"}, location)
+}
+
+func TestFindRepoConfig(t *testing.T) {
+	root, err := ioutil.TempDir("", "ao_repo")
+	assert.NoError(t, err)
+	defer os.RemoveAll(root)
+
+	subdir := filepath.Join(root, "utv", "app")
+	assert.NoError(t, os.MkdirAll(subdir, 0755))
+	assert.Empty(t, FindRepoConfig(subdir))
+
+	repoConfigFile := filepath.Join(root, RepoConfigFileName)
+	assert.NoError(t, ioutil.WriteFile(repoConfigFile, []byte("affiliation: sales\nrefName: develop\n"), 0644))
+	assert.Equal(t, repoConfigFile, FindRepoConfig(subdir))
+
+	repoConfig, err := LoadRepoConfig(repoConfigFile)
+	assert.NoError(t, err)
+	assert.Equal(t, &RepoConfig{Affiliation: "sales", RefName: "develop"}, repoConfig)
+
+	assert.NoError(t, ioutil.WriteFile(repoConfigFile, []byte("affiliaton: sales\n"), 0644))
+	_, err = LoadRepoConfig(repoConfigFile)
+	assert.Error(t, err, "Should not accept unknown keys")
+}
+
+func TestAOConfig_Settings(t *testing.T) {
+	defer os.Remove(configTmpFile)
+
+	ao := &AOConfig{
+		Affiliation: "paas",
+		RefName:     "master",
+		APICluster:  "utv",
+		Clusters: map[string]*Cluster{
+			"utv":  {Name: "utv", Token: "utv-token"},
+			"test": {Name: "test", Token: "test-token"},
+		},
+	}
+	ao.InitSettings(Setting{Name: SettingConfig, Value: configTmpFile, Source: SourceDefault})
+	ao.ApplyRepoConfig("/repo/.ao.yaml", &RepoConfig{Affiliation: "sales", RefName: "develop"})
+	ao.ApplyEnvironment(func(key string) string {
+		return map[string]string{EnvAffiliation: "finance", EnvAPICluster: "test"}[key]
+	})
+	ao.Override(SettingRefName, "feature", "flag --ref")
+
+	assert.Equal(t, []Setting{
+		{Name: SettingConfig, Value: configTmpFile, Source: SourceDefault},
+		{Name: SettingContext, Source: SourceDefault},
+		{Name: SettingAffiliation, Value: "finance", Source: "env AO_AFFILIATION"},
+		{Name: SettingRefName, Value: "feature", Source: "flag --ref"},
+		{Name: SettingAPICluster, Value: "test", Source: "env AO_API_CLUSTER"},
+		{Name: SettingToken, Value: "test-token", Source: "cluster test"},
+	}, ao.Settings())
+	assert.Empty(t, ao.TokenOverride())
+
+	t.Run("Should not write overridden values to the user config file", func(t *testing.T) {
+		ao.RefName = "changed-by-command"
+		assert.NoError(t, WriteConfig(*ao, configTmpFile))
+
+		loaded, err := LoadConfigFile(configTmpFile)
+		assert.NoError(t, err)
+		assert.Equal(t, "paas", loaded.Affiliation)
+		assert.Equal(t, "utv", loaded.APICluster)
+		assert.Equal(t, "changed-by-command", loaded.RefName)
+		assert.Equal(t, "finance", ao.Affiliation)
+	})
+
+	t.Run("Should prefer overridden token", func(t *testing.T) {
+		ao.Override(SettingToken, "flag-token", "flag --token")
+		assert.Equal(t, Setting{Name: SettingToken, Value: "flag-token", Source: "flag --token"}, ao.Token())
+		assert.Equal(t, "flag-token", ao.TokenOverride())
+	})
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ConfigLockTimeout is how long to wait for another ao process holding the config lock
+var ConfigLockTimeout = 30 * time.Second
+
+const lockRetryInterval = 100 * time.Millisecond
+
+// LockConfig takes an advisory lock on the config file, to be held around read-modify-write of the config.
+// The lock is released by calling unlock, or when ao exits.
+func LockConfig(configLocation string) (unlock func(), err error) {
+	lockFile := configLocation + ".lock"
+	deadline := time.Now().Add(ConfigLockTimeout)
+
+	for {
+		file, locked, err := tryLock(lockFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not lock %s", configLocation)
+		}
+		if locked {
+			return func() { unlockFile(file, lockFile) }, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, errors.Errorf("%s is locked by another ao process, remove %s if no other ao process is running", configLocation, lockFile)
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// TryLockConfig takes the lock on the config file like LockConfig, but does not wait when another ao process
+// holds it. locked is false if the lock is busy.
+func TryLockConfig(configLocation string) (unlock func(), locked bool, err error) {
+	lockFile := configLocation + ".lock"
+	file, locked, err := tryLock(lockFile)
+	if err != nil {
+		return nil, false, errors.Wrapf(err, "could not lock %s", configLocation)
+	}
+	if !locked {
+		return nil, false, nil
+	}
+	return func() { unlockFile(file, lockFile) }, true, nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLockConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao-config")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	configLocation := filepath.Join(dir, ".ao.json")
+
+	timeout := ConfigLockTimeout
+	ConfigLockTimeout = 200 * time.Millisecond
+	defer func() { ConfigLockTimeout = timeout }()
+
+	unlock, err := LockConfig(configLocation)
+	assert.NoError(t, err)
+
+	_, err = LockConfig(configLocation)
+	assert.Error(t, err)
+
+	unlock()
+
+	unlock, err = LockConfig(configLocation)
+	assert.NoError(t, err)
+	unlock()
+}
+
+func TestTryLockConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao-config")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	configLocation := filepath.Join(dir, ".ao.json")
+
+	unlock, locked, err := TryLockConfig(configLocation)
+	assert.NoError(t, err)
+	assert.True(t, locked)
+
+	_, locked, err = TryLockConfig(configLocation)
+	assert.NoError(t, err)
+	assert.False(t, locked, "the lock should be busy")
+
+	unlock()
+	unlock, locked, err = TryLockConfig(configLocation)
+	assert.NoError(t, err)
+	assert.True(t, locked)
+	unlock()
+}
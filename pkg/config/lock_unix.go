@@ -0,0 +1,29 @@
+// +build !windows
+
+package config
+
+import (
+	"os"
+	"syscall"
+)
+
+func tryLock(lockFile string) (*os.File, bool, error) {
+	file, err := os.OpenFile(lockFile, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		file.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return file, true, nil
+}
+
+func unlockFile(file *os.File, lockFile string) {
+	syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+	file.Close()
+}
@@ -0,0 +1,31 @@
+// +build windows
+
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// staleLockAge is the age of a lock file left behind by an ao process that did not exit cleanly
+const staleLockAge = 10 * time.Minute
+
+// tryLock creates the lock file exclusively, as Windows has no flock
+func tryLock(lockFile string) (*os.File, bool, error) {
+	file, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0600)
+	if os.IsExist(err) {
+		if info, statErr := os.Stat(lockFile); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockFile)
+		}
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return file, true, nil
+}
+
+func unlockFile(file *os.File, lockFile string) {
+	file.Close()
+	os.Remove(lockFile)
+}
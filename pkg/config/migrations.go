@@ -0,0 +1,97 @@
+package config
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// legacyClusterType is the cluster type given to clusters using the old top-level url patterns,
+// unless the patterns are the built-in ocp3 patterns
+const legacyClusterType = "default"
+
+// migration upgrades the ao config from the previous schema version to version
+type migration struct {
+	version     int
+	description string
+	migrate     func(ao *AOConfig) error
+}
+
+// migrations are run in order on configs with a lower schema version. Append new migrations at the end,
+// and never change a migration that has been released.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "move top-level url patterns to serviceURLPatterns and clusterConfig",
+		migrate:     migrateLegacyURLPatterns,
+	},
+}
+
+// CurrentSchemaVersion is the schema version of ao configs written by this version of ao
+var CurrentSchemaVersion = migrations[len(migrations)-1].version
+
+// Migrate upgrades the config to the current schema version, keeping tokens and cluster settings.
+// Returns true if the config was changed and should be written.
+func (ao *AOConfig) Migrate() (bool, error) {
+	if ao.SchemaVersion > CurrentSchemaVersion {
+		logrus.Warnf("ao config has schema version %d, which is newer than this version of ao supports (%d). Consider updating ao.", ao.SchemaVersion, CurrentSchemaVersion)
+		return false, nil
+	}
+
+	migrated := false
+	for _, m := range migrations {
+		if m.version <= ao.SchemaVersion {
+			continue
+		}
+
+		logrus.Infof("Migrating ao config to schema version %d: %s", m.version, m.description)
+		if err := m.migrate(ao); err != nil {
+			return migrated, errors.Wrapf(err, "could not migrate ao config to schema version %d", m.version)
+		}
+		ao.SchemaVersion = m.version
+		migrated = true
+	}
+
+	if ao.FileAOVersion != Version {
+		ao.FileAOVersion = Version
+		migrated = true
+	}
+
+	return migrated, nil
+}
+
+func migrateLegacyURLPatterns(ao *AOConfig) error {
+	if len(ao.ServiceURLPatterns) == 0 {
+		patterns := &ServiceURLPatterns{
+			ClusterURLPattern: ao.ClusterURLPattern,
+			BooberURLPattern:  ao.BooberURLPattern,
+			UpdateURLPattern:  ao.UpdateURLPattern,
+			GoboURLPattern:    ao.GoboURLPattern,
+		}
+
+		clusterType := legacyClusterType
+		if *patterns == *ocp3URLPatterns {
+			clusterType = "ocp3"
+		}
+		ao.ServiceURLPatterns = map[string]*ServiceURLPatterns{
+			clusterType: patterns,
+		}
+
+		if ao.ClusterConfig == nil {
+			ao.ClusterConfig = make(map[string]*ClusterConfig)
+		}
+		for _, name := range ao.AvailableClusters {
+			clusterConfig, found := ao.ClusterConfig[name]
+			if !found {
+				ao.ClusterConfig[name] = &ClusterConfig{Type: clusterType}
+			} else if clusterConfig.Type == "" {
+				clusterConfig.Type = clusterType
+			}
+		}
+	}
+
+	ao.ClusterURLPattern = ""
+	ao.BooberURLPattern = ""
+	ao.UpdateURLPattern = ""
+	ao.GoboURLPattern = ""
+	return nil
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAOConfig_MigrateLegacyURLPatterns(t *testing.T) {
+	ao := &AOConfig{
+		AvailableClusters: []string{"utv", "test"},
+		Clusters: map[string]*Cluster{
+			"utv": {Name: "utv", Token: "utv-token"},
+		},
+		ClusterConfig: map[string]*ClusterConfig{
+			"test": {ClusterURLPrefix: "test-prefix"},
+		},
+		ClusterURLPattern: ocp3URLPatterns.ClusterURLPattern,
+		BooberURLPattern:  ocp3URLPatterns.BooberURLPattern,
+		UpdateURLPattern:  ocp3URLPatterns.UpdateURLPattern,
+		GoboURLPattern:    ocp3URLPatterns.GoboURLPattern,
+		FileAOVersion:     "old",
+	}
+	before, err := ao.GetServiceURLs("utv")
+	assert.NoError(t, err)
+
+	migrated, err := ao.Migrate()
+	assert.NoError(t, err)
+	assert.True(t, migrated)
+
+	assert.Equal(t, CurrentSchemaVersion, ao.SchemaVersion)
+	assert.Equal(t, Version, ao.FileAOVersion)
+	assert.Empty(t, ao.BooberURLPattern)
+	assert.Equal(t, *ocp3URLPatterns, *ao.ServiceURLPatterns["ocp3"])
+	assert.Equal(t, &ClusterConfig{Type: "ocp3"}, ao.ClusterConfig["utv"])
+	assert.Equal(t, &ClusterConfig{Type: "ocp3", ClusterURLPrefix: "test-prefix"}, ao.ClusterConfig["test"])
+	assert.Equal(t, "utv-token", ao.Clusters["utv"].Token)
+
+	after, err := ao.GetServiceURLs("utv")
+	assert.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	migrated, err = ao.Migrate()
+	assert.NoError(t, err)
+	assert.False(t, migrated)
+}
+
+func TestAOConfig_MigrateCustomURLPatterns(t *testing.T) {
+	ao := &AOConfig{
+		AvailableClusters: []string{"local"},
+		ClusterURLPattern: "https://%s.example.com:8443",
+		BooberURLPattern:  "http://boober.%s.example.com",
+		GoboURLPattern:    "http://gobo.%s.example.com",
+	}
+
+	_, err := ao.Migrate()
+	assert.NoError(t, err)
+	assert.Equal(t, "http://boober.%s.example.com", ao.ServiceURLPatterns[legacyClusterType].BooberURLPattern)
+	assert.Equal(t, legacyClusterType, ao.ClusterConfig["local"].Type)
+}
+
+func TestAOConfig_MigrateKeepsServiceURLPatterns(t *testing.T) {
+	ao := &AOConfig{
+		AvailableClusters: []string{"utv04"},
+		ServiceURLPatterns: map[string]*ServiceURLPatterns{
+			"ocp4": ocp4URLPatterns,
+		},
+		ClusterConfig: map[string]*ClusterConfig{
+			"utv04": {Type: "ocp4"},
+		},
+		BooberURLPattern: ocp3URLPatterns.BooberURLPattern,
+	}
+
+	_, err := ao.Migrate()
+	assert.NoError(t, err)
+	assert.Len(t, ao.ServiceURLPatterns, 1)
+	assert.Equal(t, "ocp4", ao.ClusterConfig["utv04"].Type)
+	assert.Empty(t, ao.BooberURLPattern)
+}
+
+func TestAOConfig_MigrateNewerSchema(t *testing.T) {
+	ao := &AOConfig{
+		SchemaVersion: CurrentSchemaVersion + 1,
+		FileAOVersion: "newer",
+	}
+
+	migrated, err := ao.Migrate()
+	assert.NoError(t, err)
+	assert.False(t, migrated)
+	assert.Equal(t, "newer", ao.FileAOVersion)
+}
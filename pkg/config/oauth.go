@@ -0,0 +1,171 @@
+package config
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// oauthCLIClientID is the OpenShift OAuth client that allows the authorization code flow with PKCE
+// and redirects to localhost, as used by oc login --web
+const oauthCLIClientID = "openshift-cli-client"
+
+const oauthCallbackPath = "/callback"
+
+// WebLoginTimeout limits how long ao waits for the user to log in with the browser
+var WebLoginTimeout = 5 * time.Minute
+
+// GetTokenFromBrowser gets a token for the cluster from its OAuth server, with the authorization code flow and PKCE.
+// openURL is called with the url the user must open in a browser to log in. The browser is redirected to a
+// listener on localhost, which receives the authorization code.
+func (c *Cluster) GetTokenFromBrowser(openURL func(string) error) (string, error) {
+	verifier, err := randomString(32)
+	if err != nil {
+		return "", err
+	}
+	state, err := randomString(16)
+	if err != nil {
+		return "", err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", errors.Wrap(err, "could not listen for the OAuth callback")
+	}
+	redirectURL := fmt.Sprintf("http://%s%s", listener.Addr().String(), oauthCallbackPath)
+
+	codes := make(chan string, 1)
+	failures := make(chan error, 1)
+	server := &http.Server{Handler: oauthCallbackHandler(state, codes, failures)}
+	go server.Serve(listener)
+	defer server.Close()
+
+	if err := openURL(c.authorizeURL(redirectURL, state, pkceChallenge(verifier))); err != nil {
+		return "", err
+	}
+
+	select {
+	case code := <-codes:
+		return c.exchangeCode(code, redirectURL, verifier)
+	case err := <-failures:
+		return "", err
+	case <-time.After(WebLoginTimeout):
+		return "", errors.Errorf("timed out waiting for login to %s", c.Name)
+	}
+}
+
+func (c *Cluster) authorizeURL(redirectURL, state, challenge string) string {
+	query := url.Values{
+		"client_id":             {oauthCLIClientID},
+		"response_type":         {"code"},
+		"redirect_uri":          {redirectURL},
+		"state":                 {state},
+		"code_challenge":        {challenge},
+		"code_challenge_method": {"S256"},
+	}
+	return c.LoginURL + "/oauth/authorize?" + query.Encode()
+}
+
+func oauthCallbackHandler(state string, codes chan<- string, failures chan<- error) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(oauthCallbackPath, func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		var err error
+		switch {
+		case query.Get("error") != "":
+			err = errors.New(strings.TrimSpace(query.Get("error") + " " + query.Get("error_description")))
+		case query.Get("state") != state:
+			err = errors.New("the OAuth callback has an unexpected state")
+		case query.Get("code") == "":
+			err = errors.New("the OAuth callback has no authorization code")
+		}
+
+		if err != nil {
+			http.Error(w, "Login failed: "+err.Error(), http.StatusBadRequest)
+			select {
+			case failures <- err:
+			default:
+			}
+			return
+		}
+		fmt.Fprintln(w, "Login succeeded. You can close this window and return to ao.")
+		select {
+		case codes <- query.Get("code"):
+		default:
+		}
+	})
+	return mux
+}
+
+// exchangeCode gets a token for an authorization code from the OAuth server
+func (c *Cluster) exchangeCode(code, redirectURL, verifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURL},
+		"client_id":     {oauthCLIClientID},
+		"code_verifier": {verifier},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.LoginURL+"/oauth/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	logrus.WithField("url", req.URL.String()).Debug("Exchange authorization code")
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	var tokenResponse struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &tokenResponse); err != nil {
+		return "", errors.Errorf("unexpected response from %s: %d", req.URL, resp.StatusCode)
+	}
+	if tokenResponse.Error != "" {
+		return "", errors.New(strings.TrimSpace(tokenResponse.Error + " " + tokenResponse.ErrorDescription))
+	}
+	if tokenResponse.AccessToken == "" {
+		return "", errors.New("token is empty")
+	}
+	return tokenResponse.AccessToken, nil
+}
+
+// pkceChallenge returns the S256 code challenge of a code verifier
+func pkceChallenge(verifier string) string {
+	hash := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(hash[:])
+}
+
+func randomString(length int) (string, error) {
+	data := make([]byte, length)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newOAuthTestServer(t *testing.T) *httptest.Server {
+	var challenge string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/authorize", func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		assert.Equal(t, oauthCLIClientID, query.Get("client_id"))
+		assert.Equal(t, "S256", query.Get("code_challenge_method"))
+		challenge = query.Get("code_challenge")
+
+		redirect, _ := url.Parse(query.Get("redirect_uri"))
+		redirect.RawQuery = url.Values{"code": {"the-code"}, "state": {query.Get("state")}}.Encode()
+		http.Redirect(w, req, redirect.String(), http.StatusFound)
+	})
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, req *http.Request) {
+		req.ParseForm()
+		if req.Form.Get("code") != "the-code" || pkceChallenge(req.Form.Get("code_verifier")) != challenge {
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "web-token"})
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestCluster_GetTokenFromBrowser(t *testing.T) {
+	ts := newOAuthTestServer(t)
+	defer ts.Close()
+	cluster := &Cluster{Name: "utv", LoginURL: ts.URL}
+
+	token, err := cluster.GetTokenFromBrowser(func(authorizeURL string) error {
+		// The browser follows the redirect to the callback listener
+		go http.Get(authorizeURL)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "web-token", token)
+}
+
+func TestOAuthCallbackHandler(t *testing.T) {
+	codes := make(chan string, 1)
+	failures := make(chan error, 1)
+	handler := oauthCallbackHandler("expected-state", codes, failures)
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/callback?code=abc&state=other-state", nil))
+	assert.Equal(t, http.StatusBadRequest, res.Code)
+	assert.EqualError(t, <-failures, "the OAuth callback has an unexpected state")
+
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/callback?error=access_denied&error_description=denied", nil))
+	assert.EqualError(t, <-failures, "access_denied denied")
+
+	res = httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/callback?code=abc&state=expected-state", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+	assert.Equal(t, "abc", <-codes)
+}
+
+func TestPkceChallenge(t *testing.T) {
+	// base64url encoded SHA-256 of "abc", without padding
+	assert.Equal(t, "ungWv48Bz-pBQUDeXa4iI7ADYaOWF3qctBD_YfIAFa0", pkceChallenge("abc"))
+}
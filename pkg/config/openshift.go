@@ -14,22 +14,8 @@ import (
 
 const authenticationURLSuffix = "/oauth/authorize?client_id=openshift-challenging-client&response_type=token"
 
-var (
-	transport = http.Transport{
-		Dial: func(network, addr string) (net.Conn, error) {
-			timeout := time.Duration(1 * time.Second)
-			return net.DialTimeout(network, addr, timeout)
-		},
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-
-	client = http.Client{
-		Transport: &transport,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			return http.ErrUseLastResponse
-		},
-	}
-)
+// clusterDialTimeout is the dial timeout when calling the OpenShift API of a cluster
+const clusterDialTimeout = 1 * time.Second
 
 // Cluster holds information of Openshift cluster
 type Cluster struct {
@@ -40,13 +26,44 @@ type Cluster struct {
 	Reachable bool   `json:"reachable"`
 	BooberURL string `json:"booberUrl"`
 	GoboURL   string `json:"goboUrl"`
+	// Endpoints holds the result of checking each endpoint when the cluster was last probed
+	Endpoints []EndpointStatus `json:"endpoints,omitempty"`
+	// Username and TokenExpiry tell who the token belongs to and when it expires, when it was last checked
+	Username    string     `json:"username,omitempty"`
+	TokenExpiry *time.Time `json:"tokenExpiry,omitempty"`
+
+	tlsConfig *tls.Config
+	tlsErr    error
 }
 
-// InitClusters initializes Cluster objects for AOConfig
+// httpClient returns a client for the OpenShift API of the cluster, which does not follow redirects
+func (c *Cluster) httpClient() *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			DialContext:     (&net.Dialer{Timeout: clusterDialTimeout}).DialContext,
+			TLSClientConfig: c.TLSConfig(),
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// InitClusters initializes Cluster objects for AOConfig. The clusters are updated from the cluster catalogue
+// on the update server if available, otherwise the clusters already in the config are used.
 func (ao *AOConfig) InitClusters() {
+	ao.probeClusters()
+	if ao.updateClusterCatalogue() {
+		ao.probeClusters()
+	}
+}
+
+func (ao *AOConfig) probeClusters() {
 	ao.Clusters = make(map[string]*Cluster)
 	ch := make(chan *Cluster)
 	configuredClusters := 0
+	prober := ao.newProber()
 
 	for _, cluster := range ao.AvailableClusters {
 		name := cluster
@@ -59,27 +76,7 @@ func (ao *AOConfig) InitClusters() {
 
 		configuredClusters++
 		go func() {
-			reachable := false
-			resp, err := client.Get(urls.BooberURL)
-			if err == nil && resp != nil && resp.StatusCode < 500 {
-				resp, err := client.Get(urls.GoboURL)
-				if err == nil && resp != nil && resp.StatusCode < 500 {
-					resp, err = client.Get(urls.ClusterLoginURL)
-					if err == nil && resp != nil && resp.StatusCode < 500 {
-						reachable = true
-					}
-				}
-			}
-
-			logrus.WithField("reachable", reachable).Info(urls.BooberURL)
-			ch <- &Cluster{
-				Name:      name,
-				URL:       urls.ClusterURL,
-				LoginURL:  urls.ClusterLoginURL,
-				Reachable: reachable,
-				BooberURL: urls.BooberURL,
-				GoboURL:   urls.GoboURL,
-			}
+			ch <- prober.newCluster(name, urls)
 		}()
 	}
 
@@ -94,35 +91,20 @@ func (ao *AOConfig) InitClusters() {
 	}
 }
 
-// HasValidToken performs a test call to verify validity of token
+// HasValidToken performs a test call to verify validity of token. It makes the same call as UpdateTokenInfo,
+// so that the two never disagree.
 func (c *Cluster) HasValidToken() bool {
-	if c.Token == "" {
-		return false
-	}
-
-	clusterURL := fmt.Sprintf("%s/%s", c.URL, "api")
-	req, err := http.NewRequest("GET", clusterURL, nil)
-	if err != nil {
-		return false
-	}
-
-	req.Header.Add("Authorization", "Bearer "+c.Token)
-	logrus.WithField("url", clusterURL).Debug("Check for valid token")
-	resp, err := client.Do(req)
-	if err != nil {
-		return false
-	}
-
-	if resp.StatusCode != http.StatusOK {
+	if _, err := c.currentUser(); err != nil {
+		logrus.WithField("cluster", c.Name).Debug("Token is not valid: ", err)
 		return false
 	}
 	return true
 }
 
-// GetToken gets a token for a host
-func GetToken(host string, username string, password string) (string, error) {
-	clusterURL := host + authenticationURLSuffix
-	resp, err := getBasicAuth(clusterURL, username, password)
+// GetToken gets a token for the cluster from its OAuth server
+func (c *Cluster) GetToken(username string, password string) (string, error) {
+	clusterURL := c.LoginURL + authenticationURLSuffix
+	resp, err := c.getBasicAuth(clusterURL, username, password)
 	if err != nil {
 		return "", err
 	}
@@ -137,13 +119,13 @@ func GetToken(host string, username string, password string) (string, error) {
 	return token, nil
 }
 
-func getBasicAuth(url string, username string, password string) (*http.Response, error) {
+func (c *Cluster) getBasicAuth(url string, username string, password string) (*http.Response, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.SetBasicAuth(username, password)
-	return client.Do(req)
+	return c.httpClient().Do(req)
 }
 
 func oauthAuthorizeResult(location string) (string, error) {
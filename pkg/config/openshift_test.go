@@ -25,7 +25,7 @@ func TestCluster_HasValidToken(t *testing.T) {
 		if req.Header.Get("Authorization") == "Bearer "+invalidToken {
 			w.WriteHeader(http.StatusUnauthorized)
 		} else {
-			w.WriteHeader(http.StatusOK)
+			w.Write([]byte(`{"metadata":{"name":"k12345"}}`))
 		}
 	}))
 	defer ts.Close()
@@ -76,7 +76,8 @@ func TestGetToken(t *testing.T) {
 			http.Redirect(w, req, tc.RedirectPath, http.StatusFound)
 		}))
 
-		token, err := GetToken(ts.URL, "", "")
+		cluster := &Cluster{LoginURL: ts.URL}
+		token, err := cluster.GetToken("", "")
 		if err != nil {
 			assert.EqualError(t, err, tc.ExpectedError)
 		}
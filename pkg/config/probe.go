@@ -0,0 +1,198 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// Names of the endpoints checked for each cluster
+const (
+	EndpointBoober       = "boober"
+	EndpointGobo         = "gobo"
+	EndpointClusterLogin = "clusterLogin"
+)
+
+// Types of errors when checking an endpoint
+const (
+	ErrorTypeDNS        = "dns"
+	ErrorTypeTLS        = "tls"
+	ErrorTypeTimeout    = "timeout"
+	ErrorTypeConnection = "connection"
+	ErrorTypeHTTP       = "http"
+)
+
+// Default timeouts when checking if clusters are reachable
+const (
+	DefaultProbeDialTimeout    = 1 * time.Second
+	DefaultProbeRequestTimeout = 5 * time.Second
+)
+
+// ProbeConfig holds the timeouts used when checking if clusters are reachable, as durations like 500ms or 2s
+type ProbeConfig struct {
+	DialTimeout    string `json:"dialTimeout,omitempty"`
+	RequestTimeout string `json:"requestTimeout,omitempty"`
+}
+
+// EndpointStatus is the result of checking that an endpoint of a cluster is reachable
+type EndpointStatus struct {
+	Endpoint   string `json:"endpoint"`
+	URL        string `json:"url"`
+	StatusCode int    `json:"statusCode,omitempty"`
+	LatencyMs  int64  `json:"latencyMs"`
+	ErrorType  string `json:"errorType,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// OK returns true if the endpoint answered without a server error
+func (s EndpointStatus) OK() bool {
+	return s.Error == ""
+}
+
+// prober checks the endpoints of clusters
+type prober struct {
+	ao             *AOConfig
+	dialTimeout    time.Duration
+	requestTimeout time.Duration
+}
+
+func (ao *AOConfig) newProber() *prober {
+	p := &prober{
+		ao:             ao,
+		dialTimeout:    DefaultProbeDialTimeout,
+		requestTimeout: DefaultProbeRequestTimeout,
+	}
+	if ao.Probe != nil {
+		p.dialTimeout = parseTimeout(ao.Probe.DialTimeout, p.dialTimeout)
+		p.requestTimeout = parseTimeout(ao.Probe.RequestTimeout, p.requestTimeout)
+	}
+	return p
+}
+
+func (p *prober) httpClient(tlsConfig *tls.Config) *http.Client {
+	return &http.Client{
+		Timeout: p.requestTimeout,
+		Transport: &http.Transport{
+			Proxy:               http.ProxyFromEnvironment,
+			DialContext:         (&net.Dialer{Timeout: p.dialTimeout}).DialContext,
+			TLSHandshakeTimeout: p.requestTimeout,
+			TLSClientConfig:     tlsConfig,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func parseTimeout(value string, defaultTimeout time.Duration) time.Duration {
+	if value == "" {
+		return defaultTimeout
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil || timeout <= 0 {
+		logrus.Warnf("Invalid probe timeout %q, using %s", value, defaultTimeout)
+		return defaultTimeout
+	}
+	return timeout
+}
+
+// newCluster creates a cluster from service urls, and checks if it is reachable
+func (p *prober) newCluster(name string, urls *ServiceURLs) *Cluster {
+	tlsConfig, err := p.ao.TLSConfig(name)
+	if err != nil {
+		logrus.Warn(err)
+		tlsConfig = defaultTLSConfig()
+	}
+
+	endpoints := p.checkEndpoints(urls, tlsConfig)
+	reachable := true
+	for _, endpoint := range endpoints {
+		if !endpoint.OK() {
+			reachable = false
+		}
+	}
+
+	logrus.WithField("reachable", reachable).Info(urls.BooberURL)
+	return &Cluster{
+		Name:      name,
+		URL:       urls.ClusterURL,
+		LoginURL:  urls.ClusterLoginURL,
+		Reachable: reachable,
+		BooberURL: urls.BooberURL,
+		GoboURL:   urls.GoboURL,
+		Endpoints: endpoints,
+		tlsConfig: tlsConfig,
+	}
+}
+
+// checkEndpoints concurrently checks that Boober, Gobo and the cluster login answer without a server error
+func (p *prober) checkEndpoints(urls *ServiceURLs, tlsConfig *tls.Config) []EndpointStatus {
+	endpoints := []EndpointStatus{
+		{Endpoint: EndpointBoober, URL: urls.BooberURL},
+		{Endpoint: EndpointGobo, URL: urls.GoboURL},
+		{Endpoint: EndpointClusterLogin, URL: urls.ClusterLoginURL},
+	}
+
+	client := p.httpClient(tlsConfig)
+	var wg sync.WaitGroup
+	for i := range endpoints {
+		wg.Add(1)
+		go func(endpoint *EndpointStatus) {
+			defer wg.Done()
+			checkEndpoint(client, endpoint)
+		}(&endpoints[i])
+	}
+	wg.Wait()
+
+	return endpoints
+}
+
+func checkEndpoint(client *http.Client, endpoint *EndpointStatus) {
+	start := time.Now()
+	resp, err := client.Get(endpoint.URL)
+	endpoint.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		endpoint.ErrorType = classifyError(err)
+		endpoint.Error = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+
+	endpoint.StatusCode = resp.StatusCode
+	if resp.StatusCode >= 500 {
+		endpoint.ErrorType = ErrorTypeHTTP
+		endpoint.Error = "server error " + resp.Status
+	}
+}
+
+// classifyError tells if a request failed because of DNS, TLS, a timeout or the connection
+func classifyError(err error) string {
+	var dnsError *net.DNSError
+	if errors.As(err, &dnsError) {
+		return ErrorTypeDNS
+	}
+
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostnameError x509.HostnameError
+	var certificateInvalid x509.CertificateInvalidError
+	var recordHeaderError tls.RecordHeaderError
+	if errors.As(err, &unknownAuthority) || errors.As(err, &hostnameError) ||
+		errors.As(err, &certificateInvalid) || errors.As(err, &recordHeaderError) ||
+		strings.Contains(err.Error(), "tls:") {
+		return ErrorTypeTLS
+	}
+
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return ErrorTypeTimeout
+	}
+
+	return ErrorTypeConnection
+}
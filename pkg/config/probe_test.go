@@ -0,0 +1,82 @@
+package config
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyError(t *testing.T) {
+	dnsError := &url.Error{Op: "Get", URL: "http://unknown", Err: &net.OpError{Op: "dial", Err: &net.DNSError{Name: "unknown", IsNotFound: true}}}
+	assert.Equal(t, ErrorTypeDNS, classifyError(dnsError))
+
+	tlsError := &url.Error{Op: "Get", URL: "https://utv", Err: tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}}
+	assert.Equal(t, ErrorTypeTLS, classifyError(tlsError))
+
+	assert.Equal(t, ErrorTypeConnection, classifyError(errors.New("connection refused")))
+}
+
+func TestParseTimeout(t *testing.T) {
+	assert.Equal(t, 2*time.Second, parseTimeout("", 2*time.Second))
+	assert.Equal(t, 500*time.Millisecond, parseTimeout("500ms", 2*time.Second))
+	assert.Equal(t, 2*time.Second, parseTimeout("soon", 2*time.Second))
+	assert.Equal(t, 2*time.Second, parseTimeout("-1s", 2*time.Second))
+}
+
+func TestProber_CheckEndpoints(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		case "/error":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/login":
+			w.WriteHeader(http.StatusFound)
+		}
+	}))
+	defer server.Close()
+
+	ao := &AOConfig{Probe: &ProbeConfig{RequestTimeout: "50ms"}}
+	endpoints := ao.newProber().checkEndpoints(&ServiceURLs{
+		BooberURL:       server.URL + "/slow",
+		GoboURL:         server.URL + "/error",
+		ClusterLoginURL: server.URL + "/login",
+	}, defaultTLSConfig())
+
+	assert.Equal(t, EndpointBoober, endpoints[0].Endpoint)
+	assert.Equal(t, ErrorTypeTimeout, endpoints[0].ErrorType)
+	assert.False(t, endpoints[0].OK())
+
+	assert.Equal(t, ErrorTypeHTTP, endpoints[1].ErrorType)
+	assert.Equal(t, http.StatusServiceUnavailable, endpoints[1].StatusCode)
+
+	assert.True(t, endpoints[2].OK())
+	assert.Equal(t, http.StatusFound, endpoints[2].StatusCode)
+}
+
+func TestProber_NewCluster(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	urls := &ServiceURLs{
+		BooberURL:       server.URL + "/boober",
+		GoboURL:         server.URL + "/gobo",
+		ClusterURL:      server.URL,
+		ClusterLoginURL: server.URL + "/login",
+	}
+	cluster := (&AOConfig{}).newProber().newCluster("utv", urls)
+	assert.True(t, cluster.Reachable)
+	assert.Len(t, cluster.Endpoints, 3)
+
+	server.Close()
+	cluster = (&AOConfig{}).newProber().newCluster("utv", urls)
+	assert.False(t, cluster.Reachable)
+	assert.Equal(t, ErrorTypeConnection, cluster.Endpoints[0].ErrorType)
+}
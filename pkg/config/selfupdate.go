@@ -0,0 +1,165 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// aoChecksumsPath is the SHA-256 manifest of the ao binaries on the update server, in the format of sha256sum.
+// It is signed like the cluster catalogue.
+const aoChecksumsPath = "/assets/sha256sums"
+
+// previousSuffix is appended to the path of the ao executable to get the path of the previous version
+const previousSuffix = ".previous"
+
+// smokeTestTimeout limits how long the new version of ao may use to show its version before it is applied
+const smokeTestTimeout = 30 * time.Second
+
+// getVerifiedAOClient downloads a new ao client from the update server, and verifies it against the signed SHA-256 manifest
+func getVerifiedAOClient(url, channel string) ([]byte, error) {
+	manifest, err := fetchSignedFromUpdateServer(url, channelPath(channel, aoChecksumsPath), "text/plain")
+	if err != nil {
+		return nil, err
+	}
+	checksums, err := parseChecksums(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	// The manifest holds paths relative to the directory of the channel
+	downloadPath := aoDownloadPathForOS()
+	expected, found := checksums[strings.TrimPrefix(downloadPath, "/assets/")]
+	downloadPath = channelPath(channel, downloadPath)
+	if !found {
+		return nil, errors.Errorf("the checksum manifest has no checksum for %s", downloadPath)
+	}
+
+	data, err := fetchFromUpdateServer(url, downloadPath, "application/octet-stream")
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyChecksum(data, expected); err != nil {
+		return nil, errors.Wrapf(err, "could not verify %s", downloadPath)
+	}
+	return data, nil
+}
+
+// parseChecksums parses lines of "<hex sha256>  <path>", as written by sha256sum
+func parseChecksums(manifest []byte) (map[string]string, error) {
+	checksums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(manifest))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 || len(fields[0]) != sha256.Size*2 {
+			return nil, errors.Errorf("invalid line in checksum manifest: %s", line)
+		}
+		// sha256sum marks files read in binary mode with *
+		checksums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return checksums, scanner.Err()
+}
+
+func verifyChecksum(data []byte, expected string) error {
+	sum := sha256.Sum256(data)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return errors.Errorf("checksum mismatch, expected %s but was %s", expected, actual)
+	}
+	return nil
+}
+
+// replaceExecutable replaces the executable with a new version, after checking that the new version runs and
+// reports the expected version. The replaced executable is kept next to it with the suffix .previous.
+// The new version is written to, and run from, the directory of the executable, since the temp directory may
+// not allow running programs, and the executable can only be replaced by a user that can write to its directory.
+func replaceExecutable(executablePath string, data []byte, expectedVersion string) error {
+	releasePath := executablePath + "_" + "update"
+	if err := ioutil.WriteFile(releasePath, data, 0755); err != nil {
+		// Typically because ao is installed in /usr/bin or /usr/local/bin
+		return errors.Wrapf(err, "could not write the new version of ao to %s, update as a user that can write there", filepath.Dir(executablePath))
+	}
+
+	if err := smokeTest(releasePath, expectedVersion); err != nil {
+		os.Remove(releasePath)
+		return errors.Wrap(err, "the new version of ao does not work, update aborted")
+	}
+
+	previousPath := executablePath + previousSuffix
+	if err := os.Rename(executablePath, previousPath); err != nil {
+		os.Remove(releasePath)
+		return errors.Wrap(err, "could not keep the previous version of ao")
+	}
+	if err := os.Rename(releasePath, executablePath); err != nil {
+		os.Rename(previousPath, executablePath)
+		os.Remove(releasePath)
+		return errors.Wrap(err, "could not replace ao with the new version")
+	}
+	return nil
+}
+
+// smokeTest runs ao version in CI mode with the executable, and checks the version it reports
+func smokeTest(executablePath, expectedVersion string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), smokeTestTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, executablePath, "version", "--json")
+	cmd.Env = append(os.Environ(), EnvCI+"=1")
+	out, err := cmd.Output()
+	if os.IsPermission(err) {
+		return errors.Wrapf(err, "could not run %s, the file system may not allow running programs", executablePath)
+	} else if err != nil {
+		return errors.Wrap(err, "ao version failed")
+	}
+
+	var version AOVersion
+	if err := json.Unmarshal(out, &version); err != nil {
+		return errors.Wrap(err, "unexpected output from ao version")
+	}
+	if version.Version != expectedVersion {
+		return errors.Errorf("expected version %s, but ao reports %s", expectedVersion, version.Version)
+	}
+	logrus.Infof("Smoke test of ao %s passed", version.Version)
+	return nil
+}
+
+// Rollback replaces ao with the version it was updated from, which in turn is kept as the previous version
+func Rollback() error {
+	executablePath, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	return rollbackExecutable(executablePath)
+}
+
+func rollbackExecutable(executablePath string) error {
+	previousPath := executablePath + previousSuffix
+	if _, err := os.Stat(previousPath); err != nil {
+		return errors.Errorf("there is no previous version of ao at %s", previousPath)
+	}
+
+	swapPath := executablePath + "_rollback"
+	if err := os.Rename(executablePath, swapPath); err != nil {
+		return err
+	}
+	if err := os.Rename(previousPath, executablePath); err != nil {
+		os.Rename(swapPath, executablePath)
+		return err
+	}
+	return os.Rename(swapPath, previousPath)
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func fakeAO(version string) []byte {
+	return []byte("#!/bin/sh\necho '{\"version\": \"" + version + "\"}'\n")
+}
+
+func TestParseChecksums(t *testing.T) {
+	manifest := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855  ao\n" +
+		"E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855 *windows/ao.exe\n"
+	checksums, err := parseChecksums([]byte(manifest))
+	assert.NoError(t, err)
+	assert.Equal(t, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", checksums["ao"])
+	assert.Equal(t, checksums["ao"], checksums["windows/ao.exe"])
+	assert.NoError(t, verifyChecksum([]byte{}, checksums["ao"]))
+	assert.Error(t, verifyChecksum([]byte("ao"), checksums["ao"]))
+
+	_, err = parseChecksums([]byte("1234 ao\n"))
+	assert.Error(t, err)
+}
+
+func TestReplaceExecutableAndRollback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao-update")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	executablePath := filepath.Join(dir, "ao")
+	assert.NoError(t, ioutil.WriteFile(executablePath, fakeAO("1.0.0"), 0755))
+
+	err = replaceExecutable(executablePath, fakeAO("1.1.0"), "1.2.0")
+	assert.Error(t, err)
+	data, _ := ioutil.ReadFile(executablePath)
+	assert.Equal(t, fakeAO("1.0.0"), data)
+
+	assert.NoError(t, replaceExecutable(executablePath, fakeAO("1.1.0"), "1.1.0"))
+	data, _ = ioutil.ReadFile(executablePath)
+	assert.Equal(t, fakeAO("1.1.0"), data)
+	data, _ = ioutil.ReadFile(executablePath + previousSuffix)
+	assert.Equal(t, fakeAO("1.0.0"), data)
+
+	assert.NoError(t, rollbackExecutable(executablePath))
+	data, _ = ioutil.ReadFile(executablePath)
+	assert.Equal(t, fakeAO("1.0.0"), data)
+	data, _ = ioutil.ReadFile(executablePath + previousSuffix)
+	assert.Equal(t, fakeAO("1.1.0"), data)
+
+	assert.Error(t, rollbackExecutable(filepath.Join(dir, "missing")))
+}
+
+func TestSmokeTest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao-update")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	notExecutable := filepath.Join(dir, "ao_update")
+	assert.NoError(t, ioutil.WriteFile(notExecutable, fakeAO("1.1.0"), 0644))
+	err = smokeTest(notExecutable, "1.1.0")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "the file system may not allow running programs")
+	}
+}
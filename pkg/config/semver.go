@@ -0,0 +1,101 @@
+package config
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// semver is a semantic version. Versions from git describe, like 10.2.0-3-g1a2b3c4, are
+// parsed as the tagged version with the number of commits after the tag.
+type semver struct {
+	major, minor, patch int
+	prerelease          []string
+	commits             int
+}
+
+var (
+	semverPattern      = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z.-]+))?(?:\+[0-9A-Za-z.-]+)?$`)
+	gitDescribePattern = regexp.MustCompile(`^(.*)-(\d+)-g[0-9a-f]+$`)
+)
+
+func parseSemver(version string) (*semver, error) {
+	version = strings.TrimSuffix(strings.TrimSpace(version), "-dirty")
+
+	commits := 0
+	if match := gitDescribePattern.FindStringSubmatch(version); match != nil {
+		version = match[1]
+		commits, _ = strconv.Atoi(match[2])
+	}
+
+	match := semverPattern.FindStringSubmatch(version)
+	if match == nil {
+		return nil, errors.Errorf("%q is not a semantic version", version)
+	}
+	v := &semver{commits: commits}
+	v.major, _ = strconv.Atoi(match[1])
+	v.minor, _ = strconv.Atoi(match[2])
+	v.patch, _ = strconv.Atoi(match[3])
+	if match[4] != "" {
+		v.prerelease = strings.Split(match[4], ".")
+	}
+	return v, nil
+}
+
+// compare returns -1, 0 or 1 if v is older than, the same as or newer than other, following the semver precedence rules
+func (v *semver) compare(other *semver) int {
+	for _, diff := range []int{v.major - other.major, v.minor - other.minor, v.patch - other.patch} {
+		if diff != 0 {
+			return sign(diff)
+		}
+	}
+	if c := comparePrerelease(v.prerelease, other.prerelease); c != 0 {
+		return c
+	}
+	return sign(v.commits - other.commits)
+}
+
+// comparePrerelease compares pre-release identifiers. A version without pre-release is newer than one with.
+func comparePrerelease(a, b []string) int {
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return 1
+	case len(b) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a) && i < len(b); i++ {
+		an, aErr := strconv.Atoi(a[i])
+		bn, bErr := strconv.Atoi(b[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				return sign(an - bn)
+			}
+		case aErr == nil:
+			// Numeric identifiers have lower precedence than alphanumeric ones
+			return -1
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return sign(len(a) - len(b))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
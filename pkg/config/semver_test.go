@@ -0,0 +1,58 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSemver_Compare(t *testing.T) {
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"v1.0.0-2-g1a2b3c4",
+		"1.0.1",
+		"1.2.0",
+		"1.10.0",
+		"10.0.0",
+	}
+	for i := 1; i < len(ordered); i++ {
+		older, err := parseSemver(ordered[i-1])
+		assert.NoError(t, err)
+		newer, err := parseSemver(ordered[i])
+		assert.NoError(t, err)
+		assert.Equal(t, 1, newer.compare(older), "%s > %s", ordered[i], ordered[i-1])
+		assert.Equal(t, -1, older.compare(newer), "%s < %s", ordered[i-1], ordered[i])
+	}
+
+	a, _ := parseSemver("v2.3.4+build.5")
+	b, _ := parseSemver("2.3.4-dirty")
+	assert.Equal(t, 0, a.compare(b))
+
+	_, err := parseSemver("latest")
+	assert.Error(t, err)
+}
+
+func TestAOVersion_IsNewVersion(t *testing.T) {
+	previous := Version
+	defer func() { Version = previous }()
+
+	Version = "10.2.0"
+	assert.True(t, (&AOVersion{Version: "10.3.0"}).IsNewVersion())
+	assert.False(t, (&AOVersion{Version: "10.2.0"}).IsNewVersion())
+	assert.False(t, (&AOVersion{Version: "9.15.0"}).IsNewVersion())
+	assert.False(t, (&AOVersion{Version: "10.3.0-rc.1-dirty"}).IsNewVersion())
+
+	Version = "10.2.0-4-g1a2b3c4"
+	assert.False(t, (&AOVersion{Version: "10.2.0"}).IsNewVersion())
+	assert.True(t, (&AOVersion{Version: "10.2.1"}).IsNewVersion())
+
+	Version = ""
+	assert.True(t, (&AOVersion{Version: "10.2.0"}).IsNewVersion())
+}
@@ -0,0 +1,56 @@
+package config
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// UpdatePublicKey is the base64 encoded ed25519 public key used to verify files signed on the update server.
+// It is set during build time, see build/build.sh
+var UpdatePublicKey string
+
+// signatureSuffix is appended to the path of a signed file on the update server to get its signature
+const signatureSuffix = ".sig"
+
+// verifySignature verifies a base64 encoded ed25519 signature of data with UpdatePublicKey
+func verifySignature(data, signature []byte) error {
+	if UpdatePublicKey == "" {
+		return errors.New("this version of ao is built without a public key to verify signatures")
+	}
+
+	publicKey, err := base64.StdEncoding.DecodeString(UpdatePublicKey)
+	if err != nil || len(publicKey) != ed25519.PublicKeySize {
+		return errors.New("invalid public key for verifying signatures")
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+	if err != nil {
+		return errors.Wrap(err, "invalid signature")
+	}
+
+	if !ed25519.Verify(publicKey, data, decoded) {
+		return errors.New("signature verification failed")
+	}
+	return nil
+}
+
+// fetchSignedFromUpdateServer fetches a file and its signature from the update server, and verifies the signature
+func fetchSignedFromUpdateServer(url, endpoint, contentType string) ([]byte, error) {
+	data, err := fetchFromUpdateServer(url, endpoint, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := fetchFromUpdateServer(url, endpoint+signatureSuffix, "text/plain")
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get signature of %s", endpoint)
+	}
+
+	if err := verifySignature(data, signature); err != nil {
+		return nil, errors.Wrapf(err, "could not verify %s", endpoint)
+	}
+	return data, nil
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// EnvCABundle is a file with CA certificates to trust in addition to the system CAs, for all clusters
+const EnvCABundle = "AO_CA_BUNDLE"
+
+// InsecureSkipTLSVerify disables verification of server certificates. It is set by the --insecure-skip-tls-verify flag,
+// and is the only way to opt out of TLS verification.
+var InsecureSkipTLSVerify bool
+
+// TLSConfig returns the TLS configuration for a cluster, trusting the system CAs, AO_CA_BUNDLE and the caFile of
+// the cluster, and presenting the client certificate of the cluster if any
+func (ao *AOConfig) TLSConfig(clusterName string) (*tls.Config, error) {
+	var caFiles []string
+	if caBundle := os.Getenv(EnvCABundle); caBundle != "" {
+		caFiles = append(caFiles, caBundle)
+	}
+
+	var certFile, keyFile string
+	if clusterConfig := ao.ClusterConfig[clusterName]; clusterConfig != nil {
+		if clusterConfig.CAFile != "" {
+			caFiles = append(caFiles, clusterConfig.CAFile)
+		}
+		certFile = clusterConfig.ClientCertFile
+		keyFile = clusterConfig.ClientKeyFile
+	}
+
+	tlsConfig, err := newTLSConfig(caFiles, certFile, keyFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid TLS configuration for cluster %s", clusterName)
+	}
+	return tlsConfig, nil
+}
+
+// ConfigureTLS sets the TLS configuration of all clusters, used when calling the cluster. A cluster with an invalid
+// TLS configuration only gives a warning, so that commands using other clusters still work, and calls to it fail.
+func (ao *AOConfig) ConfigureTLS() {
+	if InsecureSkipTLSVerify {
+		logrus.Warn("TLS certificates are not verified")
+	}
+
+	for name, cluster := range ao.Clusters {
+		tlsConfig, err := ao.TLSConfig(name)
+		if err != nil {
+			logrus.Warn(err)
+			cluster.tlsConfig, cluster.tlsErr = nil, err
+			continue
+		}
+		cluster.tlsConfig, cluster.tlsErr = tlsConfig, nil
+	}
+}
+
+// TLSConfig returns the TLS configuration of the cluster, or the default TLS configuration if not configured.
+// If the TLS configuration of the cluster is invalid, every connection made with it fails.
+func (c *Cluster) TLSConfig() *tls.Config {
+	if c.tlsErr != nil {
+		return failingTLSConfig(c.tlsErr)
+	}
+	if c.tlsConfig != nil {
+		return c.tlsConfig
+	}
+	return defaultTLSConfig()
+}
+
+// failingTLSConfig fails the handshake of every connection with err. Normal verification is skipped so that
+// the callback is always reached and reports err rather than an unknown authority.
+func failingTLSConfig(err error) *tls.Config {
+	return &tls.Config{
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func([][]byte, [][]*x509.Certificate) error {
+			return err
+		},
+	}
+}
+
+// defaultTLSConfig trusts the system CAs and AO_CA_BUNDLE, and is used for servers that are not part of a cluster
+func defaultTLSConfig() *tls.Config {
+	var caFiles []string
+	if caBundle := os.Getenv(EnvCABundle); caBundle != "" {
+		caFiles = append(caFiles, caBundle)
+	}
+
+	tlsConfig, err := newTLSConfig(caFiles, "", "")
+	if err != nil {
+		logrus.Warnf("Ignoring %s: %s", EnvCABundle, err)
+		return &tls.Config{InsecureSkipVerify: InsecureSkipTLSVerify}
+	}
+	return tlsConfig
+}
+
+func newTLSConfig(caFiles []string, certFile, keyFile string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: InsecureSkipTLSVerify,
+	}
+
+	if len(caFiles) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		for _, caFile := range caFiles {
+			data, err := ioutil.ReadFile(caFile)
+			if err != nil {
+				return nil, errors.Wrap(err, "could not read CA bundle")
+			}
+			if !pool.AppendCertsFromPEM(data) {
+				return nil, errors.Errorf("no certificates found in %s", caFile)
+			}
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, errors.New("both clientCertFile and clientKeyFile must be set")
+		}
+		certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not load client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{certificate}
+	}
+
+	return tlsConfig, nil
+}
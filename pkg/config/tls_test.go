@@ -0,0 +1,132 @@
+package config
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeServerCA(t *testing.T, server *httptest.Server, dir string) string {
+	caFile := filepath.Join(dir, "ca.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	assert.NoError(t, ioutil.WriteFile(caFile, data, 0600))
+	return caFile
+}
+
+func TestAOConfig_TLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"metadata":{"name":"k12345"}}`))
+	}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "ao-tls")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ao := newClustersTestConfig(server.URL)
+	urls, err := ao.GetServiceURLs("utv")
+	assert.NoError(t, err)
+
+	tlsConfig, err := ao.TLSConfig("utv")
+	assert.NoError(t, err)
+	endpoints := ao.newProber().checkEndpoints(urls, tlsConfig)
+	assert.Equal(t, ErrorTypeTLS, endpoints[0].ErrorType)
+
+	ao.ClusterConfig["utv"].CAFile = writeServerCA(t, server, dir)
+	tlsConfig, err = ao.TLSConfig("utv")
+	assert.NoError(t, err)
+	endpoints = ao.newProber().checkEndpoints(urls, tlsConfig)
+	assert.True(t, endpoints[0].OK())
+
+	ao.ConfigureTLS()
+	cluster := ao.Clusters["utv"]
+	cluster.URL = server.URL
+	assert.True(t, cluster.HasValidToken())
+}
+
+func TestAOConfig_TLSConfigFromEnvironment(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "ao-tls")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	os.Setenv(EnvCABundle, writeServerCA(t, server, dir))
+	defer os.Unsetenv(EnvCABundle)
+
+	cluster := &Cluster{}
+	resp, err := cluster.httpClient().Get(server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestAOConfig_InsecureSkipTLSVerify(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	cluster := &Cluster{}
+	_, err := cluster.httpClient().Get(server.URL)
+	assert.Error(t, err)
+
+	InsecureSkipTLSVerify = true
+	defer func() { InsecureSkipTLSVerify = false }()
+
+	resp, err := cluster.httpClient().Get(server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestAOConfig_InvalidTLSConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ao-tls")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	notPEM := filepath.Join(dir, "ca.pem")
+	assert.NoError(t, ioutil.WriteFile(notPEM, []byte("not a certificate"), 0600))
+
+	ao := &AOConfig{
+		ClusterConfig: map[string]*ClusterConfig{
+			"missing":  {CAFile: filepath.Join(dir, "missing.pem")},
+			"invalid":  {CAFile: notPEM},
+			"onlycert": {ClientCertFile: notPEM},
+		},
+	}
+
+	for name := range ao.ClusterConfig {
+		_, err := ao.TLSConfig(name)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestAOConfig_ConfigureTLSWithInvalidCluster(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "ao-tls")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ao := &AOConfig{
+		ClusterConfig: map[string]*ClusterConfig{
+			"utv":  {CAFile: writeServerCA(t, server, dir)},
+			"test": {CAFile: filepath.Join(dir, "missing.pem")},
+		},
+		Clusters: map[string]*Cluster{
+			"utv":  {Name: "utv"},
+			"test": {Name: "test"},
+		},
+	}
+	ao.ConfigureTLS()
+
+	resp, err := ao.Clusters["utv"].httpClient().Get(server.URL)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	_, err = ao.Clusters["test"].httpClient().Get(server.URL)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "invalid TLS configuration for cluster test")
+	}
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// TokenExpiryWarning is how long before a token expires ao starts warning about it
+const TokenExpiryWarning = 30 * time.Minute
+
+const (
+	openShiftUserPath             = "/apis/user.openshift.io/v1/users/~"
+	openShiftAccessTokenPath      = "/apis/oauth.openshift.io/v1/useroauthaccesstokens/"
+	openShiftSHA256TokenPrefix    = "sha256~"
+	openShiftTokenRequestTimeout  = 5 * time.Second
+	openShiftTokenInfoConcurrency = 10
+)
+
+// UpdateTokenInfo checks the token of each reachable cluster, and records who it belongs to and when it expires
+func (ao *AOConfig) UpdateTokenInfo() {
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, openShiftTokenInfoConcurrency)
+	for name, cluster := range ao.Clusters {
+		if !cluster.Reachable {
+			continue
+		}
+		if err := ao.ResolveClusterToken(name); err != nil {
+			logrus.WithField("cluster", name).Debug(err)
+			continue
+		}
+		wg.Add(1)
+		go func(c *Cluster) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			c.UpdateTokenInfo()
+		}(cluster)
+	}
+	wg.Wait()
+}
+
+// UpdateTokenInfo checks that the token is valid, like HasValidToken, and records the user it belongs to and when
+// it expires. The expiry is left empty if OpenShift does not tell it. Returns false if the token is not valid.
+func (c *Cluster) UpdateTokenInfo() bool {
+	c.Username = ""
+	c.TokenExpiry = nil
+
+	username, err := c.currentUser()
+	if err != nil {
+		logrus.WithField("cluster", c.Name).Debug("Token is not valid: ", err)
+		return false
+	}
+	c.Username = username
+
+	expiry, err := c.tokenExpiry()
+	if err != nil {
+		logrus.WithField("cluster", c.Name).Debug("Unknown token expiry: ", err)
+	}
+	c.TokenExpiry = expiry
+	return true
+}
+
+// currentUser gets the name of the user the token belongs to, which fails if the token is not valid
+func (c *Cluster) currentUser() (string, error) {
+	if c.Token == "" {
+		return "", errors.New("no token")
+	}
+
+	var user struct {
+		Metadata struct {
+			Name string `json:"name"`
+		} `json:"metadata"`
+	}
+	if err := c.getOpenShiftResource(openShiftUserPath, &user); err != nil {
+		return "", err
+	}
+	return user.Metadata.Name, nil
+}
+
+// TokenExpiresIn returns the time until the token expires, and false if the expiry is not known
+func (c *Cluster) TokenExpiresIn() (time.Duration, bool) {
+	if c.Token == "" || c.TokenExpiry == nil {
+		return 0, false
+	}
+	return time.Until(*c.TokenExpiry), true
+}
+
+// TokenExpiresWithin returns true if the token is known to expire within the duration
+func (c *Cluster) TokenExpiresWithin(d time.Duration) bool {
+	left, known := c.TokenExpiresIn()
+	return known && left < d
+}
+
+// tokenExpiry gets the expiry of the token from the OAuth access token resource of the user.
+// The resource is named by a hash of the token, which is only known for tokens with the sha256~ prefix.
+func (c *Cluster) tokenExpiry() (*time.Time, error) {
+	if !strings.HasPrefix(c.Token, openShiftSHA256TokenPrefix) {
+		return nil, errors.New("the token does not have the sha256~ prefix")
+	}
+	hash := sha256.Sum256([]byte(strings.TrimPrefix(c.Token, openShiftSHA256TokenPrefix)))
+	name := openShiftSHA256TokenPrefix + base64.RawURLEncoding.EncodeToString(hash[:])
+
+	var accessToken struct {
+		Metadata struct {
+			CreationTimestamp time.Time `json:"creationTimestamp"`
+		} `json:"metadata"`
+		ExpiresIn int64 `json:"expiresIn"`
+	}
+	if err := c.getOpenShiftResource(openShiftAccessTokenPath+name, &accessToken); err != nil {
+		return nil, err
+	}
+	if accessToken.ExpiresIn <= 0 {
+		// The token does not expire
+		return nil, nil
+	}
+	expiry := accessToken.Metadata.CreationTimestamp.Add(time.Duration(accessToken.ExpiresIn) * time.Second)
+	return &expiry, nil
+}
+
+func (c *Cluster) getOpenShiftResource(path string, resource interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, c.URL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Authorization", "Bearer "+c.Token)
+	req.Header.Add("Accept", "application/json")
+
+	client := c.httpClient()
+	client.Timeout = openShiftTokenRequestTimeout
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.Errorf("%s returned %d", req.URL, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(resource)
+}
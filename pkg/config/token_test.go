@@ -0,0 +1,76 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newOpenShiftTestServer(token string, created time.Time, expiresIn int64) *httptest.Server {
+	hash := sha256.Sum256([]byte("secret"))
+	tokenName := openShiftSHA256TokenPrefix + base64.RawURLEncoding.EncodeToString(hash[:])
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(openShiftUserPath, func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"metadata": map[string]string{"name": "k12345"}})
+	})
+	mux.HandleFunc(openShiftAccessTokenPath+tokenName, func(w http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"metadata":  map[string]interface{}{"creationTimestamp": created.Format(time.RFC3339)},
+			"expiresIn": expiresIn,
+		})
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestCluster_UpdateTokenInfo(t *testing.T) {
+	created := time.Now().Add(-time.Hour).Truncate(time.Second)
+	ts := newOpenShiftTestServer("sha256~secret", created, 86400)
+	defer ts.Close()
+
+	cluster := &Cluster{Name: "utv", URL: ts.URL, Token: "sha256~secret"}
+	assert.True(t, cluster.UpdateTokenInfo())
+	assert.Equal(t, "k12345", cluster.Username)
+	assert.True(t, created.Add(24*time.Hour).Equal(*cluster.TokenExpiry))
+	assert.False(t, cluster.TokenExpiresWithin(TokenExpiryWarning))
+	assert.True(t, cluster.TokenExpiresWithin(24*time.Hour))
+
+	cluster.Token = "sha256~other"
+	assert.False(t, cluster.UpdateTokenInfo())
+	assert.Empty(t, cluster.Username)
+	assert.Nil(t, cluster.TokenExpiry)
+}
+
+func TestCluster_UpdateTokenInfoWithoutExpiry(t *testing.T) {
+	ts := newOpenShiftTestServer("legacy-token", time.Now(), 86400)
+	defer ts.Close()
+
+	cluster := &Cluster{Name: "utv", URL: ts.URL, Token: "legacy-token"}
+	assert.True(t, cluster.UpdateTokenInfo())
+	assert.Equal(t, "k12345", cluster.Username)
+	_, known := cluster.TokenExpiresIn()
+	assert.False(t, known)
+	assert.False(t, cluster.TokenExpiresWithin(TokenExpiryWarning))
+}
+
+func TestAOConfig_ActivateContextClearsTokenInfo(t *testing.T) {
+	ao := newContextTestConfig()
+	expiry := time.Now().Add(time.Hour)
+	ao.Clusters["utv"].Username = "k12345"
+	ao.Clusters["utv"].TokenExpiry = &expiry
+	ao.Contexts = map[string]*Context{"other": {Tokens: map[string]string{"utv": "other-token"}}}
+
+	assert.NoError(t, ao.ActivateContext("other"))
+	assert.Empty(t, ao.Clusters["utv"].Username)
+	assert.Nil(t, ao.Clusters["utv"].TokenExpiry)
+}
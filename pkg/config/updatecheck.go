@@ -0,0 +1,106 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// updateCheckInterval is how often ao looks for a new version to notify about
+const updateCheckInterval = 24 * time.Hour
+
+// updateCheckTimeout limits the time used looking for a new version to notify about, so that commands are not delayed
+const updateCheckTimeout = 3 * time.Second
+
+// updateCheckFileName is the name of the file caching the last update check, next to the config file
+const updateCheckFileName = ".ao-update-check.json"
+
+// UpdateCheck is the cached result of the last check for a new version of ao
+type UpdateCheck struct {
+	CheckedAt     time.Time `json:"checkedAt"`
+	Channel       string    `json:"channel"`
+	LatestVersion string    `json:"latestVersion,omitempty"`
+}
+
+// Channel returns the release channel used for updates, which is stable unless beta is selected
+func (ao *AOConfig) Channel() string {
+	if ao.UpdateChannel == "" {
+		return UpdateChannelStable
+	}
+	return ao.UpdateChannel
+}
+
+// SetUpdateChannel selects the release channel used for updates
+func (ao *AOConfig) SetUpdateChannel(channel string) error {
+	if channel != UpdateChannelStable && channel != UpdateChannelBeta {
+		return errors.Errorf("unknown update channel %s, use %s or %s", channel, UpdateChannelStable, UpdateChannelBeta)
+	}
+	ao.UpdateChannel = channel
+	return nil
+}
+
+// CheckForUpdate gets the latest version of the release channel from the update server
+func (ao *AOConfig) CheckForUpdate() (*AOVersion, error) {
+	return ao.checkForUpdate(0)
+}
+
+func (ao *AOConfig) checkForUpdate(timeout time.Duration) (*AOVersion, error) {
+	url, err := ao.getUpdateURL()
+	if err != nil {
+		return nil, err
+	}
+	return getVersionFromServer(url, ao.Channel(), timeout)
+}
+
+// UpdateCheckLocation returns the location of the update check cache for a config file
+func UpdateCheckLocation(configLocation string) string {
+	return filepath.Join(filepath.Dir(configLocation), updateCheckFileName)
+}
+
+// NewVersionNotice returns a notice if a newer version of ao is available, or an empty string.
+// The update server is asked at most once a day, and the answer is cached in cacheLocation.
+func (ao *AOConfig) NewVersionNotice(cacheLocation string, now time.Time) string {
+	check := readUpdateCheck(cacheLocation)
+	if check == nil || check.Channel != ao.Channel() || now.Sub(check.CheckedAt) > updateCheckInterval || now.Before(check.CheckedAt) {
+		check = &UpdateCheck{CheckedAt: now, Channel: ao.Channel()}
+		if version, err := ao.checkForUpdate(updateCheckTimeout); err != nil {
+			logrus.Debug("Could not check for new version: ", err)
+		} else {
+			check.LatestVersion = version.Version
+		}
+		writeUpdateCheck(cacheLocation, check)
+	}
+
+	latest := AOVersion{Version: check.LatestVersion}
+	if check.LatestVersion == "" || !latest.IsNewVersion() {
+		return ""
+	}
+	return fmt.Sprintf("A new version of AO is available (%s -> %s). Run \"ao update\" to update.", Version, check.LatestVersion)
+}
+
+func readUpdateCheck(location string) *UpdateCheck {
+	data, err := ioutil.ReadFile(location)
+	if err != nil {
+		return nil
+	}
+	var check UpdateCheck
+	if err := json.Unmarshal(data, &check); err != nil {
+		return nil
+	}
+	return &check
+}
+
+func writeUpdateCheck(location string, check *UpdateCheck) {
+	data, err := json.Marshal(check)
+	if err == nil {
+		err = writeFileAtomic(location, data)
+	}
+	if err != nil {
+		logrus.Debug("Could not cache update check: ", err)
+	}
+}
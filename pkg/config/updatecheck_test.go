@@ -0,0 +1,66 @@
+package config
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newUpdateCheckTestConfig(serverURL string) *AOConfig {
+	return &AOConfig{
+		AvailableUpdateClusters: []string{"utv"},
+		Clusters:                map[string]*Cluster{"utv": {Name: "utv", Reachable: true}},
+		ServiceURLPatterns: map[string]*ServiceURLPatterns{
+			"test": {UpdateURLPattern: serverURL + "/%s/update"},
+		},
+		ClusterConfig: map[string]*ClusterConfig{"utv": {Type: "test"}},
+	}
+}
+
+func TestAOConfig_NewVersionNotice(t *testing.T) {
+	previous := Version
+	defer func() { Version = previous }()
+	Version = "10.2.0"
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.URL.Path {
+		case "/utv/update" + aoCurrentVersionPath:
+			w.Write([]byte(`{"version": "10.3.0"}`))
+		case "/utv/update" + channelPath(UpdateChannelBeta, aoCurrentVersionPath):
+			w.Write([]byte(`{"version": "11.0.0-beta.1"}`))
+		}
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "ao-update-check")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cache := UpdateCheckLocation(filepath.Join(dir, ".ao.json"))
+
+	ao := newUpdateCheckTestConfig(server.URL)
+	now := time.Now()
+	assert.Contains(t, ao.NewVersionNotice(cache, now), "10.2.0 -> 10.3.0")
+	assert.Contains(t, ao.NewVersionNotice(cache, now.Add(time.Hour)), "10.2.0 -> 10.3.0")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	Version = "10.3.0"
+	assert.Empty(t, ao.NewVersionNotice(cache, now.Add(2*time.Hour)))
+
+	assert.NoError(t, ao.SetUpdateChannel(UpdateChannelBeta))
+	assert.Contains(t, ao.NewVersionNotice(cache, now.Add(3*time.Hour)), "10.3.0 -> 11.0.0-beta.1")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+
+	assert.Contains(t, ao.NewVersionNotice(cache, now.Add(30*time.Hour)), "11.0.0-beta.1")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+
+	assert.Error(t, ao.SetUpdateChannel("nightly"))
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -41,19 +42,47 @@ type AOVersion struct {
 	BuildStamp string `json:"buildStamp"`
 }
 
-// IsNewVersion checks if version is new
+// Release channels of ao on the update server
+const (
+	UpdateChannelStable = "stable"
+	UpdateChannelBeta   = "beta"
+)
+
+// IsNewVersion checks if version is newer than the running version of ao, comparing them as semantic versions
 func (v *AOVersion) IsNewVersion() bool {
 	// No new version if current version from server is dirty
 	if strings.Contains(v.Version, "-dirty") {
 		return false
 	}
-	// TODO: Should do better check then this
-	return v.Version != Version
+	serverVersion, err := parseSemver(v.Version)
+	if err != nil {
+		logrus.Debug(err)
+		return false
+	}
+	currentVersion, err := parseSemver(Version)
+	if err != nil {
+		// Development builds without a release version can always be updated
+		return true
+	}
+	return serverVersion.compare(currentVersion) > 0
+}
+
+// channelPath returns the path of a file on the update server in a release channel. The stable channel
+// is published in /assets, and the beta channel in /assets/beta.
+func channelPath(channel, path string) string {
+	if channel == UpdateChannelBeta {
+		return strings.Replace(path, "/assets/", "/assets/beta/", 1)
+	}
+	return path
 }
 
-// GetCurrentVersionFromServer gets current (latest release) version from server
-func GetCurrentVersionFromServer(url string) (*AOVersion, error) {
-	data, err := fetchFromUpdateServer(url, aoCurrentVersionPath, "application/json")
+// GetCurrentVersionFromServer gets current (latest release) version of a channel from server
+func GetCurrentVersionFromServer(url, channel string) (*AOVersion, error) {
+	return getVersionFromServer(url, channel, 0)
+}
+
+func getVersionFromServer(url, channel string, timeout time.Duration) (*AOVersion, error) {
+	data, err := fetchFromUpdateServerWithTimeout(url, channelPath(channel, aoCurrentVersionPath), "application/json", timeout)
 	if err != nil {
 		return nil, err
 	}
@@ -67,20 +96,27 @@ func GetCurrentVersionFromServer(url string) (*AOVersion, error) {
 	return &aoVersion, nil
 }
 
-// GetNewAOClient downloads a new ao client from update server
-func GetNewAOClient(url string) ([]byte, error) {
-	var downloadPath string
-	downloadPath = aoDownloadPath
-	if runtime.GOOS == "darwin" {
-		downloadPath = aoDownloadPathMacOs
-	}
-	if runtime.GOOS == "windows" {
-		downloadPath = aoDownloadPathWindows
+// GetNewAOClient downloads a new ao client of a channel from update server, verified against the signed checksum manifest
+func GetNewAOClient(url, channel string) ([]byte, error) {
+	return getVerifiedAOClient(url, channel)
+}
+
+func aoDownloadPathForOS() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return aoDownloadPathMacOs
+	case "windows":
+		return aoDownloadPathWindows
 	}
-	return fetchFromUpdateServer(url, downloadPath, "application/octet-stream")
+	return aoDownloadPath
 }
 
 func fetchFromUpdateServer(url, endpoint, contentType string) ([]byte, error) {
+	return fetchFromUpdateServerWithTimeout(url, endpoint, contentType, 0)
+}
+
+// fetchFromUpdateServerWithTimeout fetches a file from the update server. A zero timeout means no timeout.
+func fetchFromUpdateServerWithTimeout(url, endpoint, contentType string, timeout time.Duration) ([]byte, error) {
 	logrus.WithField("url", url).WithField("endpoint", endpoint).Info("Request")
 	req, err := http.NewRequest(http.MethodGet, url+endpoint, nil)
 	if err != nil {
@@ -88,7 +124,9 @@ func fetchFromUpdateServer(url, endpoint, contentType string) ([]byte, error) {
 	}
 
 	req.Header.Set("Content-Type", contentType)
-	res, err := http.DefaultClient.Do(req)
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = defaultTLSConfig()
+	res, err := (&http.Client{Transport: transport, Timeout: timeout}).Do(req)
 	if err != nil {
 		return nil, err
 	}
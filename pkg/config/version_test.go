@@ -1,11 +1,17 @@
 package config
 
 import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
-	"github.com/stretchr/testify/assert"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGetCurrentVersionFromServer(t *testing.T) {
@@ -36,7 +42,7 @@ func TestGetCurrentVersionFromServer(t *testing.T) {
 		}))
 		defer ts.Close()
 
-		newVersion, err := GetCurrentVersionFromServer(ts.URL)
+		newVersion, err := GetCurrentVersionFromServer(ts.URL, UpdateChannelStable)
 		assert.NoError(t, err)
 
 		assert.Equal(t, "1.3.0", newVersion.Version)
@@ -59,7 +65,7 @@ func TestGetCurrentVersionFromServer(t *testing.T) {
 		}))
 		defer ts.Close()
 
-		newVersion, err := GetCurrentVersionFromServer(ts.URL)
+		newVersion, err := GetCurrentVersionFromServer(ts.URL, UpdateChannelStable)
 		assert.NoError(t, err)
 
 		assert.Equal(t, "2.15.0-dirty", newVersion.Version)
@@ -82,7 +88,7 @@ func TestGetCurrentVersionFromServer(t *testing.T) {
 		}))
 		defer ts.Close()
 
-		newVersion, err := GetCurrentVersionFromServer(ts.URL)
+		newVersion, err := GetCurrentVersionFromServer(ts.URL, UpdateChannelStable)
 		assert.NoError(t, err)
 
 		assert.Equal(t, "1.3.0", newVersion.Version)
@@ -91,15 +97,42 @@ func TestGetCurrentVersionFromServer(t *testing.T) {
 }
 
 func TestGetNewAOClient(t *testing.T) {
-	t.Run("Should get new ao version", func(t *testing.T) {
-		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
-			w.WriteHeader(http.StatusOK)
-			w.Write([]byte("1"))
+	privateKey := newSigningKey(t)
+	binary := []byte("1")
+	sum := sha256.Sum256(binary)
+	downloadPath := aoDownloadPathForOS()
+
+	newServer := func(manifest string) *httptest.Server {
+		signature := base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, []byte(manifest)))
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			switch req.URL.Path {
+			case aoChecksumsPath:
+				w.Write([]byte(manifest))
+			case aoChecksumsPath + signatureSuffix:
+				w.Write([]byte(signature))
+			case downloadPath:
+				w.Write(binary)
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
 		}))
+	}
+
+	t.Run("Should get new ao version", func(t *testing.T) {
+		ts := newServer(hex.EncodeToString(sum[:]) + "  " + strings.TrimPrefix(downloadPath, "/assets/") + "\n")
 		defer ts.Close()
 
-		newAO, err := GetNewAOClient(ts.URL)
+		newAO, err := GetNewAOClient(ts.URL, UpdateChannelStable)
 		assert.NoError(t, err)
-		assert.NotEmpty(t, newAO)
+		assert.Equal(t, binary, newAO)
+	})
+
+	t.Run("Should not get ao with wrong checksum", func(t *testing.T) {
+		otherSum := sha256.Sum256([]byte("2"))
+		ts := newServer(hex.EncodeToString(otherSum[:]) + "  " + strings.TrimPrefix(downloadPath, "/assets/") + "\n")
+		defer ts.Close()
+
+		_, err := GetNewAOClient(ts.URL, UpdateChannelStable)
+		assert.Error(t, err)
 	})
 }
@@ -34,6 +34,8 @@ type (
 	Editor struct {
 		OpenEditor func(string) error
 		OnSave     OnSaveFunc
+		// Help is shown as comments below the content, e.g. to document the allowed keys
+		Help []string
 	}
 )
 
@@ -66,7 +68,7 @@ func (e Editor) Edit(content string, name string) error {
 	var done bool
 	for !done {
 		previousContent := currentContent
-		contentToEdit := fmt.Sprintf(editPattern, name, editErrors, currentContent)
+		contentToEdit := fmt.Sprintf(editPattern, name, editErrors, currentContent) + addHelp(e.Help)
 		if runtime.GOOS == "windows" {
 			contentToEdit, _, err = transform.String(crlf.ToCRLF{}, contentToEdit)
 		}
@@ -176,3 +178,15 @@ func addErrorMessage(errorMessage string) string {
 
 	return comments + "##\n"
 }
+
+func addHelp(help []string) string {
+	if len(help) == 0 {
+		return ""
+	}
+
+	comments := "##\n"
+	for _, line := range help {
+		comments += fmt.Sprintf("## %s\n", line)
+	}
+	return comments
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"strings"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -98,6 +99,29 @@ func TestAddComments(t *testing.T) {
 	assert.Equal(t, expected, errs)
 }
 
+func TestAddHelp(t *testing.T) {
+	assert.Equal(t, "", addHelp(nil))
+	assert.Equal(t, "##\n## Fields of this file:\n##   version\n", addHelp([]string{"Fields of this file:", "  version"}))
+}
+
+func TestEditor_EditWithHelp(t *testing.T) {
+	fileEditor := NewEditor(func(modifiedContent string) error {
+		assert.Equal(t, `{"version":"1.0.0"}`, modifiedContent)
+		return nil
+	})
+	fileEditor.Help = []string{"version  string  Version of the artifact to deploy"}
+	fileEditor.OpenEditor = func(tempFile string) error {
+		data, err := ioutil.ReadFile(tempFile)
+		assert.NoError(t, err)
+		assert.Contains(t, string(data), "{}\n##\n## version  string  Version of the artifact to deploy\n")
+
+		edited := strings.Replace(string(data), "{}", `{"version":"1.0.0"}`, 1)
+		return ioutil.WriteFile(tempFile, []byte(edited), 0700)
+	}
+
+	assert.NoError(t, fileEditor.Edit("{}", "foo.json"))
+}
+
 func TestStripComments(t *testing.T) {
 
 	content := `## Name: foo.json
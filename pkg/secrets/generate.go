@@ -0,0 +1,143 @@
+package secrets
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// Types of secrets that can be generated
+const (
+	TypePassword           = "password"
+	TypeHex                = "hex"
+	TypeRSA                = "rsa"
+	TypeEd25519            = "ed25519"
+	TypePropertiesTemplate = "properties-template"
+)
+
+// Types lists all types of secrets that can be generated
+var Types = []string{TypePassword, TypeHex, TypeRSA, TypeEd25519, TypePropertiesTemplate}
+
+const passwordCharacters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_.~"
+
+const (
+	defaultLength  = 32
+	defaultRSABits = 4096
+	minRSABits     = 2048
+)
+
+// GenerateOptions configures the generated secret material
+type GenerateOptions struct {
+	Type string
+	// Length is number of characters for passwords and number of random bytes for hex
+	Length int
+	// Bits is the size of RSA keys
+	Bits int
+	// Keys are the property names in a properties template
+	Keys []string
+}
+
+// GeneratedFile is a secret file generated in memory
+type GeneratedFile struct {
+	Name    string
+	Content []byte
+}
+
+// Generate creates secret material with crypto/rand. Key pairs are returned as two files,
+// the private key with the given name and the public key with a .pub suffix.
+func Generate(name string, options GenerateOptions) ([]GeneratedFile, error) {
+	length := options.Length
+	if length == 0 {
+		length = defaultLength
+	}
+	if length < 0 {
+		return nil, errors.Errorf("length must be positive, was %d", length)
+	}
+
+	switch options.Type {
+	case TypePassword:
+		password, err := generatePassword(length)
+		if err != nil {
+			return nil, err
+		}
+		return []GeneratedFile{{Name: name, Content: []byte(password)}}, nil
+	case TypeHex:
+		data := make([]byte, length)
+		if _, err := rand.Read(data); err != nil {
+			return nil, err
+		}
+		return []GeneratedFile{{Name: name, Content: []byte(hex.EncodeToString(data))}}, nil
+	case TypeRSA:
+		bits := options.Bits
+		if bits == 0 {
+			bits = defaultRSABits
+		}
+		if bits < minRSABits {
+			return nil, errors.Errorf("RSA keys must be at least %d bits", minRSABits)
+		}
+		key, err := rsa.GenerateKey(rand.Reader, bits)
+		if err != nil {
+			return nil, err
+		}
+		return encodeKeyPair(name, key, &key.PublicKey)
+	case TypeEd25519:
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		return encodeKeyPair(name, privateKey, publicKey)
+	case TypePropertiesTemplate:
+		keys := options.Keys
+		if len(keys) == 0 {
+			keys = []string{"PASSWORD"}
+		}
+		var buffer bytes.Buffer
+		for _, key := range keys {
+			password, err := generatePassword(length)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&buffer, "%s=%s\n", key, password)
+		}
+		return []GeneratedFile{{Name: name, Content: buffer.Bytes()}}, nil
+	default:
+		return nil, errors.Errorf("unknown secret type %s, must be one of %v", options.Type, Types)
+	}
+}
+
+func generatePassword(length int) (string, error) {
+	max := big.NewInt(int64(len(passwordCharacters)))
+	password := make([]byte, length)
+	for i := range password {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		password[i] = passwordCharacters[n.Int64()]
+	}
+	return string(password), nil
+}
+
+func encodeKeyPair(name string, privateKey, publicKey interface{}) ([]GeneratedFile, error) {
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		return nil, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return []GeneratedFile{
+		{Name: name, Content: pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})},
+		{Name: name + ".pub", Content: pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})},
+	}, nil
+}
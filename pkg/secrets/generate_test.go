@@ -0,0 +1,72 @@
+package secrets
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Generate(t *testing.T) {
+	t.Run("Should generate password with default length", func(t *testing.T) {
+		files, err := Generate("db-password", GenerateOptions{Type: TypePassword})
+		assert.NoError(t, err)
+		assert.Len(t, files, 1)
+		assert.Equal(t, "db-password", files[0].Name)
+		assert.Len(t, files[0].Content, defaultLength)
+		assert.Regexp(t, regexp.MustCompile(`^[A-Za-z0-9\-_.~]+$`), string(files[0].Content))
+	})
+
+	t.Run("Should generate hex of given number of bytes", func(t *testing.T) {
+		files, err := Generate("key", GenerateOptions{Type: TypeHex, Length: 16})
+		assert.NoError(t, err)
+		assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{32}$`), string(files[0].Content))
+	})
+
+	t.Run("Should generate rsa key pair", func(t *testing.T) {
+		files, err := Generate("id_rsa", GenerateOptions{Type: TypeRSA, Bits: 2048})
+		assert.NoError(t, err)
+		assert.Len(t, files, 2)
+		assert.Equal(t, "id_rsa.pub", files[1].Name)
+
+		block, _ := pem.Decode(files[0].Content)
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		assert.NoError(t, err)
+		assert.IsType(t, &rsa.PrivateKey{}, key)
+	})
+
+	t.Run("Should refuse weak rsa keys", func(t *testing.T) {
+		_, err := Generate("id_rsa", GenerateOptions{Type: TypeRSA, Bits: 1024})
+		assert.Error(t, err)
+	})
+
+	t.Run("Should generate ed25519 key pair", func(t *testing.T) {
+		files, err := Generate("id_ed25519", GenerateOptions{Type: TypeEd25519})
+		assert.NoError(t, err)
+		assert.Len(t, files, 2)
+
+		block, _ := pem.Decode(files[1].Content)
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		assert.NoError(t, err)
+		assert.IsType(t, ed25519.PublicKey{}, key)
+	})
+
+	t.Run("Should generate properties template with a password for each key", func(t *testing.T) {
+		files, err := Generate("latest.properties", GenerateOptions{Type: TypePropertiesTemplate, Keys: []string{"DB_PASSWORD", "API_KEY"}, Length: 20})
+		assert.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(files[0].Content)), "\n")
+		assert.Len(t, lines, 2)
+		assert.Regexp(t, regexp.MustCompile(`^DB_PASSWORD=.{20}$`), lines[0])
+		assert.Regexp(t, regexp.MustCompile(`^API_KEY=.{20}$`), lines[1])
+	})
+
+	t.Run("Should fail on unknown type", func(t *testing.T) {
+		_, err := Generate("secret", GenerateOptions{Type: "dsa"})
+		assert.Error(t, err)
+	})
+}
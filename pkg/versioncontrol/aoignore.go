@@ -0,0 +1,141 @@
+package versioncontrol
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// AoIgnoreFileName is the file in the root of the repo listing files that are not part of the AuroraConfig
+const AoIgnoreFileName = ".aoignore"
+
+// AoIgnore holds the patterns of an .aoignore file. The patterns use the syntax of .gitignore.
+type AoIgnore struct {
+	patterns []ignorePattern
+}
+
+type ignorePattern struct {
+	regexp  *regexp.Regexp
+	negate  bool
+	dirOnly bool
+}
+
+// LoadAoIgnore loads the .aoignore file in gitRoot. A missing file ignores nothing.
+func LoadAoIgnore(gitRoot string) (*AoIgnore, error) {
+	file, err := os.Open(filepath.Join(gitRoot, AoIgnoreFileName))
+	if os.IsNotExist(err) {
+		return &AoIgnore{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	ignore, err := ParseAoIgnore(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not read "+AoIgnoreFileName)
+	}
+	return ignore, nil
+}
+
+// ParseAoIgnore parses patterns in the syntax of .gitignore, one on each line
+func ParseAoIgnore(in io.Reader) (*AoIgnore, error) {
+	ignore := &AoIgnore{}
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var pattern ignorePattern
+		if strings.HasPrefix(line, "!") {
+			pattern.negate = true
+			line = line[1:]
+		} else if strings.HasPrefix(line, `\`) {
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			pattern.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if line == "" {
+			continue
+		}
+
+		// Patterns without a slash match at any depth, other patterns are relative to the root
+		anchored := strings.Contains(line, "/")
+		line = strings.TrimPrefix(line, "/")
+		expr := globToRegexp(line)
+		if !anchored {
+			expr = "(.*/)?" + expr
+		}
+
+		re, err := regexp.Compile("^" + expr + "$")
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern %s", scanner.Text())
+		}
+		pattern.regexp = re
+		ignore.patterns = append(ignore.patterns, pattern)
+	}
+	return ignore, scanner.Err()
+}
+
+// Ignored is true when the last pattern matching the file, or one of its folders, is not negated
+func (i *AoIgnore) Ignored(name string) bool {
+	name = filepath.ToSlash(name)
+	var dirs []string
+	for dir := name; strings.Contains(dir, "/"); {
+		dir = dir[:strings.LastIndex(dir, "/")]
+		dirs = append(dirs, dir)
+	}
+
+	ignored := false
+	for _, pattern := range i.patterns {
+		matches := !pattern.dirOnly && pattern.regexp.MatchString(name)
+		for _, dir := range dirs {
+			matches = matches || pattern.regexp.MatchString(dir)
+		}
+		if matches {
+			ignored = !pattern.negate
+		}
+	}
+	return ignored
+}
+
+// globToRegexp translates a glob with *, ?, ** and character classes to a regular expression
+func globToRegexp(glob string) string {
+	var expr strings.Builder
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case strings.HasPrefix(glob[i:], "**/"):
+			expr.WriteString("(.*/)?")
+			i += 2
+		case strings.HasPrefix(glob[i:], "**"):
+			expr.WriteString(".*")
+			i++
+		case c == '*':
+			expr.WriteString("[^/]*")
+		case c == '?':
+			expr.WriteString("[^/]")
+		case c == '[' && strings.Contains(glob[i:], "]"):
+			end := i + strings.Index(glob[i:], "]")
+			class := glob[i+1 : end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			expr.WriteString("[" + class + "]")
+			i = end
+		case c == '\\' && i+1 < len(glob):
+			i++
+			expr.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return expr.String()
+}
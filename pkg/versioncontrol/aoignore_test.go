@@ -0,0 +1,53 @@
+package versioncontrol
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAoIgnore(t *testing.T) {
+	ignore, err := ParseAoIgnore(strings.NewReader(`
+# Comments and blank lines are skipped
+
+*.bak.json
+/templates/
+node_modules/
+docs/**/*.yaml
+utv/foo-?.json
+!keep.bak.json
+`))
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"foo.json", false},
+		{"foo.bak.json", true},
+		{"utv/foo.bak.json", true},
+		{"keep.bak.json", false},
+		{"templates/foo.json", true},
+		{"utv/templates/foo.json", false},
+		{"templates.json", false},
+		{"node_modules/pkg/package.json", true},
+		{"web/node_modules/package.json", true},
+		{"docs/example.yaml", true},
+		{"docs/a/b/example.yaml", true},
+		{"docs/example.json", false},
+		{"utv/foo-1.json", true},
+		{"utv/foo-10.json", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ignore.Ignored(tt.name))
+		})
+	}
+}
+
+func TestLoadAoIgnore(t *testing.T) {
+	ignore, err := LoadAoIgnore("/path/that/does/not/exist")
+	assert.NoError(t, err)
+	assert.False(t, ignore.Ignored("foo.json"))
+}
@@ -0,0 +1,292 @@
+package versioncontrol
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/skatteetaten/ao/pkg/auroraconfig"
+)
+
+// Git hooks that validate the AuroraConfig
+const (
+	HookPreCommit = "pre-commit"
+	HookPrePush   = "pre-push"
+)
+
+// HookTypes are the git hooks ao can install
+var HookTypes = []string{HookPreCommit, HookPrePush}
+
+// auroraConfigExtensions are the extensions of the files in an AuroraConfig
+var auroraConfigExtensions = []string{".json", ".yaml", ".yml"}
+
+// zeroObjectID is the object id git uses for refs that do not exist
+const zeroObjectID = "0000000000000000000000000000000000000000"
+
+// CreateGitValidateHook creates a git hook that validates the files changed by a commit or push
+func CreateGitValidateHook(gitPath, hookType, auroraConfig string) error {
+	if !isHookType(hookType) {
+		return errors.Errorf("unsupported git hook %s, must be one of %v", hookType, HookTypes)
+	}
+
+	hookScript := fmt.Sprintf("#!/bin/sh\nexec ao validate --hook %s -a %s \"$@\"\n", hookType, auroraConfig)
+	gitHookFile := filepath.Join(gitPath, ".git", "hooks", hookType)
+	return ioutil.WriteFile(gitHookFile, []byte(hookScript), 0755)
+}
+
+func isHookType(hookType string) bool {
+	for _, t := range HookTypes {
+		if t == hookType {
+			return true
+		}
+	}
+	return false
+}
+
+// PushedRef is a ref pushed by git push, as given to the pre-push hook on stdin
+type PushedRef struct {
+	LocalRef  string
+	LocalSHA  string
+	RemoteRef string
+	RemoteSHA string
+}
+
+// IsDelete is true when the push deletes the remote ref
+func (r PushedRef) IsDelete() bool {
+	return r.LocalSHA == zeroObjectID
+}
+
+// ReadPushedRefs reads the lines "<local ref> <local sha> <remote ref> <remote sha>" given to the pre-push hook
+func ReadPushedRefs(in io.Reader) ([]PushedRef, error) {
+	var refs []PushedRef
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 4 {
+			return nil, errors.Errorf("unexpected input to pre-push hook: %s", scanner.Text())
+		}
+		refs = append(refs, PushedRef{LocalRef: fields[0], LocalSHA: fields[1], RemoteRef: fields[2], RemoteSHA: fields[3]})
+	}
+	return refs, scanner.Err()
+}
+
+// StagedFiles returns the files that are added, changed or deleted in the index
+func StagedFiles(gitRoot string) ([]string, error) {
+	out, err := git(gitRoot, nil, "diff", "--cached", "--name-only", "--no-renames", "-z")
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(out), nil
+}
+
+// PushedFiles returns the files that are added, changed or deleted by a pushed ref. All files are returned if
+// the remote ref is new, or if the remote commit is not known locally.
+func PushedFiles(gitRoot string, ref PushedRef) ([]string, error) {
+	if ref.RemoteSHA != zeroObjectID {
+		if _, err := git(gitRoot, nil, "cat-file", "-e", ref.RemoteSHA+"^{commit}"); err == nil {
+			out, err := git(gitRoot, nil, "diff", "--name-only", "--no-renames", "-z", ref.RemoteSHA, ref.LocalSHA)
+			if err != nil {
+				return nil, err
+			}
+			return splitNul(out), nil
+		}
+	}
+
+	out, err := git(gitRoot, nil, "ls-tree", "-r", "--name-only", "-z", ref.LocalSHA)
+	if err != nil {
+		return nil, err
+	}
+	return splitNul(out), nil
+}
+
+// gitSymlinkMode is the mode of symbolic links in the index and in trees
+const gitSymlinkMode = "120000"
+
+// gitObject is a blob in the index or in a tree
+type gitObject struct {
+	mode string
+	id   string
+}
+
+// CollectAuroraConfigFilesInIndex finds the AuroraConfig as it will be committed, from the staged files
+func CollectAuroraConfigFilesInIndex(affiliation, gitRoot string) (*auroraconfig.AuroraConfig, error) {
+	out, err := git(gitRoot, nil, "ls-files", "--stage", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	// Each entry is "<mode> <object> <stage>\t<path>"
+	objects := make(map[string]gitObject)
+	for _, entry := range splitNul(out) {
+		split := strings.SplitN(entry, "\t", 2)
+		fields := strings.Fields(split[0])
+		if len(split) != 2 || len(fields) != 3 || fields[2] != "0" {
+			continue
+		}
+		objects[split[1]] = gitObject{mode: fields[0], id: fields[1]}
+	}
+	return collectObjects(affiliation, gitRoot, objects)
+}
+
+// CollectAuroraConfigFilesInCommit finds the AuroraConfig as it is in a commit
+func CollectAuroraConfigFilesInCommit(affiliation, gitRoot, commit string) (*auroraconfig.AuroraConfig, error) {
+	out, err := git(gitRoot, nil, "ls-tree", "-r", "-z", commit)
+	if err != nil {
+		return nil, err
+	}
+
+	// Each entry is "<mode> <type> <object>\t<path>"
+	objects := make(map[string]gitObject)
+	for _, entry := range splitNul(out) {
+		split := strings.SplitN(entry, "\t", 2)
+		fields := strings.Fields(split[0])
+		if len(split) != 2 || len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+		objects[split[1]] = gitObject{mode: fields[0], id: fields[2]}
+	}
+	return collectObjects(affiliation, gitRoot, objects)
+}
+
+// collectObjects reads the AuroraConfig files among the given git objects, by path. The files are selected as
+// in CollectAuroraConfigFilesInRepo, and symbolic links are followed when they point to a file in the same tree.
+func collectObjects(affiliation, gitRoot string, objects map[string]gitObject) (*auroraconfig.AuroraConfig, error) {
+	ac := &auroraconfig.AuroraConfig{
+		Name: affiliation,
+	}
+
+	ignore, err := LoadAoIgnore(gitRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for name := range objects {
+		if HasOneOfExtension(name, auroraConfigExtensions) && skipReason(name, ignore) == "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if err := resolveSymlinks(gitRoot, objects, names); err != nil {
+		return nil, err
+	}
+	var found, ids []string
+	for _, name := range names {
+		if object, ok := objects[name]; ok {
+			found = append(found, name)
+			ids = append(ids, object.id)
+		}
+	}
+	if len(found) == 0 {
+		return ac, nil
+	}
+
+	contents, err := readObjects(gitRoot, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range found {
+		ac.Files = append(ac.Files, auroraconfig.File{
+			Name:     name,
+			Contents: contents[i],
+		})
+	}
+	return ac, nil
+}
+
+// resolveSymlinks replaces the symbolic links among names with the objects they point to, and removes
+// the links that do not point to a file in the tree
+func resolveSymlinks(gitRoot string, objects map[string]gitObject, names []string) error {
+	var links, ids []string
+	for _, name := range names {
+		if objects[name].mode == gitSymlinkMode {
+			links = append(links, name)
+			ids = append(ids, objects[name].id)
+		}
+	}
+	if len(links) == 0 {
+		return nil
+	}
+
+	targets, err := readObjects(gitRoot, ids)
+	if err != nil {
+		return err
+	}
+	for i, name := range links {
+		target := path.Clean(path.Join(path.Dir(name), targets[i]))
+		if object, ok := objects[target]; ok && object.mode != gitSymlinkMode && !path.IsAbs(targets[i]) {
+			objects[name] = object
+		} else {
+			delete(objects, name)
+		}
+	}
+	return nil
+}
+
+// readObjects reads the contents of git objects with a single git cat-file --batch
+func readObjects(gitRoot string, ids []string) ([]string, error) {
+	out, err := git(gitRoot, strings.NewReader(strings.Join(ids, "\n")+"\n"), "cat-file", "--batch")
+	if err != nil {
+		return nil, err
+	}
+
+	// Each object is "<object> <type> <size>\n<contents>\n"
+	reader := bufio.NewReader(bytes.NewReader(out))
+	contents := make([]string, 0, len(ids))
+	for range ids {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, errors.Wrap(err, "unexpected output from git cat-file")
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			return nil, errors.Errorf("could not read git object: %s", strings.TrimSpace(header))
+		}
+		size, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, errors.Wrap(err, "unexpected output from git cat-file")
+		}
+		data := make([]byte, size+1)
+		if _, err := io.ReadFull(reader, data); err != nil {
+			return nil, errors.Wrap(err, "unexpected output from git cat-file")
+		}
+		contents = append(contents, string(data[:size]))
+	}
+	return contents, nil
+}
+
+// git runs a git command in gitRoot and returns its output
+func git(gitRoot string, stdin io.Reader, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", append([]string{"-C", gitRoot}, args...)...)
+	cmd.Stdin = stdin
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Errorf("git %s failed: %s", args[0], strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+func splitNul(out []byte) []string {
+	var values []string
+	for _, value := range strings.Split(string(out), "\x00") {
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,128 @@
+package versioncontrol
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func gitTestRepo(t *testing.T) string {
+	gitRoot, err := ioutil.TempDir("", "ao-githook")
+	assert.NoError(t, err)
+	_, err = git(gitRoot, nil, "init", "-q")
+	assert.NoError(t, err)
+	return gitRoot
+}
+
+func gitCommit(t *testing.T, gitRoot string, files map[string]string) string {
+	for name, contents := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(gitRoot, name)), 0755))
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(gitRoot, name), []byte(contents), 0644))
+	}
+	_, err := git(gitRoot, nil, "add", "-A")
+	assert.NoError(t, err)
+	_, err = git(gitRoot, nil, "-c", "user.name=ao", "-c", "user.email=ao@example.com", "commit", "-q", "-m", "test")
+	assert.NoError(t, err)
+	sha, err := git(gitRoot, nil, "rev-parse", "HEAD")
+	assert.NoError(t, err)
+	return strings.TrimSpace(string(sha))
+}
+
+func TestStagedFiles(t *testing.T) {
+	gitRoot := gitTestRepo(t)
+	defer os.RemoveAll(gitRoot)
+	gitCommit(t, gitRoot, map[string]string{"about.json": `{}`, "foo.json": `{}`, "utv/foo.json": `{}`})
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(gitRoot, "foo.json"), []byte(`{"version": "1"}`), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(gitRoot, "bar.json"), []byte(`{}`), 0644))
+	_, err := git(gitRoot, nil, "add", "foo.json")
+	assert.NoError(t, err)
+	_, err = git(gitRoot, nil, "rm", "-q", "utv/foo.json")
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(gitRoot, "foo.json"), []byte(`{"version": "2"}`), 0644))
+
+	changed, err := StagedFiles(gitRoot)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo.json", "utv/foo.json"}, changed)
+
+	ac, err := CollectAuroraConfigFilesInIndex("paas", gitRoot)
+	assert.NoError(t, err)
+	assert.Equal(t, "paas", ac.Name)
+	assert.Len(t, ac.Files, 2)
+	assert.Equal(t, "foo.json", ac.Files[1].Name)
+	assert.Equal(t, `{"version": "1"}`, ac.Files[1].Contents, "the staged contents should be used")
+}
+
+func TestPushedFiles(t *testing.T) {
+	gitRoot := gitTestRepo(t)
+	defer os.RemoveAll(gitRoot)
+	first := gitCommit(t, gitRoot, map[string]string{"about.json": `{}`, "foo.json": `{}`, "README.md": "ao"})
+	second := gitCommit(t, gitRoot, map[string]string{"utv/foo.yaml": "version: 1\n"})
+
+	changed, err := PushedFiles(gitRoot, PushedRef{LocalSHA: second, RemoteSHA: first})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"utv/foo.yaml"}, changed)
+
+	changed, err = PushedFiles(gitRoot, PushedRef{LocalSHA: second, RemoteSHA: zeroObjectID})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"README.md", "about.json", "foo.json", "utv/foo.yaml"}, changed)
+
+	ac, err := CollectAuroraConfigFilesInCommit("paas", gitRoot, first)
+	assert.NoError(t, err)
+	assert.Len(t, ac.Files, 2)
+}
+
+func TestReadPushedRefs(t *testing.T) {
+	in := "refs/heads/master 1111111111111111111111111111111111111111 refs/heads/master 2222222222222222222222222222222222222222\n" +
+		"(delete) 0000000000000000000000000000000000000000 refs/heads/old 3333333333333333333333333333333333333333\n"
+
+	refs, err := ReadPushedRefs(strings.NewReader(in))
+	assert.NoError(t, err)
+	assert.Len(t, refs, 2)
+	assert.Equal(t, "refs/heads/master", refs[0].LocalRef)
+	assert.Equal(t, "2222222222222222222222222222222222222222", refs[0].RemoteSHA)
+	assert.False(t, refs[0].IsDelete())
+	assert.True(t, refs[1].IsDelete())
+
+	_, err = ReadPushedRefs(strings.NewReader("refs/heads/master\n"))
+	assert.Error(t, err)
+}
+
+func TestCreateGitValidateHookTypes(t *testing.T) {
+	gitRoot := gitTestRepo(t)
+	defer os.RemoveAll(gitRoot)
+
+	assert.NoError(t, CreateGitValidateHook(gitRoot, HookPreCommit, "paas"))
+	data, err := ioutil.ReadFile(filepath.Join(gitRoot, ".git", "hooks", HookPreCommit))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "exec ao validate --hook pre-commit -a paas")
+
+	assert.Error(t, CreateGitValidateHook(gitRoot, "post-merge", "paas"))
+}
+
+func TestCollectAuroraConfigFilesInCommitFollowsSymlinks(t *testing.T) {
+	gitRoot := gitTestRepo(t)
+	defer os.RemoveAll(gitRoot)
+	assert.NoError(t, os.MkdirAll(filepath.Join(gitRoot, "utv"), 0755))
+	assert.NoError(t, os.Symlink("../foo.yml", filepath.Join(gitRoot, "utv", "foo.yml")))
+	assert.NoError(t, os.Symlink("/etc/hostname.json", filepath.Join(gitRoot, "bar.json")))
+	commit := gitCommit(t, gitRoot, map[string]string{
+		".aoignore":          "templates/\n",
+		"foo.yml":            "version: 1\n",
+		"templates/foo.json": `{}`,
+	})
+
+	ac, err := CollectAuroraConfigFilesInCommit("paas", gitRoot, commit)
+	assert.NoError(t, err)
+	assert.Len(t, ac.Files, 2)
+	assert.Equal(t, "utv/foo.yml", ac.Files[1].Name)
+	assert.Equal(t, "version: 1\n", ac.Files[1].Contents)
+
+	ac, err = CollectAuroraConfigFilesInIndex("paas", gitRoot)
+	assert.NoError(t, err)
+	assert.Len(t, ac.Files, 2)
+}
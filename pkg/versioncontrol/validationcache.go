@@ -0,0 +1,101 @@
+package versioncontrol
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/skatteetaten/ao/pkg/auroraconfig"
+)
+
+// validationCacheFile is kept in the .git folder, so that it is never committed
+const validationCacheFile = "ao-validation-cache.json"
+
+// validationCacheSize is the number of validations kept in the cache
+const validationCacheSize = 100
+
+// CachedValidation is a successful remote validation of a set of AuroraConfig files. Full validations are not
+// cached, as they depend on the state of the cluster and not only on the files.
+type CachedValidation struct {
+	Warnings    string    `json:"warnings"`
+	ValidatedAt time.Time `json:"validatedAt"`
+}
+
+// ValidationCache holds successful remote validations, keyed by the content hash of the validated files
+type ValidationCache struct {
+	file        string
+	Validations map[string]CachedValidation `json:"validations"`
+}
+
+// LoadValidationCache loads the validation cache of a git repo. A missing or unreadable cache is empty.
+func LoadValidationCache(gitRoot string) *ValidationCache {
+	cache := &ValidationCache{
+		file:        filepath.Join(gitRoot, ".git", validationCacheFile),
+		Validations: make(map[string]CachedValidation),
+	}
+
+	data, err := ioutil.ReadFile(cache.file)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, cache); err != nil || cache.Validations == nil {
+		cache.Validations = make(map[string]CachedValidation)
+	}
+	return cache
+}
+
+// ValidationCacheKey returns the content hash of an AuroraConfig, validated by the Boober at apiHost for the given ref
+func ValidationCacheKey(ac *auroraconfig.AuroraConfig, apiHost, ref string) string {
+	files := make([]auroraconfig.File, len(ac.Files))
+	copy(files, ac.Files)
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].Name < files[j].Name
+	})
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\x00%s\x00%s\x00", apiHost, ref, ac.Name)
+	for _, file := range files {
+		contentHash := sha256.Sum256([]byte(file.Contents))
+		fmt.Fprintf(hash, "%s\x00%x\x00", file.Name, contentHash)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Get returns a cached validation
+func (c *ValidationCache) Get(key string) (CachedValidation, bool) {
+	validation, found := c.Validations[key]
+	return validation, found
+}
+
+// Put adds a successful validation, and removes the oldest validations when the cache is full
+func (c *ValidationCache) Put(key, warnings string, now time.Time) {
+	c.Validations[key] = CachedValidation{Warnings: warnings, ValidatedAt: now}
+
+	if len(c.Validations) <= validationCacheSize {
+		return
+	}
+	keys := make([]string, 0, len(c.Validations))
+	for k := range c.Validations {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return c.Validations[keys[i]].ValidatedAt.After(c.Validations[keys[j]].ValidatedAt)
+	})
+	for _, k := range keys[validationCacheSize:] {
+		delete(c.Validations, k)
+	}
+}
+
+// Save writes the cache to the .git folder
+func (c *ValidationCache) Save() error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.file, data, 0644)
+}
@@ -0,0 +1,73 @@
+package versioncontrol
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/skatteetaten/ao/pkg/auroraconfig"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidationCacheKey(t *testing.T) {
+	ac := &auroraconfig.AuroraConfig{
+		Name: "paas",
+		Files: []auroraconfig.File{
+			{Name: "about.json", Contents: `{}`},
+			{Name: "utv/foo.json", Contents: `{}`},
+		},
+	}
+	reordered := &auroraconfig.AuroraConfig{
+		Name:  "paas",
+		Files: []auroraconfig.File{ac.Files[1], ac.Files[0]},
+	}
+	changed := &auroraconfig.AuroraConfig{
+		Name:  "paas",
+		Files: []auroraconfig.File{ac.Files[0], {Name: "utv/foo.json", Contents: `{"version": "1"}`}},
+	}
+
+	const host, ref = "https://boober-utv", "master"
+	key := ValidationCacheKey(ac, host, ref)
+	assert.Equal(t, key, ValidationCacheKey(reordered, host, ref))
+	assert.NotEqual(t, key, ValidationCacheKey(changed, host, ref))
+	assert.NotEqual(t, key, ValidationCacheKey(ac, "https://boober-test", ref))
+	assert.NotEqual(t, key, ValidationCacheKey(ac, host, "feature"))
+	assert.Equal(t, "utv/foo.json", ac.Files[1].Name, "the files should not be sorted in place")
+}
+
+func TestValidationCache(t *testing.T) {
+	gitRoot, err := ioutil.TempDir("", "ao-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(gitRoot)
+	assert.NoError(t, os.Mkdir(filepath.Join(gitRoot, ".git"), 0755))
+
+	cache := LoadValidationCache(gitRoot)
+	_, found := cache.Get("key")
+	assert.False(t, found)
+
+	now := time.Date(2020, 10, 1, 12, 0, 0, 0, time.UTC)
+	cache.Put("key", "warning", now)
+	assert.NoError(t, cache.Save())
+
+	validation, found := LoadValidationCache(gitRoot).Get("key")
+	assert.True(t, found)
+	assert.Equal(t, "warning", validation.Warnings)
+	assert.True(t, now.Equal(validation.ValidatedAt))
+
+	t.Run("Should remove the oldest validations when full", func(t *testing.T) {
+		for i := 1; i <= validationCacheSize; i++ {
+			cache.Put(strconv.Itoa(i), "", now.Add(time.Duration(i)*time.Minute))
+		}
+		assert.Len(t, cache.Validations, validationCacheSize)
+		_, found := cache.Get("key")
+		assert.False(t, found)
+	})
+
+	t.Run("Should ignore a corrupt cache", func(t *testing.T) {
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(gitRoot, ".git", validationCacheFile), []byte("{"), 0644))
+		assert.Empty(t, LoadValidationCache(gitRoot).Validations)
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/skatteetaten/ao/pkg/auroraconfig"
@@ -19,18 +20,6 @@ func Checkout(url string, outputPath string) error {
 	return cmd.Run()
 }
 
-// CreateGitValidateHook creates a git validate
-func CreateGitValidateHook(gitPath, hookType, auroraConfig string) error {
-	hookScript := "#!/bin/bash\nexec ao validate -a " + auroraConfig
-	gitHookFile := fmt.Sprintf("%s/.git/hooks/%s", gitPath, hookType)
-	err := ioutil.WriteFile(gitHookFile, []byte(hookScript), 0755)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
 // GetGitURL gets git URL for an affiliation
 func GetGitURL(affiliation, user, gitURLPattern string) string {
 	if !strings.Contains(gitURLPattern, "https://") {
@@ -60,31 +49,117 @@ func FindGitPath(path string) (string, error) {
 	return FindGitPath(next)
 }
 
-// CollectAuroraConfigFilesInRepo finds complete AuroraConfig for an affiliation
-func CollectAuroraConfigFilesInRepo(affiliation, gitRoot string) (*auroraconfig.AuroraConfig, error) {
+// SkippedFile is a file in the repo that is not collected as part of the AuroraConfig
+type SkippedFile struct {
+	Name   string
+	Reason string
+}
+
+// CollectAuroraConfigFilesInRepo finds complete AuroraConfig for an affiliation. Only the files git tracks, or would
+// track according to .gitignore, are collected, except hidden files and the files matching .aoignore. The files
+// with AuroraConfig extensions that are left out are returned with the reason they are skipped.
+func CollectAuroraConfigFilesInRepo(affiliation, gitRoot string) (*auroraconfig.AuroraConfig, []SkippedFile, error) {
 	ac := &auroraconfig.AuroraConfig{
 		Name: affiliation,
 	}
 
-	return ac, filepath.Walk(gitRoot, func(path string, info os.FileInfo, err error) error {
+	ignore, err := LoadAoIgnore(gitRoot)
+	if err != nil {
+		return nil, nil, err
+	}
+	realRoot, err := filepath.EvalSymlinks(gitRoot)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		fileName := strings.TrimPrefix(path, gitRoot+string(filepath.Separator))
+	out, err := git(gitRoot, nil, "ls-files", "--cached", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, nil, err
+	}
+	var skipped []SkippedFile
+	for _, fileName := range splitNul(out) {
+		if !HasOneOfExtension(fileName, auroraConfigExtensions) {
+			continue
+		}
+		if reason := skipReason(fileName, ignore); reason != "" {
+			skipped = append(skipped, SkippedFile{Name: fileName, Reason: reason})
+			continue
+		}
 
-		if !HasOneOfExtension(fileName, []string{".json", ".yaml"}) {
-			return nil
+		path := filepath.Join(gitRoot, filepath.FromSlash(fileName))
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			// Deleted, but not yet staged
+			continue
+		} else if err != nil {
+			return nil, nil, errors.Wrap(err, "Could not read file "+fileName)
+		}
+		if reason := fileSkipReason(realRoot, path, info); reason != "" {
+			skipped = append(skipped, SkippedFile{Name: fileName, Reason: reason})
+			continue
 		}
 
-		file, err := ioutil.ReadFile(filepath.Join(gitRoot, fileName))
+		file, err := ioutil.ReadFile(path)
 		if err != nil {
-			return errors.Wrap(err, "Could not read file "+fileName)
+			return nil, nil, errors.Wrap(err, "Could not read file "+fileName)
 		}
-
 		ac.Files = append(ac.Files, auroraconfig.File{
 			Name:     fileName,
 			Contents: string(file),
 		})
-		return nil
+	}
+
+	out, err = git(gitRoot, nil, "ls-files", "--others", "--ignored", "--exclude-standard", "--directory", "-z")
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, fileName := range splitNul(out) {
+		if strings.HasSuffix(fileName, "/") || HasOneOfExtension(fileName, auroraConfigExtensions) {
+			skipped = append(skipped, SkippedFile{Name: fileName, Reason: "ignored by git"})
+		}
+	}
+
+	sort.Slice(ac.Files, func(i, j int) bool {
+		return ac.Files[i].Name < ac.Files[j].Name
 	})
+	sort.Slice(skipped, func(i, j int) bool {
+		return skipped[i].Name < skipped[j].Name
+	})
+	return ac, skipped, nil
+}
+
+// skipReason tells why a file in the repo is not part of the AuroraConfig, or is empty if it is
+func skipReason(fileName string, ignore *AoIgnore) string {
+	for _, part := range strings.Split(fileName, "/") {
+		if strings.HasPrefix(part, ".") {
+			return "hidden"
+		}
+	}
+	if ignore.Ignored(fileName) {
+		return "ignored by " + AoIgnoreFileName
+	}
+	return ""
+}
+
+// fileSkipReason tells why a file in the working tree can not be read as part of the AuroraConfig.
+// Symbolic links are followed if they point to a file in the repo.
+func fileSkipReason(realRoot, path string, info os.FileInfo) string {
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return "broken symbolic link"
+		}
+		if rel, err := filepath.Rel(realRoot, target); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "symbolic link to a file outside the repo"
+		}
+		if info, err = os.Stat(target); err != nil {
+			return "broken symbolic link"
+		}
+	}
+	if info.IsDir() {
+		return "not a file"
+	}
+	return ""
 }
 
 // HasOneOfExtension checks text for an array of suffixes
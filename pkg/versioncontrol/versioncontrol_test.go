@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -132,7 +133,7 @@ func TestCollectJSONFilesInRepo(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := CollectAuroraConfigFilesInRepo("aurora", tt.gitRoot)
+			got, _, err := CollectAuroraConfigFilesInRepo("aurora", tt.gitRoot)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("CollectJSONFilesInRepo() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -177,3 +178,54 @@ func TestCreateGitValidateHook(t *testing.T) {
 		})
 	}
 }
+
+func TestCollectAuroraConfigFilesInRepoSkipsFiles(t *testing.T) {
+	gitRoot := gitTestRepo(t)
+	defer os.RemoveAll(gitRoot)
+	outside, err := ioutil.TempDir("", "ao-outside")
+	assert.NoError(t, err)
+	defer os.RemoveAll(outside)
+
+	files := map[string]string{
+		".gitignore":                       "node_modules/\n*.swp.json\n",
+		".aoignore":                        "templates/\n",
+		".ao.yaml":                         "affiliation: paas\n",
+		"about.json":                       `{}`,
+		"foo.yml":                          "version: 1\n",
+		"utv/about.yaml":                   "cluster: utv\n",
+		"utv/foo.json":                     `{}`,
+		"utv/foo.swp.json":                 `{}`,
+		"node_modules/pkg/x.json":          `{}`,
+		"templates/foo.json":               `{}`,
+		"README.md":                        "paas",
+		filepath.Join(outside, "bar.json"): `{}`,
+	}
+	for name, contents := range files {
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(gitRoot, name)
+		}
+		assert.NoError(t, os.MkdirAll(filepath.Dir(name), 0755))
+		assert.NoError(t, ioutil.WriteFile(name, []byte(contents), 0644))
+	}
+	assert.NoError(t, os.Symlink("../foo.yml", filepath.Join(gitRoot, "utv", "bar.yml")))
+	assert.NoError(t, os.Symlink(filepath.Join(outside, "bar.json"), filepath.Join(gitRoot, "bar.json")))
+	assert.NoError(t, os.Symlink("missing.json", filepath.Join(gitRoot, "baz.json")))
+
+	ac, skipped, err := CollectAuroraConfigFilesInRepo("paas", gitRoot)
+	assert.NoError(t, err)
+
+	var names []string
+	for _, file := range ac.Files {
+		names = append(names, file.Name)
+	}
+	assert.Equal(t, []string{"about.json", "foo.yml", "utv/about.yaml", "utv/bar.yml", "utv/foo.json"}, names)
+	assert.Equal(t, "version: 1\n", ac.Files[3].Contents)
+	assert.Equal(t, []SkippedFile{
+		{Name: ".ao.yaml", Reason: "hidden"},
+		{Name: "bar.json", Reason: "symbolic link to a file outside the repo"},
+		{Name: "baz.json", Reason: "broken symbolic link"},
+		{Name: "node_modules/", Reason: "ignored by git"},
+		{Name: "templates/foo.json", Reason: "ignored by .aoignore"},
+		{Name: "utv/foo.swp.json", Reason: "ignored by git"},
+	}, skipped)
+}